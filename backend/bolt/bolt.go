@@ -5,9 +5,11 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash"
+	"io"
 	"os"
 	stdpath "path"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/boltdb/bolt"
@@ -22,14 +24,37 @@ const (
 var (
 	ErrInvalidDBPath     = fmt.Errorf("db file doesn't exist")
 	ErrDuplicateResource = fmt.Errorf("duplicate resource")
+	ErrChecksumMismatch  = fmt.Errorf("checksum of snapshot mismatch")
 )
 
 type BoltDB struct {
-	path string
+	path    string
+	options Options
+
+	//db is replaced by Restore and Compact, read lock is held while it's
+	//used, so it isn't closed or swapped in the middle
+	lock sync.RWMutex
 	db   *bolt.DB
 }
 
+type Options struct {
+	//time to wait to obtain the file lock, zero means wait indefinitely
+	Timeout time.Duration
+	//skip fsync after each commit, data may be lost if os crashes
+	NoSync bool
+	//initial mmap size of the db file in bytes
+	InitialMmapSize int
+	//open the db file with shared lock, write transaction isn't allowed
+	ReadOnly bool
+}
+
 func New(path string) (kvzoo.DB, error) {
+	return NewWithOptions(path, Options{
+		Timeout: openTimeout,
+	})
+}
+
+func NewWithOptions(path string, options Options) (kvzoo.DB, error) {
 	if path == "" {
 		return nil, ErrInvalidDBPath
 	}
@@ -41,26 +66,44 @@ func New(path string) (kvzoo.DB, error) {
 		}
 	}
 
-	db, err := bolt.Open(path, 0664, &bolt.Options{
-		Timeout: openTimeout,
-	})
+	db, err := open(path, options)
 	if err != nil {
 		return nil, err
 	}
 
 	return &BoltDB{
-		db:   db,
-		path: path,
+		db:      db,
+		path:    path,
+		options: options,
 	}, nil
 }
 
+func open(path string, options Options) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0664, &bolt.Options{
+		Timeout:         options.Timeout,
+		ReadOnly:        options.ReadOnly,
+		InitialMmapSize: options.InitialMmapSize,
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.NoSync = options.NoSync
+	return db, nil
+}
+
 func (db *BoltDB) Checksum() (string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	tx, err := db.db.Begin(false)
 	if err != nil {
 		return "", err
 	}
 	defer tx.Rollback()
 
+	return checksum(tx), nil
+}
+
+func checksum(tx *bolt.Tx) string {
 	h := md5.New()
 	c := tx.Cursor()
 	k, v := c.First()
@@ -69,7 +112,7 @@ func (db *BoltDB) Checksum() (string, error) {
 		if v != nil {
 			h.Write(v)
 		} else {
-			db.bucketCheckSum(h, tx.Bucket(k))
+			bucketCheckSum(h, tx.Bucket(k))
 		}
 		for {
 			k, v := c.Next()
@@ -80,14 +123,14 @@ func (db *BoltDB) Checksum() (string, error) {
 			if v != nil {
 				h.Write(v)
 			} else {
-				db.bucketCheckSum(h, tx.Bucket(k))
+				bucketCheckSum(h, tx.Bucket(k))
 			}
 		}
 	}
-	return hex.EncodeToString(h.Sum(nil)[:16]), nil
+	return hex.EncodeToString(h.Sum(nil)[:16])
 }
 
-func (db *BoltDB) bucketCheckSum(h hash.Hash, b *bolt.Bucket) {
+func bucketCheckSum(h hash.Hash, b *bolt.Bucket) {
 	c := b.Cursor()
 	k, v := c.First()
 	if k != nil {
@@ -95,7 +138,7 @@ func (db *BoltDB) bucketCheckSum(h hash.Hash, b *bolt.Bucket) {
 		if v != nil {
 			h.Write(v)
 		} else {
-			db.bucketCheckSum(h, b.Bucket(k))
+			bucketCheckSum(h, b.Bucket(k))
 		}
 		for {
 			k, v := c.Next()
@@ -106,13 +149,209 @@ func (db *BoltDB) bucketCheckSum(h hash.Hash, b *bolt.Bucket) {
 			if v != nil {
 				h.Write(v)
 			} else {
-				db.bucketCheckSum(h, b.Bucket(k))
+				bucketCheckSum(h, b.Bucket(k))
 			}
 		}
 	}
 }
 
+// Backup write the whole db to w in one read transaction, so the snapshot
+// is consistent even when other transactions are running
+func (db *BoltDB) Backup(w io.Writer) (string, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+
+	cs := checksum(tx)
+	if _, err := tx.WriteTo(w); err != nil {
+		return "", err
+	}
+	return cs, nil
+}
+
+// Restore replace current db with the snapshot read from r, snapshot is
+// verified against cs before it's used, all the transactions should be
+// finished before restore
+func (db *BoltDB) Restore(r io.Reader, cs string) error {
+	tmpPath := db.path + ".restore"
+	if err := writeSnapshot(tmpPath, r, cs); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	db.lock.Lock()
+	defer db.lock.Unlock()
+	return db.replaceWith(tmpPath)
+}
+
+// Compact rewrites all the data into a new db file to release the free
+// pages, all the transactions should be finished before compact
+func (db *BoltDB) Compact() error {
+	db.lock.Lock()
+	defer db.lock.Unlock()
+
+	tmpPath := db.path + ".compact"
+	os.Remove(tmpPath)
+	dst, err := open(tmpPath, db.options)
+	if err != nil {
+		return err
+	}
+
+	err = db.db.View(func(src *bolt.Tx) error {
+		return dst.Update(func(tx *bolt.Tx) error {
+			return src.ForEach(func(name []byte, b *bolt.Bucket) error {
+				bucket, err := tx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(bucket, b)
+			})
+		})
+	})
+	if err_ := dst.Close(); err == nil {
+		err = err_
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return db.replaceWith(tmpPath)
+}
+
+func copyBucket(dst, src *bolt.Bucket) error {
+	//keys are inserted in order, so fill the pages fully
+	dst.FillPercent = 1.0
+	return src.ForEach(func(k, v []byte) error {
+		if v != nil {
+			return dst.Put(k, v)
+		}
+
+		bucket, err := dst.CreateBucket(k)
+		if err != nil {
+			return err
+		}
+		return copyBucket(bucket, src.Bucket(k))
+	})
+}
+
+// replaceWith swaps the db file with the file of path, the original file is
+// kept until the new one is opened, so it's reopened if the swap fails.
+// caller should hold the write lock
+func (db *BoltDB) replaceWith(path string) error {
+	origPath := db.path + ".orig"
+	err := db.db.Close()
+	if err == nil {
+		err = os.Rename(db.path, origPath)
+	}
+	if err != nil {
+		os.Remove(path)
+		return db.reopen(err)
+	}
+
+	if err := os.Rename(path, db.path); err != nil {
+		os.Remove(path)
+		return db.restoreOriginal(origPath, err)
+	}
+
+	bdb, err := open(db.path, db.options)
+	if err != nil {
+		os.Remove(db.path)
+		return db.restoreOriginal(origPath, err)
+	}
+	db.db = bdb
+	os.Remove(origPath)
+	return nil
+}
+
+func (db *BoltDB) restoreOriginal(origPath string, cause error) error {
+	if err := os.Rename(origPath, db.path); err != nil {
+		return fmt.Errorf("%s, and restore original db failed:%s", cause.Error(), err.Error())
+	}
+	return db.reopen(cause)
+}
+
+// reopen opens the db file again after it's closed for a failed swap, cause
+// is returned if it succeeds
+func (db *BoltDB) reopen(cause error) error {
+	bdb, err := open(db.path, db.options)
+	if err != nil {
+		return fmt.Errorf("%s, and reopen db failed:%s", cause.Error(), err.Error())
+	}
+	db.db = bdb
+	return cause
+}
+
+func writeSnapshot(path string, r io.Reader, cs string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0664)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	snapshot, err := open(path, Options{
+		Timeout:  openTimeout,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer snapshot.Close()
+
+	tx, err := snapshot.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if checksum(tx) != cs {
+		return ErrChecksumMismatch
+	}
+	return nil
+}
+
+func (db *BoltDB) DBStats() (*kvzoo.DBStats, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	info, err := os.Stat(db.path)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := db.db.Stats()
+	return &kvzoo.DBStats{
+		FileSize:      info.Size(),
+		FreePageN:     stats.FreePageN,
+		PendingPageN:  stats.PendingPageN,
+		FreeAlloc:     stats.FreeAlloc,
+		FreelistInuse: stats.FreelistInuse,
+		TxN:           stats.TxN,
+		OpenTxN:       stats.OpenTxN,
+		PageCount:     stats.TxStats.PageCount,
+		PageAlloc:     stats.TxStats.PageAlloc,
+	}, nil
+}
+
 func (db *BoltDB) Close() error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	return db.db.Close()
 }
 
@@ -124,6 +363,8 @@ func (db *BoltDB) Destroy() error {
 }
 
 func (db *BoltDB) CreateOrGetTable(tableName kvzoo.TableName) (kvzoo.Table, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	tx, err := db.db.Begin(true)
 	if err != nil {
 		return nil, err
@@ -141,11 +382,13 @@ func (db *BoltDB) CreateOrGetTable(tableName kvzoo.TableName) (kvzoo.Table, erro
 
 	return &DBTable{
 		name: string(tableName),
-		db:   db.db,
+		db:   db,
 	}, nil
 }
 
 func (db *BoltDB) DeleteTable(tableName kvzoo.TableName) error {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
 	tx, err := db.db.Begin(true)
 	if err != nil {
 		return err
@@ -176,6 +419,226 @@ func (db *BoltDB) DeleteTable(tableName kvzoo.TableName) error {
 	return tx.Commit()
 }
 
+func (db *BoltDB) ListTables(parent kvzoo.TableName, recursive bool) ([]kvzoo.TableName, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var tables []kvzoo.TableName
+	if parent == kvzoo.Root {
+		err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			tables = appendTables(tables, parent.Child(string(name)), b, recursive)
+			return nil
+		})
+		return tables, err
+	}
+
+	bucket := getBucket(tx, parent)
+	if bucket == nil {
+		return nil, fmt.Errorf("no found table %s", parent)
+	}
+	return appendChildTables(tables, parent, bucket, recursive), nil
+}
+
+func appendTables(tables []kvzoo.TableName, name kvzoo.TableName, b *bolt.Bucket, recursive bool) []kvzoo.TableName {
+	tables = append(tables, name)
+	if recursive {
+		tables = appendChildTables(tables, name, b, recursive)
+	}
+	return tables
+}
+
+func appendChildTables(tables []kvzoo.TableName, parent kvzoo.TableName, b *bolt.Bucket, recursive bool) []kvzoo.TableName {
+	b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			tables = appendTables(tables, parent.Child(string(k)), b.Bucket(k), recursive)
+		}
+		return nil
+	})
+	return tables
+}
+
+func (db *BoltDB) TableExists(tableName kvzoo.TableName) (bool, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	return getBucket(tx, tableName) != nil, nil
+}
+
+func (db *BoltDB) Stats(tableName kvzoo.TableName) (*kvzoo.TableStats, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bucket := getBucket(tx, tableName)
+	if bucket == nil {
+		return nil, fmt.Errorf("no found table %s", tableName)
+	}
+
+	stats := &kvzoo.TableStats{}
+	bucket.ForEach(func(k, v []byte) error {
+		if v == nil {
+			stats.ChildTableCount += 1
+		} else {
+			stats.KeyCount += 1
+			stats.KeySize += int64(len(k))
+			stats.ValueSize += int64(len(v))
+		}
+		return nil
+	})
+
+	bs := bucket.Stats()
+	stats.Storage = kvzoo.StorageStats{
+		BranchPageN:       bs.BranchPageN,
+		BranchOverflowN:   bs.BranchOverflowN,
+		LeafPageN:         bs.LeafPageN,
+		LeafOverflowN:     bs.LeafOverflowN,
+		KeyN:              bs.KeyN,
+		Depth:             bs.Depth,
+		BranchAlloc:       bs.BranchAlloc,
+		BranchInuse:       bs.BranchInuse,
+		LeafAlloc:         bs.LeafAlloc,
+		LeafInuse:         bs.LeafInuse,
+		BucketN:           bs.BucketN,
+		InlineBucketN:     bs.InlineBucketN,
+		InlineBucketInuse: bs.InlineBucketInuse,
+	}
+	return stats, nil
+}
+
+func (db *BoltDB) MerkleTree(tableName kvzoo.TableName, boundaries []string) (*kvzoo.MerkleTree, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bucket := getBucket(tx, tableName)
+	if bucket == nil {
+		return nil, fmt.Errorf("no found table %s", tableName)
+	}
+
+	if boundaries == nil {
+		boundaries = merkleBoundaries(bucket)
+	} else if len(boundaries) != kvzoo.MerkleLeafCount {
+		return nil, fmt.Errorf("merkle tree needs %d boundaries but get %d", kvzoo.MerkleLeafCount, len(boundaries))
+	}
+	cs, nodes := merkleTree(bucket, boundaries)
+	return &kvzoo.MerkleTree{
+		Checksum:   hex.EncodeToString(cs),
+		Boundaries: boundaries,
+		Nodes:      nodes,
+	}, nil
+}
+
+// split keys evenly, one pass to count the keys, another to pick the
+// first key of each leaf
+func merkleBoundaries(b *bolt.Bucket) []string {
+	count := 0
+	b.ForEach(func(k, v []byte) error {
+		if v != nil {
+			count += 1
+		}
+		return nil
+	})
+
+	boundaries := make([]string, kvzoo.MerkleLeafCount)
+	leaf, i := 1, 0
+	b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			return nil
+		}
+		for leaf < kvzoo.MerkleLeafCount && kvzoo.MerkleBoundaryIndex(leaf, count) == i {
+			boundaries[leaf] = string(k)
+			leaf += 1
+		}
+		i += 1
+		return nil
+	})
+	return boundaries
+}
+
+// checksum of table is md5 of the checksum of its keys and the name and
+// checksum of each child table, so it doesn't depend on the boundaries
+func merkleTree(b *bolt.Bucket, boundaries []string) ([]byte, [][]byte) {
+	builder := kvzoo.NewMerkleBuilder(boundaries)
+	var children [][]byte
+	b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			cs, _ := merkleTree(b.Bucket(k), make([]string, kvzoo.MerkleLeafCount))
+			children = append(children, k, cs)
+		} else {
+			builder.Add(k, v)
+		}
+		return nil
+	})
+
+	nodes, keysChecksum := builder.Build()
+	h := md5.New()
+	h.Write(keysChecksum)
+	for _, child := range children {
+		h.Write(child)
+	}
+	return h.Sum(nil), nodes
+}
+
+func (db *BoltDB) KeyDigests(tableName kvzoo.TableName, ranges []kvzoo.KeyRange) (map[string][]byte, error) {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	bucket := getBucket(tx, tableName)
+	if bucket == nil {
+		return nil, fmt.Errorf("no found table %s", tableName)
+	}
+
+	digests := make(map[string][]byte)
+	for _, r := range ranges {
+		c := bucket.Cursor()
+		for k, v := c.Seek([]byte(r.Begin)); k != nil && r.Contains(string(k)); k, v = c.Next() {
+			if v != nil {
+				digests[string(k)] = kvzoo.ValueDigest(v)
+			}
+		}
+	}
+	return digests, nil
+}
+
+func getBucket(tx *bolt.Tx, tableName kvzoo.TableName) *bolt.Bucket {
+	var bucket *bolt.Bucket
+	for i, table := range tableName.Segments() {
+		if i == 0 {
+			bucket = tx.Bucket([]byte(table))
+		} else {
+			bucket = bucket.Bucket([]byte(table))
+		}
+
+		if bucket == nil {
+			return nil
+		}
+	}
+	return bucket
+}
+
 func createOrGetBucket(tx *bolt.Tx, tableName string) (*bolt.Bucket, error) {
 	var bucket *bolt.Bucket
 	var err error
@@ -200,11 +663,13 @@ func createOrGetBucket(tx *bolt.Tx, tableName string) (*bolt.Bucket, error) {
 
 type DBTable struct {
 	name string
-	db   *bolt.DB
+	db   *BoltDB
 }
 
 func (db *DBTable) Begin() (kvzoo.Transaction, error) {
-	tx, err := db.db.Begin(true)
+	db.db.lock.RLock()
+	tx, err := db.db.db.Begin(true)
+	db.db.lock.RUnlock()
 	if err != nil {
 		return nil, err
 	}
@@ -225,6 +690,25 @@ func (db *DBTable) Begin() (kvzoo.Transaction, error) {
 	}, nil
 }
 
+func (db *DBTable) BeginReadOnly() (kvzoo.Transaction, error) {
+	db.db.lock.RLock()
+	tx, err := db.db.db.Begin(false)
+	db.db.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+
+	bucket := getBucket(tx, kvzoo.TableName(db.name))
+	if bucket == nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("table %s is non-exists", db.name)
+	}
+
+	return &TableTX{
+		bucket: bucket,
+	}, nil
+}
+
 type TableTX struct {
 	bucket *bolt.Bucket
 }
@@ -233,7 +717,11 @@ func (tx *TableTX) Rollback() error {
 	return tx.bucket.Tx().Rollback()
 }
 
+// commit of read only transaction is rollback
 func (tx *TableTX) Commit() error {
+	if tx.bucket.Tx().Writable() == false {
+		return tx.bucket.Tx().Rollback()
+	}
 	return tx.bucket.Tx().Commit()
 }
 
@@ -279,3 +767,52 @@ func (tx *TableTX) List() (map[string][]byte, error) {
 
 	return resourceMap, nil
 }
+
+func (tx *TableTX) Cursor() kvzoo.Cursor {
+	return &tableCursor{
+		cursor: tx.bucket.Cursor(),
+	}
+}
+
+// tableCursor skips child tables which are nested buckets with nil value
+type tableCursor struct {
+	cursor *bolt.Cursor
+}
+
+func (c *tableCursor) skipTables(k, v []byte, move func() ([]byte, []byte)) (string, []byte) {
+	for k != nil && v == nil {
+		k, v = move()
+	}
+	if k == nil {
+		return "", nil
+	}
+
+	tmp := make([]byte, len(v))
+	copy(tmp, v)
+	return string(k), tmp
+}
+
+func (c *tableCursor) Seek(key string) (string, []byte) {
+	k, v := c.cursor.Seek([]byte(key))
+	return c.skipTables(k, v, c.cursor.Next)
+}
+
+func (c *tableCursor) First() (string, []byte) {
+	k, v := c.cursor.First()
+	return c.skipTables(k, v, c.cursor.Next)
+}
+
+func (c *tableCursor) Last() (string, []byte) {
+	k, v := c.cursor.Last()
+	return c.skipTables(k, v, c.cursor.Prev)
+}
+
+func (c *tableCursor) Next() (string, []byte) {
+	k, v := c.cursor.Next()
+	return c.skipTables(k, v, c.cursor.Next)
+}
+
+func (c *tableCursor) Prev() (string, []byte) {
+	k, v := c.cursor.Prev()
+	return c.skipTables(k, v, c.cursor.Prev)
+}
@@ -0,0 +1,95 @@
+package bolt
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/boltdb/bolt"
+	"github.com/zdnscloud/kvzoo"
+)
+
+type TableInfo struct {
+	Name      kvzoo.TableName `json:"name"`
+	KeyCount  int             `json:"keyCount"`
+	KeySize   int64           `json:"keySize"`
+	ValueSize int64           `json:"valueSize"`
+	//md5 of the keys and values in the table, child tables aren't included
+	Checksum string       `json:"checksum"`
+	Children []*TableInfo `json:"children,omitempty"`
+}
+
+// OpenReadOnly opens an existing db file without write permission, the file
+// could be inspected while other process holds it in read only mode
+func OpenReadOnly(path string) (*BoltDB, error) {
+	db, err := open(path, Options{
+		Timeout:  openTimeout,
+		ReadOnly: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &BoltDB{
+		db:   db,
+		path: path,
+	}, nil
+}
+
+// Inspect returns the info of all the tables as a tree
+func (db *BoltDB) Inspect() ([]*TableInfo, error) {
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var tables []*TableInfo
+	err = tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+		tables = append(tables, inspectBucket(kvzoo.TableName(kvzoo.Root+string(name)), b))
+		return nil
+	})
+	return tables, err
+}
+
+func inspectBucket(name kvzoo.TableName, b *bolt.Bucket) *TableInfo {
+	info := &TableInfo{
+		Name: name,
+	}
+	h := md5.New()
+	b.ForEach(func(k, v []byte) error {
+		if v == nil {
+			info.Children = append(info.Children, inspectBucket(kvzoo.TableName(string(name)+"/"+string(k)), b.Bucket(k)))
+		} else {
+			info.KeyCount += 1
+			info.KeySize += int64(len(k))
+			info.ValueSize += int64(len(v))
+			h.Write(k)
+			h.Write(v)
+		}
+		return nil
+	})
+	info.Checksum = hex.EncodeToString(h.Sum(nil))
+	return info
+}
+
+// Check verifies the consistency of the db file, all the errors are returned
+// in one error
+func (db *BoltDB) Check() error {
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var errs []string
+	for err := range tx.Check() {
+		errs = append(errs, err.Error())
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%d errors found:\n%s", len(errs), strings.Join(errs, "\n"))
+}
@@ -0,0 +1,27 @@
+package client
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// tokenCredentials sends token as bearer token in authorization metadata,
+// token is sent in plaintext if tls isn't used
+type tokenCredentials string
+
+func (t tokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + string(t),
+	}, nil
+}
+
+func (t tokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// WithTokenCredentials is the dial option for NewClient to authenticate
+// with token
+func WithTokenCredentials(token string) grpc.DialOption {
+	return grpc.WithPerRPCCredentials(tokenCredentials(token))
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/zdnscloud/kvzoo/internal/backupfile"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+const (
+	restoreChunkSize     = 1024 * 1024
+	DefaultBackupTimeout = 10 * time.Minute
+)
+
+// BackupTo streams a consistent snapshot of the server db to w, and returns
+// the checksum of the snapshot
+func (c *Client) BackupTo(w io.Writer) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultBackupTimeout)
+	defer cancel()
+
+	stream, err := c.Backup(ctx, &pb.BackupRequest{})
+	if err != nil {
+		return "", err
+	}
+
+	for {
+		reply, err := stream.Recv()
+		if err == io.EOF {
+			return "", fmt.Errorf("backup stream of %s closed without checksum", c.Target())
+		} else if err != nil {
+			return "", err
+		}
+
+		if len(reply.Data) > 0 {
+			if _, err := w.Write(reply.Data); err != nil {
+				return "", err
+			}
+		}
+
+		if reply.Checksum != "" {
+			return reply.Checksum, nil
+		}
+	}
+}
+
+// RestoreFrom replaces the server db with the snapshot read from r, server
+// will refuse the snapshot if its checksum isn't same with cs
+func (c *Client) RestoreFrom(r io.Reader, cs string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultBackupTimeout)
+	defer cancel()
+
+	stream, err := c.Restore(ctx)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, restoreChunkSize)
+	checksum := cs
+	for {
+		n, err := r.Read(buf)
+		if n > 0 || checksum != "" {
+			if err := stream.Send(&pb.RestoreRequest{
+				Data:     buf[:n],
+				Checksum: checksum,
+			}); err != nil {
+				return err
+			}
+			checksum = ""
+		}
+
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+	}
+
+	_, err = stream.CloseAndRecv()
+	return err
+}
+
+// BackupToDir writes a timestamped backup and its checksum into dir,
+// only the newest retention backups are kept, retention <= 0 means keep all
+func (c *Client) BackupToDir(dir string, retention int) (string, error) {
+	backupFile, err := backupfile.Write(dir, c.BackupTo)
+	if err != nil {
+		return "", err
+	}
+
+	if retention > 0 {
+		if err := removeOldBackups(dir, retention); err != nil {
+			return "", err
+		}
+	}
+	return backupFile, nil
+}
+
+// RestoreFromFile restores the backup file generated by BackupToDir
+func (c *Client) RestoreFromFile(backupFile string) error {
+	cs, err := backupfile.Checksum(backupFile)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(backupFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return c.RestoreFrom(f, cs)
+}
+
+// ListBackups returns the backup files in dir, the newest comes first
+func ListBackups(dir string) ([]string, error) {
+	return backupfile.List(dir)
+}
+
+func removeOldBackups(dir string, retention int) error {
+	backups, err := backupfile.List(dir)
+	if err != nil {
+		return err
+	}
+
+	for i := retention; i < len(backups); i++ {
+		if err := backupfile.Remove(backups[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+// batch is applied to master first, then to each slave, batch without
+// writes is only sent to master. buffered transactions flush their writes
+// by it at commit
+func (p *Proxy) batch(ctx context.Context, req *pb.BatchRequest) (*pb.BatchReply, error) {
+	written := req.HasWrites()
+	m := p.joinMembers()
+	defer m.txs.Done()
+	//transactions of this proxy don't begin while the batch is applied, so
+	//they are in the same order with the batch on every replica. it doesn't
+	//hold for other proxies, whose transactions are only ordered with the
+	//batch on master by the db lock of master, like transactions of
+	//different proxies are
+	if written {
+		p.batchLock.Lock()
+		defer p.batchLock.Unlock()
+	}
+
+	start := time.Now()
+	reply, err := p.master.Batch(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	p.masterLatency.observe(start)
+	if written == false {
+		return reply, nil
+	}
+
+	//slaves apply the batch with the revision of master
+	req = &pb.BatchRequest{
+		TableName:  req.TableName,
+		Operations: req.Operations,
+		Revision:   reply.Revision,
+	}
+	seq := p.nextWriteSeq()
+	for _, slave := range m.slaves {
+		if slave.available() == false {
+			slave.markDiverged(seq)
+			continue
+		}
+
+		start := time.Now()
+		_, err := slave.Batch(ctx, req)
+		slave.observe(err)
+		if err != nil {
+			log.Warnf("%s Batch failed:%s", slave.Target(), err.Error())
+			slave.markDiverged(seq)
+		} else {
+			slave.latency.observe(start)
+		}
+	}
+	return reply, nil
+}
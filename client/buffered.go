@@ -0,0 +1,186 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/tracing"
+)
+
+var errTxFinished = fmt.Errorf("transaction is already committed or rolled back")
+
+// bufferedTransaction stages writes locally and ships them to each replica
+// in one Batch at commit, so replicas are only locked while the batch is
+// applied. reads of staged keys see the staged writes, other reads are
+// batches without writes on master, which aren't isolated from other
+// writers. errors of writes like adding existing key are returned by Commit
+type bufferedTransaction struct {
+	proxy     *Proxy
+	tableName string
+	//staged writes in order, and the latest staged state of each key, nil
+	//value means the key is deleted
+	operations []*pb.BatchOperation
+	staged     map[string][]byte
+	done       bool
+	ctx        context.Context
+	span       *tracing.Span
+}
+
+func (tb *ProxyTable) BeginBuffered() (kvzoo.Transaction, error) {
+	return tb.BeginBufferedWithContext(context.Background())
+}
+
+// BeginBufferedWithContext begins buffered transaction, which doesn't lock
+// any replica until commit. it's for writers which don't need reads
+// isolated, like bulk loading, other transactions should use Begin
+func (tb *ProxyTable) BeginBufferedWithContext(ctx context.Context) (kvzoo.Transaction, error) {
+	ctx, span := tb.proxy.options.tracer.Start(ctx, "kvzoo.Transaction")
+	span.SetAttribute("table", tb.tableName)
+	span.SetAttribute("buffered", true)
+	return &bufferedTransaction{
+		proxy:     tb.proxy,
+		tableName: tb.tableName,
+		staged:    make(map[string][]byte),
+		ctx:       ctx,
+		span:      span,
+	}, nil
+}
+
+func (tx *bufferedTransaction) finish(err error) {
+	tx.done = true
+	tx.operations = nil
+	tx.staged = nil
+	tx.span.End(err)
+}
+
+func (tx *bufferedTransaction) stage(typ pb.BatchOperation_Type, key string, value []byte) error {
+	if tx.done {
+		return errTxFinished
+	}
+
+	if typ != pb.BatchOperation_DELETE {
+		//caller may reuse value, and empty value isn't a deleted key
+		value = append([]byte{}, value...)
+	}
+	tx.operations = append(tx.operations, &pb.BatchOperation{
+		Type:  typ,
+		Key:   key,
+		Value: value,
+	})
+	tx.staged[key] = value
+	return nil
+}
+
+func (tx *bufferedTransaction) Add(key string, value []byte) error {
+	return tx.stage(pb.BatchOperation_ADD, key, value)
+}
+
+func (tx *bufferedTransaction) Update(key string, value []byte) error {
+	return tx.stage(pb.BatchOperation_UPDATE, key, value)
+}
+
+func (tx *bufferedTransaction) Delete(key string) error {
+	return tx.stage(pb.BatchOperation_DELETE, key, nil)
+}
+
+// read runs op on master in a batch without writes, which is retried like
+// other requests without transaction
+func (tx *bufferedTransaction) read(ctx context.Context, op *pb.BatchOperation) (*pb.BatchResult, error) {
+	reply, err := tx.proxy.batch(ctx, &pb.BatchRequest{
+		TableName:  tx.tableName,
+		Operations: []*pb.BatchOperation{op},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Results[0], nil
+}
+
+func (tx *bufferedTransaction) Get(key string) (_ []byte, err error) {
+	if tx.done {
+		return nil, errTxFinished
+	}
+	if value, ok := tx.staged[key]; ok {
+		if value == nil {
+			return nil, kvzoo.ErrNotFound
+		}
+		return append([]byte{}, value...), nil
+	}
+
+	ctx, span := tx.proxy.options.tracer.Start(tx.ctx, "kvzoo.Get")
+	span.SetAttribute("key", key)
+	defer func() {
+		span.End(err)
+	}()
+
+	result, err := tx.read(ctx, &pb.BatchOperation{Type: pb.BatchOperation_GET, Key: key})
+	if err != nil {
+		return nil, err
+	} else if result.Found == false {
+		return nil, kvzoo.ErrNotFound
+	}
+	return result.Value, nil
+}
+
+// List merges staged writes into the values listed on master
+func (tx *bufferedTransaction) List() (_ map[string][]byte, err error) {
+	if tx.done {
+		return nil, errTxFinished
+	}
+
+	ctx, span := tx.proxy.options.tracer.Start(tx.ctx, "kvzoo.List")
+	defer func() {
+		span.End(err)
+	}()
+
+	result, err := tx.read(ctx, &pb.BatchOperation{Type: pb.BatchOperation_LIST})
+	if err != nil {
+		return nil, err
+	}
+
+	values := result.Values
+	if values == nil {
+		values = make(map[string][]byte)
+	}
+	for key, value := range tx.staged {
+		if value == nil {
+			delete(values, key)
+		} else {
+			values[key] = append([]byte{}, value...)
+		}
+	}
+	return values, nil
+}
+
+func (tx *bufferedTransaction) Commit() (err error) {
+	if tx.done {
+		return errTxFinished
+	}
+
+	ctx, span := tx.proxy.options.tracer.Start(tx.ctx, "kvzoo.Commit")
+	span.SetAttribute("operations", len(tx.operations))
+	defer func() {
+		span.End(err)
+		tx.finish(err)
+	}()
+
+	if len(tx.operations) == 0 {
+		return nil
+	}
+	if _, err := tx.proxy.batch(ctx, &pb.BatchRequest{
+		TableName:  tx.tableName,
+		Operations: tx.operations,
+	}); err != nil {
+		return &commitError{err: err}
+	}
+	return nil
+}
+
+func (tx *bufferedTransaction) Rollback() error {
+	if tx.done == false {
+		tx.finish(nil)
+	}
+	return nil
+}
@@ -12,11 +12,23 @@ type Client struct {
 	conn *grpc.ClientConn
 }
 
-func NewClient(addr string, timeout time.Duration) (*Client, error) {
+func NewClient(addr string, timeout time.Duration, opts ...grpc.DialOption) (*Client, error) {
+	return NewTLSClient(addr, timeout, nil, opts...)
+}
+
+// NewTLSClient connects to server with tls, nil conf means plaintext
+func NewTLSClient(addr string, timeout time.Duration, conf *TLSConfig, opts ...grpc.DialOption) (*Client, error) {
 	dialOptions := []grpc.DialOption{
-		grpc.WithInsecure(),
 		grpc.WithTimeout(timeout),
 	}
+	if conf == nil {
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+	} else if creds, err := conf.credentials(addr); err != nil {
+		return nil, err
+	} else {
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(creds))
+	}
+	dialOptions = append(dialOptions, opts...)
 
 	conn, err := grpc.Dial(addr, dialOptions...)
 	if err != nil {
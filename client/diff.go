@@ -0,0 +1,31 @@
+package client
+
+import (
+	"github.com/zdnscloud/kvzoo"
+)
+
+func (p *Proxy) MerkleTree(tableName kvzoo.TableName, boundaries []string) (*kvzoo.MerkleTree, error) {
+	return newReplicaDB(p.master).MerkleTree(tableName, boundaries)
+}
+
+func (p *Proxy) KeyDigests(tableName kvzoo.TableName, ranges []kvzoo.KeyRange) (map[string][]byte, error) {
+	return newReplicaDB(p.master).KeyDigests(tableName, ranges)
+}
+
+// Diff compares each slave with master, returns the differences of slaves
+// keyed by slave address, slave without difference isn't included
+func (p *Proxy) Diff(tableName kvzoo.TableName) (map[string][]kvzoo.Difference, error) {
+	diffs := make(map[string][]kvzoo.Difference)
+	master := newReplicaDB(p.master)
+	for _, slave := range p.getSlaves() {
+		slaveDiffs, err := kvzoo.Diff(master, newReplicaDB(slave.Client), tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(slaveDiffs) > 0 {
+			diffs[slave.Target()] = slaveDiffs
+		}
+	}
+	return diffs, nil
+}
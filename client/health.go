@@ -0,0 +1,243 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+)
+
+const (
+	//suggested interval of health checking, which is disabled by default
+	DefaultHealthCheckInterval     = 5 * time.Second
+	DefaultCircuitBreakerThreshold = 3
+	healthCheckTimeout             = 2 * time.Second
+)
+
+var errCircuitOpen = fmt.Errorf("replica is unavailable")
+
+type ReplicaState string
+
+const (
+	ReplicaHealthy ReplicaState = "healthy"
+	//some requests failed, but not enough to open the circuit
+	ReplicaSuspect ReplicaState = "suspect"
+	//circuit is open, requests are not sent to replica
+	ReplicaDown ReplicaState = "down"
+)
+
+type ReplicaStatus struct {
+	Addr      string        `json:"addr"`
+	Master    bool          `json:"master"`
+	State     ReplicaState  `json:"state"`
+	ConnState string        `json:"connState"`
+	LastErr   string        `json:"lastErr,omitempty"`
+	LastSeen  time.Time     `json:"lastSeen"`
+	Latency   time.Duration `json:"latency"`
+	//count of writes of this proxy slave missed, -1 means unknown
+	Lag int64 `json:"lag"`
+}
+
+// CheckHealth uses grpc health checking protocol, server without health
+// service is regarded as healthy if it responds
+func (c *Client) CheckHealth(ctx context.Context) error {
+	reply, err := healthpb.NewHealthClient(c.conn).Check(ctx, &healthpb.HealthCheckRequest{})
+	if err != nil {
+		if status.Code(err) == codes.Unimplemented {
+			return nil
+		}
+		return err
+	} else if reply.Status != healthpb.HealthCheckResponse_SERVING {
+		return fmt.Errorf("server status is %s", reply.Status)
+	}
+	return nil
+}
+
+func (c *Client) ConnState() string {
+	return c.conn.GetState().String()
+}
+
+// health works as a circuit breaker, after threshold consecutive failures
+// the circuit opens, and only a successful health check closes it
+type health struct {
+	lock      sync.Mutex
+	threshold int
+	failures  int
+	open      bool
+	lastErr   error
+	lastSeen  time.Time
+}
+
+func (h *health) available() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.open == false
+}
+
+// success returns true if the circuit is closed by it
+func (h *health) success() bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	recovered := h.open
+	h.failures = 0
+	h.open = false
+	h.lastSeen = time.Now()
+	return recovered
+}
+
+// failure returns true if the circuit is opened by it
+func (h *health) failure(err error) bool {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.failures += 1
+	h.lastErr = err
+	if h.open == false && h.threshold > 0 && h.failures >= h.threshold {
+		h.open = true
+		return true
+	}
+	return false
+}
+
+func (h *health) fillStatus(s *ReplicaStatus) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.open {
+		s.State = ReplicaDown
+	} else if h.failures > 0 {
+		s.State = ReplicaSuspect
+	} else {
+		s.State = ReplicaHealthy
+	}
+	if h.lastErr != nil {
+		s.LastErr = h.lastErr.Error()
+	}
+	s.LastSeen = h.lastSeen
+}
+
+// isUnavailable returns whether err means the server can't be reached,
+// other errors like duplicate key don't affect health
+func isUnavailable(err error) bool {
+	code := status.Code(err)
+	return code == codes.Unavailable || code == codes.DeadlineExceeded
+}
+
+// observe records the result of a request sent to slave
+func (r *replica) observe(err error) {
+	if err == nil {
+		r.health.success()
+	} else if isUnavailable(err) && r.health.failure(err) {
+		log.Warnf("%s is down, stop sending requests to it:%s", r.Target(), err.Error())
+	}
+}
+
+type healthMonitor struct {
+	stopCh chan struct{}
+	doneCh chan struct{}
+	//repairs of slaves which are back
+	repairs sync.WaitGroup
+}
+
+func (p *Proxy) startHealthMonitor() {
+	m := &healthMonitor{
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	p.monitor = m
+
+	go func() {
+		defer close(m.doneCh)
+		ticker := time.NewTicker(p.options.healthCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.stopCh:
+				return
+			case <-ticker.C:
+				p.checkHealth(m)
+			}
+		}
+	}()
+}
+
+// stopHealthMonitor waits for the running repairs, since they use the
+// connections of replicas
+func (p *Proxy) stopHealthMonitor() {
+	if p.monitor != nil {
+		close(p.monitor.stopCh)
+		<-p.monitor.doneCh
+		p.monitor.repairs.Wait()
+		p.monitor = nil
+	}
+}
+
+func (p *Proxy) checkHealth(m *healthMonitor) {
+	if err := checkClientHealth(p.master); err != nil {
+		p.masterHealth.failure(err)
+	} else {
+		p.masterHealth.success()
+	}
+
+	for _, slave := range p.getSlaves() {
+		if err := checkClientHealth(slave.Client); err != nil {
+			if slave.health.failure(err) {
+				log.Warnf("%s is down, stop sending requests to it:%s", slave.Target(), err.Error())
+			}
+		} else if slave.health.success() {
+			log.Infof("%s is back", slave.Target())
+			//writes missed while it's down are repaired in background
+			if slave.lag(p.currentWriteSeq()) != 0 {
+				m.repairs.Add(1)
+				go func(slave *replica) {
+					defer m.repairs.Done()
+					if _, err := p.repairReplicas(kvzoo.Root, []*replica{slave}, nil); err != nil {
+						log.Warnf("repair %s failed:%s", slave.Target(), err.Error())
+					}
+				}(slave)
+			}
+		}
+	}
+}
+
+func checkClientHealth(c *Client) error {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	return c.CheckHealth(ctx)
+}
+
+// ReplicaStatus returns the status of master and slaves, master comes first
+func (p *Proxy) ReplicaStatus() []ReplicaStatus {
+	master := ReplicaStatus{
+		Addr:      p.master.Target(),
+		Master:    true,
+		ConnState: p.master.ConnState(),
+		Latency:   p.masterLatency.get(),
+	}
+	p.masterHealth.fillStatus(&master)
+
+	slaves := p.getSlaves()
+	statuses := make([]ReplicaStatus, 0, 1+len(slaves))
+	statuses = append(statuses, master)
+	seq := p.currentWriteSeq()
+	for _, slave := range slaves {
+		s := ReplicaStatus{
+			Addr:      slave.Target(),
+			ConnState: slave.ConnState(),
+			Latency:   slave.latency.get(),
+			Lag:       slave.lag(seq),
+		}
+		if s.Lag == math.MaxInt64 {
+			s.Lag = -1
+		}
+		slave.health.fillStatus(&s)
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
@@ -0,0 +1,274 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+	"github.com/zdnscloud/kvzoo"
+)
+
+// time the membership watcher waits for the transactions which began before
+// membership change
+const MembershipChangeTimeout = 30 * time.Second
+
+// members is one generation of slaves, each membership change creates a
+// new generation, transactions are tracked by the generation they began
+// in, so the change could wait for them to finish
+type members struct {
+	slaves []*replica
+	txs    sync.WaitGroup
+}
+
+func (p *Proxy) getSlaves() []*replica {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	return p.members.slaves
+}
+
+func (p *Proxy) joinMembers() *members {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	p.members.txs.Add(1)
+	return p.members
+}
+
+// changeMembers replaces the slaves and waits for the transactions which
+// began with old slaves, false is returned if they don't finish before ctx
+// is done
+func (p *Proxy) changeMembers(ctx context.Context, change func([]*replica) ([]*replica, error)) (bool, error) {
+	p.lock.Lock()
+	old := p.members
+	slaves, err := change(old.slaves)
+	if err != nil {
+		p.lock.Unlock()
+		return false, err
+	}
+	p.members = &members{slaves: slaves}
+	p.lock.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		old.txs.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true, nil
+	case <-ctx.Done():
+		return false, nil
+	}
+}
+
+func (p *Proxy) findSlave(slaves []*replica, addr string) int {
+	for i, slave := range slaves {
+		if slave.Target() == addr {
+			return i
+		}
+	}
+	return -1
+}
+
+// AddReplica copies the snapshot of master to the new replica, then adds it
+// into slaves. writes which happen during copy are repaired after all the
+// transactions began before joining finish. replica doesn't serve reads
+// until the repair succeeds. if ctx is done before the transactions finish,
+// the repair is left to anti entropy
+func (p *Proxy) AddReplica(ctx context.Context, addr string) error {
+	if addr == p.master.Target() || p.findSlave(p.getSlaves(), addr) != -1 {
+		return fmt.Errorf("replica %s already exists", addr)
+	}
+
+	c, err := p.options.connect(addr, p.metrics)
+	if err != nil {
+		return err
+	}
+
+	if err := p.copySnapshot(c); err != nil {
+		c.Close()
+		return fmt.Errorf("copy snapshot to %s failed:%s", addr, err.Error())
+	}
+
+	slave := newReplica(c, p.options.circuitBreakerThreshold)
+	slave.markDiverged(unknownMissedWrite)
+	finished, err := p.changeMembers(ctx, func(slaves []*replica) ([]*replica, error) {
+		if p.findSlave(slaves, addr) != -1 {
+			return nil, fmt.Errorf("replica %s already exists", addr)
+		}
+		return append(append([]*replica{}, slaves...), slave), nil
+	})
+	if err != nil {
+		c.Close()
+		return err
+	}
+
+	if finished == false {
+		log.Warnf("transactions before %s joined don't finish, leave it to anti entropy", addr)
+	} else if _, err := p.repairReplicas(kvzoo.Root, []*replica{slave}, nil); err != nil {
+		log.Warnf("repair new replica %s failed:%s", addr, err.Error())
+	}
+	return nil
+}
+
+func (p *Proxy) copySnapshot(c *Client) error {
+	f, err := ioutil.TempFile("", "kvzoo-snapshot-")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		f.Close()
+		os.Remove(f.Name())
+	}()
+
+	cs, err := p.master.BackupTo(f)
+	if err != nil {
+		return err
+	}
+
+	if _, err := f.Seek(0, 0); err != nil {
+		return err
+	}
+	return c.RestoreFrom(f, cs)
+}
+
+// RemoveReplica removes the slave from proxy, the connection is closed after
+// the transactions using it finish, or ctx is done
+func (p *Proxy) RemoveReplica(ctx context.Context, addr string) error {
+	var removed *replica
+	finished, err := p.changeMembers(ctx, func(slaves []*replica) ([]*replica, error) {
+		i := p.findSlave(slaves, addr)
+		if i == -1 {
+			return nil, fmt.Errorf("replica %s doesn't exist", addr)
+		}
+		removed = slaves[i]
+		return append(append([]*replica{}, slaves[:i]...), slaves[i+1:]...), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if finished == false {
+		log.Warnf("transactions using %s don't finish, close it anyway", addr)
+	}
+	return removed.Close()
+}
+
+// Replicas returns the addresses of slaves
+func (p *Proxy) Replicas() []string {
+	slaves := p.getSlaves()
+	addrs := make([]string, 0, len(slaves))
+	for _, slave := range slaves {
+		addrs = append(addrs, slave.Target())
+	}
+	return addrs
+}
+
+type membershipWatcher struct {
+	lock   sync.Mutex
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// WatchMembership reads slave addresses from file, one address per line,
+// lines start with # are ignored. file is checked every interval, replicas
+// are added or removed according to the change of file
+func (p *Proxy) WatchMembership(file string, interval time.Duration) error {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	p.StopWatchMembership()
+	if err := p.syncMembership(content); err != nil {
+		return err
+	}
+
+	stopCh := make(chan struct{})
+	doneCh := make(chan struct{})
+	p.membership.lock.Lock()
+	p.membership.stopCh = stopCh
+	p.membership.doneCh = doneCh
+	p.membership.lock.Unlock()
+
+	go func() {
+		defer close(doneCh)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stopCh:
+				return
+			case <-ticker.C:
+				newContent, err := ioutil.ReadFile(file)
+				if err != nil {
+					log.Warnf("read membership file %s failed:%s", file, err.Error())
+				} else if bytes.Equal(newContent, content) == false {
+					if err := p.syncMembership(newContent); err != nil {
+						log.Warnf("change membership failed:%s", err.Error())
+					} else {
+						content = newContent
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (p *Proxy) StopWatchMembership() {
+	p.membership.lock.Lock()
+	stopCh, doneCh := p.membership.stopCh, p.membership.doneCh
+	p.membership.stopCh, p.membership.doneCh = nil, nil
+	p.membership.lock.Unlock()
+
+	if stopCh != nil {
+		close(stopCh)
+		<-doneCh
+	}
+}
+
+func (p *Proxy) syncMembership(content []byte) error {
+	addrs := make(map[string]bool)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && strings.HasPrefix(line, "#") == false && line != p.master.Target() {
+			addrs[line] = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	for _, addr := range p.Replicas() {
+		if addrs[addr] {
+			delete(addrs, addr)
+		} else if err := p.changeReplica(p.RemoveReplica, addr); err != nil {
+			return err
+		} else {
+			log.Infof("replica %s is removed", addr)
+		}
+	}
+
+	for addr := range addrs {
+		if err := p.changeReplica(p.AddReplica, addr); err != nil {
+			return err
+		}
+		log.Infof("replica %s is added", addr)
+	}
+	return nil
+}
+
+func (p *Proxy) changeReplica(change func(context.Context, string) error, addr string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), MembershipChangeTimeout)
+	defer cancel()
+	return change(ctx, addr)
+}
@@ -0,0 +1,147 @@
+package client
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+const kvsMethodPrefix = "/pb.KVS/"
+
+// proxyMetrics is nil if metrics isn't enabled, series of replica are
+// labeled with its address
+type proxyMetrics struct {
+	registerer prometheus.Registerer
+	collectors []prometheus.Collector
+	requests   *prometheus.CounterVec
+	failures   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+}
+
+func newProxyMetrics(registerer prometheus.Registerer) (*proxyMetrics, error) {
+	if registerer == nil {
+		return nil, nil
+	}
+
+	m := &proxyMetrics{
+		registerer: registerer,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kvzoo_proxy_replica_requests_total",
+			Help: "Requests sent to replicas.",
+		}, []string{"replica", "method", "code"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kvzoo_proxy_replica_failures_total",
+			Help: "Requests sent to replicas which failed because replica is unreachable.",
+		}, []string{"replica"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "kvzoo_proxy_replica_request_duration_seconds",
+			Help: "Latency of requests sent to replicas.",
+		}, []string{"replica", "method"}),
+	}
+	for _, c := range []prometheus.Collector{m.requests, m.failures, m.latency} {
+		if err := m.register(c); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// register c, all the registered collectors are unregistered if it fails
+func (m *proxyMetrics) register(c prometheus.Collector) error {
+	if err := m.registerer.Register(c); err != nil {
+		m.stop()
+		return err
+	}
+	m.collectors = append(m.collectors, c)
+	return nil
+}
+
+// start exports status of replicas and anti entropy at each scrape
+func (m *proxyMetrics) start(p *Proxy) error {
+	return m.register(&proxyStatusCollector{proxy: p})
+}
+
+func (m *proxyMetrics) stop() {
+	if m == nil {
+		return
+	}
+	for _, c := range m.collectors {
+		m.registerer.Unregister(c)
+	}
+	m.collectors = nil
+}
+
+// interceptor records each attempt of request to replica
+func (m *proxyMetrics) interceptor(replica string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		method = strings.TrimPrefix(method, kvsMethodPrefix)
+		m.latency.WithLabelValues(replica, method).Observe(time.Since(start).Seconds())
+		m.requests.WithLabelValues(replica, method, status.Code(err).String()).Inc()
+		//errors like missing key are answers of replica
+		if isUnavailable(err) {
+			m.failures.WithLabelValues(replica).Inc()
+		}
+		return err
+	}
+}
+
+var (
+	replicaUpDesc = prometheus.NewDesc("kvzoo_proxy_replica_up",
+		"Whether replica isn't marked down by circuit breaker.", []string{"replica", "master"}, nil)
+	replicaLagDesc = prometheus.NewDesc("kvzoo_proxy_replica_lag",
+		"Writes missed by replica, -1 means unknown.", []string{"replica", "master"}, nil)
+	repairRoundsDesc = prometheus.NewDesc("kvzoo_proxy_repair_rounds_total",
+		"Rounds of anti entropy.", nil, nil)
+	repairFailedRoundsDesc = prometheus.NewDesc("kvzoo_proxy_repair_failed_rounds_total",
+		"Failed rounds of anti entropy.", nil, nil)
+	divergentTablesDesc = prometheus.NewDesc("kvzoo_proxy_divergent_tables_total",
+		"Tables found different with master by anti entropy.", nil, nil)
+	repairedTablesDesc = prometheus.NewDesc("kvzoo_proxy_repaired_tables_total",
+		"Tables repaired by anti entropy.", nil, nil)
+	repairedKeysDesc = prometheus.NewDesc("kvzoo_proxy_repaired_keys_total",
+		"Keys repaired by anti entropy.", nil, nil)
+)
+
+// proxyStatusCollector reads status of replicas and anti entropy at each
+// scrape, removed replica disappears with it
+type proxyStatusCollector struct {
+	proxy *Proxy
+}
+
+func (c *proxyStatusCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{replicaUpDesc, replicaLagDesc, repairRoundsDesc,
+		repairFailedRoundsDesc, divergentTablesDesc, repairedTablesDesc, repairedKeysDesc} {
+		ch <- desc
+	}
+}
+
+func (c *proxyStatusCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, status := range c.proxy.ReplicaStatus() {
+		master := "false"
+		if status.Master {
+			master = "true"
+		}
+		up := 1.0
+		if status.State == ReplicaDown {
+			up = 0
+		}
+		ch <- prometheus.MustNewConstMetric(replicaUpDesc, prometheus.GaugeValue, up, status.Addr, master)
+		ch <- prometheus.MustNewConstMetric(replicaLagDesc, prometheus.GaugeValue, float64(status.Lag), status.Addr, master)
+	}
+
+	stats := c.proxy.RepairStats()
+	counter := func(desc *prometheus.Desc, value int64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value))
+	}
+	counter(repairRoundsDesc, stats.Rounds)
+	counter(repairFailedRoundsDesc, stats.FailedRounds)
+	counter(divergentTablesDesc, stats.DivergentTables)
+	counter(repairedTablesDesc, stats.RepairedTables)
+	counter(repairedKeysDesc, stats.RepairedKeys)
+}
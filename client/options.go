@@ -0,0 +1,120 @@
+package client
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+
+	"github.com/zdnscloud/kvzoo/tracing"
+)
+
+type options struct {
+	readPolicy              ReadPolicy
+	maxStaleness            int64
+	healthCheckInterval     time.Duration
+	circuitBreakerThreshold int
+	retryPolicy             RetryPolicy
+	tls                     *TLSConfig
+	token                   string
+	metrics                 prometheus.Registerer
+	tracer                  *tracing.Tracer
+}
+
+type Option func(*options)
+
+func defaultOptions() options {
+	return options{
+		readPolicy:              ReadFromMaster,
+		circuitBreakerThreshold: DefaultCircuitBreakerThreshold,
+	}
+}
+
+// WithReadPolicy decides which replica serves Get and List of transactions
+func WithReadPolicy(policy ReadPolicy) Option {
+	return func(o *options) {
+		o.readPolicy = policy
+	}
+}
+
+// WithMaxStaleness allows reading from slave which is at most revisions
+// writes behind master when the transaction begins. writes are numbered by
+// master, so writes of other clients are counted too. default is 0 which
+// means only slaves which have applied all the writes of master are read
+func WithMaxStaleness(revisions int64) Option {
+	return func(o *options) {
+		o.maxStaleness = revisions
+	}
+}
+
+// WithHealthCheckInterval enables health checking replicas in background,
+// health checking is disabled by default, so is circuit breaker since only
+// health checking could close the circuit
+func WithHealthCheckInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.healthCheckInterval = interval
+	}
+}
+
+// WithCircuitBreakerThreshold sets the count of consecutive failures which
+// marks a slave down, <= 0 disables circuit breaker, it only works with
+// health checking
+func WithCircuitBreakerThreshold(threshold int) Option {
+	return func(o *options) {
+		o.circuitBreakerThreshold = threshold
+	}
+}
+
+// WithRetryPolicy retries idempotent requests to master and replays
+// transaction run by Update with policy, default is no retry
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retryPolicy = policy
+	}
+}
+
+// WithTLS connects to all the servers with tls
+func WithTLS(conf TLSConfig) Option {
+	return func(o *options) {
+		o.tls = &conf
+	}
+}
+
+// WithToken authenticates to all the servers with token
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithMetrics registers metrics of proxy and its replicas into registerer,
+// they are unregistered after proxy is closed. registerer should be used by
+// one proxy, wrap it with prometheus.WrapRegistererWith for more proxies
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(o *options) {
+		o.metrics = registerer
+	}
+}
+
+// WithTracer records spans of transaction operations and their requests to
+// each replica, trace context is propagated to servers
+func WithTracer(tracer *tracing.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// connect creates client of replica with tls and token in options, requests
+// are recorded by m if it isn't nil
+func (o *options) connect(addr string, m *proxyMetrics, opts ...grpc.DialOption) (*Client, error) {
+	if o.token != "" {
+		opts = append(opts, WithTokenCredentials(o.token))
+	}
+	if m != nil {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(m.interceptor(addr)))
+	}
+	if o.tracer != nil {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(tracing.UnaryClientInterceptor(o.tracer, addr)))
+	}
+	return NewTLSClient(addr, ConnectTimeout, o.tls, opts...)
+}
@@ -4,16 +4,39 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/zdnscloud/cement/log"
 	"github.com/zdnscloud/kvzoo"
 	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/tracing"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type Proxy struct {
-	master *Client
-	slaves []*Client
+	master  *Client
+	options options
+
+	lock       sync.RWMutex
+	members    *members
+	membership membershipWatcher
+
+	writeSeq      int64
+	nextSlave     uint64
+	masterLatency latency
+	masterHealth  health
+	monitor       *healthMonitor
+
+	repair repairState
+
+	//held by batch while it's applied to replicas, transactions begin
+	//with read lock
+	batchLock sync.RWMutex
+
+	metrics *proxyMetrics
 }
 
 const (
@@ -21,52 +44,106 @@ const (
 	InvalidTxID    = int64(-1)
 )
 
-func New(masterAddr string, slaveAddrs []string) (kvzoo.DB, error) {
-	master, err := NewClient(masterAddr, ConnectTimeout)
+var errChecksumMismatch = fmt.Errorf("checksum mismatch")
+
+func New(masterAddr string, slaveAddrs []string, opts ...Option) (kvzoo.DB, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.healthCheckInterval <= 0 {
+		options.circuitBreakerThreshold = 0
+	}
+
+	//slaves aren't retried, the circuit breaker skips them when they are down
+	var masterOptions []grpc.DialOption
+	if options.retryPolicy.MaxAttempts > 1 {
+		masterOptions = append(masterOptions, grpc.WithUnaryInterceptor(RetryInterceptor(options.retryPolicy)))
+	}
+	m, err := newProxyMetrics(options.metrics)
 	if err != nil {
 		return nil, err
 	}
+	master, err := options.connect(masterAddr, m, masterOptions...)
+	if err != nil {
+		m.stop()
+		return nil, err
+	}
 
-	slaves := make([]*Client, 0, len(slaveAddrs))
+	slaves := make([]*replica, 0, len(slaveAddrs))
 	for _, addr := range slaveAddrs {
-		slave, err := NewClient(addr, ConnectTimeout)
+		slave, err := options.connect(addr, m)
 		if err != nil {
+			m.stop()
 			return nil, err
 		}
-		slaves = append(slaves, slave)
+		slaves = append(slaves, newReplica(slave, options.circuitBreakerThreshold))
 	}
 
-	return &Proxy{
-		master: master,
-		slaves: slaves,
-	}, nil
+	p := &Proxy{
+		master:  master,
+		members: &members{slaves: slaves},
+		options: options,
+		metrics: m,
+	}
+
+	//slaves which are not same with master at start shouldn't serve reads
+	if options.readPolicy != ReadFromMaster {
+		if _, errs, err := p.checkReplicas(slaves); err != nil {
+			p.Close()
+			return nil, err
+		} else {
+			for i, err := range errs {
+				if err != nil {
+					slaves[i].markDiverged(unknownMissedWrite)
+					log.Warnf("%s isn't same with master:%s", slaves[i].Target(), err.Error())
+				}
+			}
+		}
+	}
+
+	if m != nil {
+		if err := m.start(p); err != nil {
+			p.Close()
+			return nil, err
+		}
+	}
+	if options.healthCheckInterval > 0 {
+		p.startHealthMonitor()
+	}
+	return p, nil
 }
 
 func (p *Proxy) Checksum() (string, error) {
-	req := &pb.ChecksumRequest{}
-	reply, err := p.master.Checksum(context.TODO(), req)
+	slaves := p.getSlaves()
+	cs, errs, err := p.checkReplicas(slaves)
 	if err != nil {
 		return "", err
 	}
 
-	cs := reply.Checksum
-	for _, slave := range p.slaves {
-		if reply, err := slave.Checksum(context.TODO(), req); err != nil {
-			return "", fmt.Errorf("%s get checksum failed:%s", slave.Target(), err.Error())
-		} else if reply.Checksum != cs {
+	for i, err := range errs {
+		slave := slaves[i]
+		if err == errChecksumMismatch {
 			return "", fmt.Errorf("checksum of %s isn't same with master %s", slave.Target(), p.master.Target())
+		} else if err != nil {
+			return "", fmt.Errorf("%s get checksum failed:%s", slave.Target(), err.Error())
 		}
 	}
 	return cs, nil
 }
 
 func (p *Proxy) Close() error {
+	p.StopAntiEntropy()
+	p.StopWatchMembership()
+	p.stopHealthMonitor()
+	p.metrics.stop()
+
 	var err error
 	if err_ := p.master.Close(); err_ != nil {
 		err = err_
 	}
 
-	for _, slave := range p.slaves {
+	for _, slave := range p.getSlaves() {
 		if err_ := slave.Close(); err == nil && err_ != nil {
 			err = err_
 		}
@@ -76,13 +153,46 @@ func (p *Proxy) Close() error {
 }
 
 func (p *Proxy) Destroy() error {
-	req := &pb.DestroyRequest{}
+	return p.destroy(&pb.DestroyRequest{})
+}
+
+// DestroyWithConfirm destroys replicas which only allow confirmed destroy,
+// confirm should be the checksum of db. checksum of each available replica
+// is compared with confirm before any replica is destroyed, so diverged
+// slave doesn't refuse the destroy after master is gone
+func (p *Proxy) DestroyWithConfirm(confirm string) error {
+	slaves := p.getSlaves()
+	cs, errs, err := p.checkReplicas(slaves)
+	if err != nil {
+		return err
+	} else if cs != confirm {
+		return status.Errorf(codes.FailedPrecondition, "checksum of %s isn't same with confirm", p.master.Target())
+	}
+
+	for i, err := range errs {
+		slave := slaves[i]
+		if err == errCircuitOpen {
+			//down slave is skipped by destroy
+			continue
+		} else if err == errChecksumMismatch {
+			return status.Errorf(codes.FailedPrecondition, "checksum of %s isn't same with confirm", slave.Target())
+		} else if err != nil {
+			return fmt.Errorf("%s get checksum failed:%s", slave.Target(), err.Error())
+		}
+	}
+	return p.destroy(&pb.DestroyRequest{Confirm: confirm})
+}
+
+func (p *Proxy) destroy(req *pb.DestroyRequest) error {
 	if _, err := p.master.Destroy(context.TODO(), req); err != nil {
 		return err
 	}
 
-	for _, slave := range p.slaves {
-		if _, err := slave.Destroy(context.TODO(), req); err != nil {
+	for _, slave := range p.getSlaves() {
+		if slave.available() == false {
+			log.Warnf("%s is down, skip Destroy", slave.Target())
+		} else if _, err := slave.Destroy(context.TODO(), req); err != nil {
+			slave.observe(err)
 			log.Warnf("%s Destroy failed:%s", slave.Target(), err.Error())
 		}
 	}
@@ -103,9 +213,17 @@ func (p *Proxy) CreateOrGetTable(tableName kvzoo.TableName) (kvzoo.Table, error)
 		return nil, err
 	}
 
-	for _, slave := range p.slaves {
-		if _, err := slave.CreateOrGetTable(context.TODO(), req); err != nil {
+	for _, slave := range p.getSlaves() {
+		if slave.available() == false {
+			continue
+		}
+
+		_, err := slave.CreateOrGetTable(context.TODO(), req)
+		slave.observe(err)
+		if err != nil {
 			log.Warnf("%s CreateOrGetTable failed:%s", slave.Target(), err.Error())
+			//writes to the table after this will be missed
+			slave.markDiverged(p.currentWriteSeq() + 1)
 		}
 	}
 
@@ -120,64 +238,164 @@ func (p *Proxy) DeleteTable(tableName kvzoo.TableName) error {
 		Name: string(tableName),
 	}
 
-	if _, err := p.master.DeleteTable(context.TODO(), req); err != nil {
+	reply, err := p.master.DeleteTable(context.TODO(), req)
+	if err != nil {
 		return err
 	}
 
-	for _, slave := range p.slaves {
-		if _, err := slave.DeleteTable(context.TODO(), req); err != nil {
+	req = &pb.DeleteTableRequest{
+		Name:     string(tableName),
+		Revision: reply.Revision,
+	}
+	seq := p.nextWriteSeq()
+	for _, slave := range p.getSlaves() {
+		if slave.available() == false {
+			slave.markDiverged(seq)
+			continue
+		}
+
+		_, err := slave.DeleteTable(context.TODO(), req)
+		slave.observe(err)
+		if err != nil {
 			log.Warnf("%s DeleteTable failed:%s", slave.Target(), err.Error())
+			slave.markDiverged(seq)
 		}
 	}
 	return nil
 }
 
+func (p *Proxy) ListTables(parent kvzoo.TableName, recursive bool) ([]kvzoo.TableName, error) {
+	return newReplicaDB(p.master).ListTables(parent, recursive)
+}
+
+func (p *Proxy) TableExists(tableName kvzoo.TableName) (bool, error) {
+	return newReplicaDB(p.master).TableExists(tableName)
+}
+
 type ProxyTransaction struct {
 	proxy *Proxy
-	ids   []int64
+	//slaves when transaction begins, membership change doesn't affect it
+	members *members
+	slaves  []*replica
+	ids     []int64
+	done    bool
+	//slaves which missed some writes of the transaction
+	failed []bool
+	//index of replica in ids which serves reads
+	reader  int
+	written bool
+	//context holds the span from begin to commit or rollback
+	ctx  context.Context
+	span *tracing.Span
 }
 
 func (tb *ProxyTable) Begin() (kvzoo.Transaction, error) {
+	return tb.BeginWithContext(context.Background())
+}
+
+// BeginWithContext begins transaction whose spans are children of the span
+// in ctx, so the transaction is traced as part of the caller's request
+func (tb *ProxyTable) BeginWithContext(ctx context.Context) (kvzoo.Transaction, error) {
+	ctx, span := tb.proxy.options.tracer.Start(ctx, "kvzoo.Transaction")
+	span.SetAttribute("table", tb.tableName)
+	opCtx, opSpan := tb.proxy.options.tracer.Start(ctx, "kvzoo.Begin")
+	tx, err := tb.begin(opCtx)
+	opSpan.End(err)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+
+	tx.ctx = ctx
+	tx.span = span
+	return tx, nil
+}
+
+func (tb *ProxyTable) begin(ctx context.Context) (*ProxyTransaction, error) {
 	req := &pb.BeginTransactionRequest{
 		TableName: tb.tableName,
 	}
 
 	p := tb.proxy
+	m := p.joinMembers()
+	p.batchLock.RLock()
+	defer p.batchLock.RUnlock()
 	var tx *ProxyTransaction
-	if reply, err := p.master.BeginTransaction(context.TODO(), req); err != nil {
+	var master *pb.Revision
+	if reply, err := p.master.BeginTransaction(ctx, req); err != nil {
+		m.txs.Done()
 		return nil, err
 	} else {
-		ids := make([]int64, 0, 1+len(p.slaves))
+		master = reply.Revision
+		ids := make([]int64, 0, 1+len(m.slaves))
 		ids = append(ids, reply.TxId)
 		tx = &ProxyTransaction{
-			proxy: tb.proxy,
-			ids:   ids,
+			proxy:   tb.proxy,
+			members: m,
+			slaves:  m.slaves,
+			ids:     ids,
+			failed:  make([]bool, len(m.slaves)),
 		}
 	}
 
-	for _, slave := range p.slaves {
-		if reply, err := slave.BeginTransaction(context.TODO(), req); err != nil {
+	applied := make([]*pb.Revision, len(tx.slaves))
+	for i, slave := range tx.slaves {
+		if slave.available() == false {
+			tx.ids = append(tx.ids, InvalidTxID)
+			tx.failed[i] = true
+		} else if reply, err := slave.BeginTransaction(ctx, req); err != nil {
+			slave.observe(err)
 			log.Warnf("%s BeginTransaction failed:%s", slave.Target(), err.Error())
 			tx.ids = append(tx.ids, InvalidTxID)
+			tx.failed[i] = true
 		} else {
+			slave.observe(nil)
 			tx.ids = append(tx.ids, reply.TxId)
+			applied[i] = reply.Applied
 		}
 	}
 
+	tx.reader = p.readers(tx.slaves, func(i int) bool {
+		return tx.ids[i+1] != InvalidTxID && p.fresh(master, applied[i])
+	})[0]
 	return tx, nil
 }
 
-func (tx *ProxyTransaction) Rollback() error {
+func (tx *ProxyTransaction) finish(err error) {
+	if tx.done == false {
+		tx.done = true
+		tx.members.txs.Done()
+		tx.span.End(err)
+	}
+}
+
+// startSpan creates span of operation which is child of the transaction
+// span, requests to replicas are traced as children of the returned span
+func (tx *ProxyTransaction) startSpan(operation string) (context.Context, *tracing.Span) {
+	return tx.proxy.options.tracer.Start(tx.ctx, "kvzoo."+operation)
+}
+
+func (tx *ProxyTransaction) Rollback() (err error) {
+	//already committed or rolled back
+	if tx.done {
+		return nil
+	}
+	ctx, span := tx.startSpan("Rollback")
+	defer func() {
+		span.End(err)
+		tx.finish(err)
+	}()
+
 	req := &pb.RollbackTransactionRequest{
 		TxId: tx.ids[0],
 	}
 
 	p := tx.proxy
-	if _, err := p.master.RollbackTransaction(context.TODO(), req); err != nil {
+	if _, err := p.master.RollbackTransaction(ctx, req); err != nil {
 		return err
 	}
 
-	for i, slave := range p.slaves {
+	for i, slave := range tx.slaves {
 		id := tx.ids[i+1]
 		if id == InvalidTxID {
 			continue
@@ -186,51 +404,92 @@ func (tx *ProxyTransaction) Rollback() error {
 		req := &pb.RollbackTransactionRequest{
 			TxId: id,
 		}
-		if _, err := slave.RollbackTransaction(context.TODO(), req); err != nil {
+		if _, err := slave.RollbackTransaction(ctx, req); err != nil {
+			slave.observe(err)
 			log.Warnf("%s Rollback failed:%s", slave.Target(), err.Error())
 		}
 	}
 	return nil
 }
 
-func (tx *ProxyTransaction) Commit() error {
+func (tx *ProxyTransaction) Commit() (err error) {
+	ctx, span := tx.startSpan("Commit")
+	defer func() {
+		span.End(err)
+		tx.finish(err)
+	}()
 	req := &pb.CommitTransactionRequest{
 		TxId: tx.ids[0],
 	}
 
 	p := tx.proxy
-	if _, err := p.master.CommitTransaction(context.TODO(), req); err != nil {
-		return err
+	start := time.Now()
+	reply, err := p.master.CommitTransaction(ctx, req)
+	if err != nil {
+		return &commitError{err: err}
 	}
+	p.masterLatency.observe(start)
 
-	for i, slave := range p.slaves {
+	var seq int64
+	if tx.written {
+		seq = p.nextWriteSeq()
+	}
+	for i, slave := range tx.slaves {
 		id := tx.ids[i+1]
-		if id == InvalidTxID {
-			continue
+		if id != InvalidTxID {
+			req := &pb.CommitTransactionRequest{
+				TxId: id,
+			}
+			//slave which missed writes of the transaction doesn't apply
+			//its revision
+			if tx.failed[i] == false {
+				req.Revision = reply.Revision
+			}
+			start := time.Now()
+			_, err := slave.CommitTransaction(ctx, req)
+			slave.observe(err)
+			if err != nil {
+				log.Warnf("%s commit failed:%s", slave.Target(), err.Error())
+				tx.failed[i] = true
+			} else {
+				slave.latency.observe(start)
+			}
 		}
 
-		req := &pb.CommitTransactionRequest{
-			TxId: id,
-		}
-		if _, err := slave.CommitTransaction(context.TODO(), req); err != nil {
-			log.Warnf("%s commit failed:%s", slave.Target(), err.Error())
+		if tx.written && tx.failed[i] {
+			slave.markDiverged(seq)
 		}
 	}
 	return nil
 }
 
-func (tx *ProxyTransaction) Add(key string, value []byte) error {
+func (tx *ProxyTransaction) slaveWriteFailed(i int) {
+	tx.failed[i] = true
+	if tx.reader == i+1 {
+		//keep read your writes
+		tx.reader = 0
+	}
+}
+
+func (tx *ProxyTransaction) Add(key string, value []byte) (err error) {
+	ctx, span := tx.startSpan("Add")
+	span.SetAttribute("key", key)
+	defer func() {
+		span.End(err)
+	}()
+
 	req := &pb.AddRequest{
 		TxId:  tx.ids[0],
 		Key:   key,
 		Value: value,
 	}
 	p := tx.proxy
-	if _, err := p.master.Add(context.TODO(), req); err != nil {
+	if _, err := p.master.Add(ctx, req); err != nil {
 		return err
 	}
+	tx.written = true
 
-	for i, slave := range p.slaves {
+	for i, slave := range tx.slaves {
 		id := tx.ids[i+1]
 		if id == InvalidTxID {
 			continue
@@ -241,24 +500,33 @@ func (tx *ProxyTransaction) Add(key string, value []byte) error {
 			Key:   key,
 			Value: value,
 		}
-		if _, err := slave.Add(context.TODO(), req); err != nil {
+		if _, err := slave.Add(ctx, req); err != nil {
 			log.Warnf("%s Add %s failed:%s", slave.Target(), key, err.Error())
+			slave.observe(err)
+			tx.slaveWriteFailed(i)
 		}
 	}
 	return nil
 }
 
-func (tx *ProxyTransaction) Delete(key string) error {
+func (tx *ProxyTransaction) Delete(key string) (err error) {
+	ctx, span := tx.startSpan("Delete")
+	span.SetAttribute("key", key)
+	defer func() {
+		span.End(err)
+	}()
+
 	req := &pb.DeleteRequest{
 		TxId: tx.ids[0],
 		Key:  key,
 	}
 	p := tx.proxy
-	if _, err := p.master.Delete(context.TODO(), req); err != nil {
+	if _, err := p.master.Delete(ctx, req); err != nil {
 		return err
 	}
+	tx.written = true
 
-	for i, slave := range p.slaves {
+	for i, slave := range tx.slaves {
 		id := tx.ids[i+1]
 		if id == InvalidTxID {
 			continue
@@ -268,25 +536,34 @@ func (tx *ProxyTransaction) Delete(key string) error {
 			TxId: id,
 			Key:  key,
 		}
-		if _, err := slave.Delete(context.TODO(), req); err != nil {
+		if _, err := slave.Delete(ctx, req); err != nil {
 			log.Warnf("%s delete %s failed:%s", slave.Target(), key, err.Error())
+			slave.observe(err)
+			tx.slaveWriteFailed(i)
 		}
 	}
 	return nil
 }
 
-func (tx *ProxyTransaction) Update(key string, value []byte) error {
+func (tx *ProxyTransaction) Update(key string, value []byte) (err error) {
+	ctx, span := tx.startSpan("Update")
+	span.SetAttribute("key", key)
+	defer func() {
+		span.End(err)
+	}()
+
 	req := &pb.UpdateRequest{
 		TxId:  tx.ids[0],
 		Key:   key,
 		Value: value,
 	}
 	p := tx.proxy
-	if _, err := p.master.Update(context.TODO(), req); err != nil {
+	if _, err := p.master.Update(ctx, req); err != nil {
 		return err
 	}
+	tx.written = true
 
-	for i, slave := range p.slaves {
+	for i, slave := range tx.slaves {
 		id := tx.ids[i+1]
 		if id == InvalidTxID {
 			continue
@@ -297,36 +574,99 @@ func (tx *ProxyTransaction) Update(key string, value []byte) error {
 			Key:   key,
 			Value: value,
 		}
-		if _, err := slave.Update(context.TODO(), req); err != nil {
+		if _, err := slave.Update(ctx, req); err != nil {
 			log.Warnf("%s Update %s failed:%s", slave.Target(), key, err.Error())
+			slave.observe(err)
+			tx.slaveWriteFailed(i)
 		}
 	}
 	return nil
 }
 
-func (tx *ProxyTransaction) Get(key string) ([]byte, error) {
-	req := &pb.GetRequest{
-		TxId: tx.ids[0],
-		Key:  key,
+// read runs f on the replica chosen as reader, with ReadSlaveWithFallback
+// policy, failed read on slave is retried on master
+func (tx *ProxyTransaction) read(f func(c *Client, txID int64) error) error {
+	p := tx.proxy
+	if i := tx.reader; i > 0 {
+		slave := tx.slaves[i-1]
+		start := time.Now()
+		err := f(slave.Client, tx.ids[i])
+		if err != nil && isNotFound(err) == false {
+			slave.observe(err)
+		}
+		if err == nil || isNotFound(err) {
+			slave.latency.observe(start)
+			return err
+		} else if p.options.readPolicy != ReadSlaveWithFallback {
+			return err
+		}
+
+		log.Warnf("%s read failed, fallback to master:%s", slave.Target(), err.Error())
+		tx.reader = 0
+	}
+
+	start := time.Now()
+	err := f(p.master, tx.ids[0])
+	if err == nil || isNotFound(err) {
+		p.masterLatency.observe(start)
 	}
-	if reply, err := tx.proxy.master.Get(context.TODO(), req); err != nil {
-		if strings.Contains(err.Error(), kvzoo.ErrNotFound.Error()) {
+	return err
+}
+
+func isNotFound(err error) bool {
+	return strings.Contains(err.Error(), kvzoo.ErrNotFound.Error())
+}
+
+func (tx *ProxyTransaction) Get(key string) (_ []byte, err error) {
+	ctx, span := tx.startSpan("Get")
+	span.SetAttribute("key", key)
+	defer func() {
+		span.End(err)
+	}()
+
+	var value []byte
+	err = tx.read(func(c *Client, txID int64) error {
+		reply, err := c.Get(ctx, &pb.GetRequest{
+			TxId: txID,
+			Key:  key,
+		})
+		if err == nil {
+			value = reply.Value
+		}
+		return err
+	})
+
+	if err != nil {
+		if isNotFound(err) {
 			return nil, kvzoo.ErrNotFound
 		} else {
 			return nil, err
 		}
 	} else {
-		return reply.Value, nil
+		return value, nil
 	}
 }
 
-func (tx *ProxyTransaction) List() (map[string][]byte, error) {
-	req := &pb.ListRequest{
-		TxId: tx.ids[0],
-	}
-	if reply, err := tx.proxy.master.List(context.TODO(), req); err != nil {
+func (tx *ProxyTransaction) List() (_ map[string][]byte, err error) {
+	ctx, span := tx.startSpan("List")
+	defer func() {
+		span.End(err)
+	}()
+
+	var values map[string][]byte
+	err = tx.read(func(c *Client, txID int64) error {
+		reply, err := c.List(ctx, &pb.ListRequest{
+			TxId: txID,
+		})
+		if err == nil {
+			values = reply.Values
+		}
+		return err
+	})
+
+	if err != nil {
 		return nil, err
 	} else {
-		return reply.Values, nil
+		return values, nil
 	}
 }
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+type ReadPolicy string
+
+const (
+	//all the reads go to master
+	ReadFromMaster ReadPolicy = "master"
+	//reads are spread over slaves in turn, master is used only when no
+	//slave is fresh enough
+	ReadRoundRobin ReadPolicy = "round-robin"
+	//reads go to the replica with least latency, master included
+	ReadLeastLatency ReadPolicy = "least-latency"
+	//same as round robin, but failed read on slave is retried on master
+	ReadSlaveWithFallback ReadPolicy = "slave-with-fallback"
+)
+
+// each write committed by this proxy gets a sequence number, the sequence
+// of the first write missed by a slave is recorded, so the lag of slave is
+// the count of writes since then. the sequence is local to the proxy, writes
+// of other clients aren't counted, so lag is only a hint of this client to
+// repair slave, whether slave serves reads is decided by the revisions of
+// servers. lag is reset only when the slave is verified to be same with
+// master by Checksum or Repair
+type replica struct {
+	*Client
+	firstMissedWrite int64
+	latency          latency
+	health
+}
+
+// slave is found different with master, but which write is missed is unknown
+const unknownMissedWrite = int64(-1)
+
+func newReplica(c *Client, circuitBreakerThreshold int) *replica {
+	return &replica{
+		Client: c,
+		health: health{threshold: circuitBreakerThreshold},
+	}
+}
+
+func (r *replica) markDiverged(seq int64) {
+	if seq == unknownMissedWrite {
+		atomic.StoreInt64(&r.firstMissedWrite, seq)
+	} else {
+		atomic.CompareAndSwapInt64(&r.firstMissedWrite, 0, seq)
+	}
+}
+
+// markSynced clears the divergence which happened no later than seq,
+// divergence after it may not be covered by the verification
+func (r *replica) markSynced(seq int64) {
+	for {
+		diverged := atomic.LoadInt64(&r.firstMissedWrite)
+		if diverged == 0 || diverged > seq {
+			return
+		}
+		if atomic.CompareAndSwapInt64(&r.firstMissedWrite, diverged, 0) {
+			return
+		}
+	}
+}
+
+func (r *replica) lag(current int64) int64 {
+	diverged := atomic.LoadInt64(&r.firstMissedWrite)
+	if diverged == 0 {
+		return 0
+	} else if diverged == unknownMissedWrite {
+		return math.MaxInt64
+	}
+	return current - diverged + 1
+}
+
+// latency is the moving average of rpc latency in nanoseconds
+type latency struct {
+	value int64
+}
+
+func (l *latency) observe(start time.Time) {
+	sample := int64(time.Since(start))
+	for {
+		old := atomic.LoadInt64(&l.value)
+		avg := sample
+		if old != 0 {
+			avg = old + (sample-old)/8
+		}
+		if atomic.CompareAndSwapInt64(&l.value, old, avg) {
+			return
+		}
+	}
+}
+
+func (l *latency) get() time.Duration {
+	return time.Duration(atomic.LoadInt64(&l.value))
+}
+
+func (p *Proxy) nextWriteSeq() int64 {
+	return atomic.AddInt64(&p.writeSeq, 1)
+}
+
+func (p *Proxy) currentWriteSeq() int64 {
+	return atomic.LoadInt64(&p.writeSeq)
+}
+
+// staleness returns the count of writes of master which aren't applied by
+// slave, it's unknown if slave hasn't been synced with master or master has
+// changed its epoch
+func staleness(master, applied *pb.Revision) int64 {
+	if master == nil || applied == nil || master.Epoch != applied.Epoch {
+		return math.MaxInt64
+	} else if applied.Number >= master.Number {
+		return 0
+	}
+	return master.Number - applied.Number
+}
+
+func (p *Proxy) fresh(master, applied *pb.Revision) bool {
+	return staleness(master, applied) <= p.options.maxStaleness
+}
+
+// checkReplicas compares checksum of slaves with master, and updates the
+// lag of slaves accordingly, the error of each slave is returned in order.
+// slave with the same checksum knows it has applied the writes of master
+func (p *Proxy) checkReplicas(slaves []*replica) (string, []error, error) {
+	seq := p.currentWriteSeq()
+	reply, err := p.master.Checksum(context.TODO(), &pb.ChecksumRequest{})
+	if err != nil {
+		return "", nil, err
+	}
+
+	req := &pb.ChecksumRequest{
+		MasterChecksum: reply.Checksum,
+		Revision:       reply.Revision,
+	}
+	errs := make([]error, len(slaves))
+	for i, slave := range slaves {
+		if slave.available() == false {
+			errs[i] = errCircuitOpen
+		} else if r, err := slave.Checksum(context.TODO(), req); err != nil {
+			slave.observe(err)
+			errs[i] = err
+		} else if r.Checksum != reply.Checksum {
+			errs[i] = errChecksumMismatch
+			slave.markDiverged(unknownMissedWrite)
+		} else {
+			slave.markSynced(seq)
+		}
+	}
+	return reply.Checksum, errs, nil
+}
+
+// syncRevisions lets slaves which have the same checksum with master know
+// they have applied the writes of master, it's called after slaves are
+// repaired, slave which fails only doesn't serve reads
+func (p *Proxy) syncRevisions(slaves []*replica) {
+	reply, err := p.master.Checksum(context.TODO(), &pb.ChecksumRequest{})
+	if err != nil {
+		log.Warnf("get checksum of master failed:%s", err.Error())
+		return
+	}
+
+	req := &pb.ChecksumRequest{
+		MasterChecksum: reply.Checksum,
+		Revision:       reply.Revision,
+	}
+	for _, slave := range slaves {
+		r, err := slave.Checksum(context.TODO(), req)
+		slave.observe(err)
+		if err != nil {
+			log.Warnf("%s get checksum failed:%s", slave.Target(), err.Error())
+		} else if r.Checksum != reply.Checksum {
+			log.Warnf("%s isn't same with master after repair", slave.Target())
+		}
+	}
+}
+
+// readers returns the indexes of replicas which could serve reads in the
+// order of read policy, 0 is master which is always the last one, slaves
+// which are down or not usable are skipped
+func (p *Proxy) readers(slaves []*replica, usable func(i int) bool) []int {
+	readers := make([]int, 0, 1+len(slaves))
+	switch p.options.readPolicy {
+	case ReadRoundRobin, ReadSlaveWithFallback:
+		if len(slaves) == 0 {
+			break
+		}
+		start := int(atomic.AddUint64(&p.nextSlave, 1) % uint64(len(slaves)))
+		for j := 0; j < len(slaves); j++ {
+			i := (start + j) % len(slaves)
+			if slaves[i].available() && usable(i) {
+				readers = append(readers, i+1)
+			}
+		}
+	case ReadLeastLatency:
+		master := p.masterLatency.get()
+		latencies := make(map[int]time.Duration)
+		for i, slave := range slaves {
+			if l := slave.latency.get(); l < master && slave.available() && usable(i) {
+				readers = append(readers, i+1)
+				latencies[i+1] = l
+			}
+		}
+		sort.SliceStable(readers, func(i, j int) bool {
+			return latencies[readers[i]] < latencies[readers[j]]
+		})
+	}
+	return append(readers, 0)
+}
@@ -0,0 +1,253 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/tracing"
+)
+
+var errReadOnlyTx = fmt.Errorf("transaction is read only")
+
+// readOnlyTransaction begins read only transaction on the replica chosen by
+// read policy only, so it doesn't lock master or other slaves, and it isn't
+// blocked by writers on the replica
+type readOnlyTransaction struct {
+	proxy     *Proxy
+	tableName string
+	members   *members
+	//nil means master
+	slave *replica
+	id    int64
+	done  bool
+	ctx   context.Context
+	span  *tracing.Span
+}
+
+func (tb *ProxyTable) BeginReadOnly() (kvzoo.Transaction, error) {
+	return tb.BeginReadOnlyWithContext(context.Background())
+}
+
+// BeginReadOnlyWithContext begins read only transaction whose spans are
+// children of the span in ctx
+func (tb *ProxyTable) BeginReadOnlyWithContext(ctx context.Context) (kvzoo.Transaction, error) {
+	ctx, span := tb.proxy.options.tracer.Start(ctx, "kvzoo.Transaction")
+	span.SetAttribute("table", tb.tableName)
+	span.SetAttribute("readOnly", true)
+	opCtx, opSpan := tb.proxy.options.tracer.Start(ctx, "kvzoo.Begin")
+	tx, err := tb.beginReadOnly(opCtx)
+	opSpan.End(err)
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+
+	tx.ctx = ctx
+	tx.span = span
+	return tx, nil
+}
+
+func (tb *ProxyTable) beginReadOnly(ctx context.Context) (*readOnlyTransaction, error) {
+	p := tb.proxy
+	m := p.joinMembers()
+	tx := &readOnlyTransaction{
+		proxy:     p,
+		tableName: tb.tableName,
+		members:   m,
+	}
+
+	readers := p.readers(m.slaves, func(int) bool { return true })
+	if readers[0] != 0 {
+		//staleness of slave is known after its transaction begins, stale
+		//slave is skipped
+		master, err := p.master.Revision(ctx, &pb.RevisionRequest{})
+		if err != nil {
+			m.txs.Done()
+			return nil, err
+		}
+
+		for _, i := range readers[:len(readers)-1] {
+			slave := m.slaves[i-1]
+			applied, err := tx.begin(ctx, slave)
+			if err != nil {
+				if p.options.readPolicy != ReadSlaveWithFallback {
+					m.txs.Done()
+					return nil, err
+				}
+				log.Warnf("%s BeginTransaction failed, fallback to master:%s", slave.Target(), err.Error())
+				break
+			} else if p.fresh(master.Revision, applied) {
+				return tx, nil
+			}
+			slave.RollbackTransaction(ctx, &pb.RollbackTransactionRequest{
+				TxId: tx.id,
+			})
+		}
+	}
+
+	if _, err := tx.begin(ctx, nil); err != nil {
+		m.txs.Done()
+		return nil, err
+	}
+	return tx, nil
+}
+
+// begin transaction on slave, nil slave means master, writes of master
+// applied by the replica is returned
+func (tx *readOnlyTransaction) begin(ctx context.Context, slave *replica) (*pb.Revision, error) {
+	c := tx.proxy.master
+	if slave != nil {
+		c = slave.Client
+	}
+
+	reply, err := c.BeginTransaction(ctx, &pb.BeginTransactionRequest{
+		TableName: tx.tableName,
+		ReadOnly:  true,
+	})
+	if slave != nil {
+		slave.observe(err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	tx.slave = slave
+	tx.id = reply.TxId
+	return reply.Applied, nil
+}
+
+func (tx *readOnlyTransaction) client() *Client {
+	if tx.slave != nil {
+		return tx.slave.Client
+	}
+	return tx.proxy.master
+}
+
+// read runs f in the transaction, with ReadSlaveWithFallback policy, failed
+// read on slave is retried in a new transaction on master
+func (tx *readOnlyTransaction) read(ctx context.Context, f func(c *Client, txID int64) error) error {
+	if tx.done {
+		return errTxFinished
+	}
+
+	p := tx.proxy
+	if slave := tx.slave; slave != nil {
+		start := time.Now()
+		err := f(slave.Client, tx.id)
+		if err != nil && isNotFound(err) == false {
+			slave.observe(err)
+		}
+		if err == nil || isNotFound(err) {
+			slave.latency.observe(start)
+			return err
+		} else if p.options.readPolicy != ReadSlaveWithFallback {
+			return err
+		}
+
+		log.Warnf("%s read failed, fallback to master:%s", slave.Target(), err.Error())
+		slave.RollbackTransaction(ctx, &pb.RollbackTransactionRequest{
+			TxId: tx.id,
+		})
+		if _, err := tx.begin(ctx, nil); err != nil {
+			return err
+		}
+	}
+
+	start := time.Now()
+	err := f(p.master, tx.id)
+	if err == nil || isNotFound(err) {
+		p.masterLatency.observe(start)
+	}
+	return err
+}
+
+func (tx *readOnlyTransaction) Get(key string) (_ []byte, err error) {
+	ctx, span := tx.proxy.options.tracer.Start(tx.ctx, "kvzoo.Get")
+	span.SetAttribute("key", key)
+	defer func() {
+		span.End(err)
+	}()
+
+	var value []byte
+	err = tx.read(ctx, func(c *Client, txID int64) error {
+		reply, err := c.Get(ctx, &pb.GetRequest{
+			TxId: txID,
+			Key:  key,
+		})
+		if err == nil {
+			value = reply.Value
+		}
+		return err
+	})
+
+	if err != nil {
+		if isNotFound(err) {
+			return nil, kvzoo.ErrNotFound
+		}
+		return nil, err
+	}
+	return value, nil
+}
+
+func (tx *readOnlyTransaction) List() (_ map[string][]byte, err error) {
+	ctx, span := tx.proxy.options.tracer.Start(tx.ctx, "kvzoo.List")
+	defer func() {
+		span.End(err)
+	}()
+
+	var values map[string][]byte
+	err = tx.read(ctx, func(c *Client, txID int64) error {
+		reply, err := c.List(ctx, &pb.ListRequest{
+			TxId: txID,
+		})
+		if err == nil {
+			values = reply.Values
+		}
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (tx *readOnlyTransaction) Add(key string, value []byte) error {
+	return errReadOnlyTx
+}
+
+func (tx *readOnlyTransaction) Update(key string, value []byte) error {
+	return errReadOnlyTx
+}
+
+func (tx *readOnlyTransaction) Delete(key string) error {
+	return errReadOnlyTx
+}
+
+// Commit is same with Rollback, since nothing is written
+func (tx *readOnlyTransaction) Commit() error {
+	return tx.Rollback()
+}
+
+func (tx *readOnlyTransaction) Rollback() (err error) {
+	if tx.done {
+		return nil
+	}
+
+	ctx, span := tx.proxy.options.tracer.Start(tx.ctx, "kvzoo.Rollback")
+	defer func() {
+		span.End(err)
+		tx.done = true
+		tx.members.txs.Done()
+		tx.span.End(err)
+	}()
+
+	_, err = tx.client().RollbackTransaction(ctx, &pb.RollbackTransactionRequest{
+		TxId: tx.id,
+	})
+	return err
+}
@@ -0,0 +1,390 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+	"github.com/zdnscloud/kvzoo"
+)
+
+const (
+	DefaultAntiEntropyInterval = 10 * time.Minute
+	//max keys copied in one transaction
+	repairChunkSize = 100
+)
+
+type AntiEntropyConfig struct {
+	//interval between two rounds of repair, default to DefaultAntiEntropyInterval
+	Interval time.Duration
+	//table to check, all its child tables are checked too, default to Root
+	Table kvzoo.TableName
+	//max keys written to slaves per second, <= 0 means no limit
+	MaxKeysPerSecond int
+}
+
+type RepairResult struct {
+	DivergentTables int `json:"divergentTables"`
+	RepairedTables  int `json:"repairedTables"`
+	RepairedKeys    int `json:"repairedKeys"`
+}
+
+// RepairStats accumulates the results of all the repair rounds
+type RepairStats struct {
+	Rounds          int64
+	FailedRounds    int64
+	DivergentTables int64
+	RepairedTables  int64
+	RepairedKeys    int64
+	LastRound       time.Time
+	LastErr         string
+}
+
+type repairState struct {
+	lock        sync.Mutex
+	stats       RepairStats
+	antiEntropy *antiEntropy
+}
+
+type antiEntropy struct {
+	stopCh chan struct{}
+	doneCh chan struct{}
+}
+
+// StartAntiEntropy periodically compares slaves with master and repairs
+// the differences, the running loop is replaced if it's started twice
+func (p *Proxy) StartAntiEntropy(conf AntiEntropyConfig) {
+	if conf.Interval <= 0 {
+		conf.Interval = DefaultAntiEntropyInterval
+	}
+	if conf.Table == "" {
+		conf.Table = kvzoo.Root
+	}
+
+	p.StopAntiEntropy()
+	ae := &antiEntropy{
+		stopCh: make(chan struct{}),
+		doneCh: make(chan struct{}),
+	}
+	p.repair.lock.Lock()
+	p.repair.antiEntropy = ae
+	p.repair.lock.Unlock()
+
+	go func() {
+		defer close(ae.doneCh)
+		ticker := time.NewTicker(conf.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ae.stopCh:
+				return
+			case <-ticker.C:
+				limiter := newRateLimiter(conf.MaxKeysPerSecond)
+				if result, err := p.repairReplicas(conf.Table, p.getSlaves(), limiter); err != nil {
+					log.Warnf("anti entropy of %s failed:%s", conf.Table, err.Error())
+				} else if result.DivergentTables > 0 {
+					log.Infof("anti entropy repaired %d keys and %d tables in %d divergent tables",
+						result.RepairedKeys, result.RepairedTables, result.DivergentTables)
+				}
+			}
+		}
+	}()
+}
+
+func (p *Proxy) StopAntiEntropy() {
+	p.repair.lock.Lock()
+	ae := p.repair.antiEntropy
+	p.repair.antiEntropy = nil
+	p.repair.lock.Unlock()
+
+	if ae != nil {
+		close(ae.stopCh)
+		<-ae.doneCh
+	}
+}
+
+func (p *Proxy) RepairStats() RepairStats {
+	p.repair.lock.Lock()
+	defer p.repair.lock.Unlock()
+	return p.repair.stats
+}
+
+// Repair makes table and its child tables in all slaves same with master,
+// failure of one slave doesn't stop repairing others, the first error is
+// returned
+func (p *Proxy) Repair(tableName kvzoo.TableName) (*RepairResult, error) {
+	return p.repairReplicas(tableName, p.getSlaves(), nil)
+}
+
+// RepairWithRate is same with Repair except at most keysPerSecond keys
+// are written to slaves per second
+func (p *Proxy) RepairWithRate(tableName kvzoo.TableName, keysPerSecond int) (*RepairResult, error) {
+	return p.repairReplicas(tableName, p.getSlaves(), newRateLimiter(keysPerSecond))
+}
+
+func (p *Proxy) repairReplicas(tableName kvzoo.TableName, slaves []*replica, limiter *rateLimiter) (*RepairResult, error) {
+	result := &RepairResult{}
+	divergentTables := make(map[kvzoo.TableName]struct{})
+	master := newReplicaDB(p.master)
+	seq := p.currentWriteSeq()
+	var firstErr error
+	var synced []*replica
+	for _, slave := range slaves {
+		r := &replicaRepairer{
+			master:  master,
+			slave:   newReplicaDB(slave.Client),
+			limiter: limiter,
+			result:  result,
+		}
+		if err := r.repair(tableName, divergentTables); err != nil {
+			log.Warnf("%s repair %s failed:%s", slave.Target(), tableName, err.Error())
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s repair failed:%s", slave.Target(), err.Error())
+			}
+		} else if tableName == kvzoo.Root {
+			slave.markSynced(seq)
+			synced = append(synced, slave)
+		}
+	}
+	result.DivergentTables = len(divergentTables)
+	if len(synced) != 0 {
+		p.syncRevisions(synced)
+	}
+
+	p.repair.lock.Lock()
+	stats := &p.repair.stats
+	stats.Rounds += 1
+	stats.DivergentTables += int64(result.DivergentTables)
+	stats.RepairedTables += int64(result.RepairedTables)
+	stats.RepairedKeys += int64(result.RepairedKeys)
+	stats.LastRound = time.Now()
+	if firstErr != nil {
+		stats.FailedRounds += 1
+		stats.LastErr = firstErr.Error()
+	} else {
+		stats.LastErr = ""
+	}
+	p.repair.lock.Unlock()
+	return result, firstErr
+}
+
+type replicaRepairer struct {
+	master  *replicaDB
+	slave   *replicaDB
+	limiter *rateLimiter
+	result  *RepairResult
+}
+
+func (r *replicaRepairer) repair(tableName kvzoo.TableName, divergentTables map[kvzoo.TableName]struct{}) error {
+	diffs, err := kvzoo.Diff(r.master, r.slave, tableName)
+	if err != nil {
+		return err
+	}
+
+	var tables []kvzoo.TableName
+	keys := make(map[kvzoo.TableName][]string)
+	for _, d := range diffs {
+		divergentTables[d.Table] = struct{}{}
+		if d.Key != "" {
+			if _, ok := keys[d.Table]; ok == false {
+				tables = append(tables, d.Table)
+			}
+			keys[d.Table] = append(keys[d.Table], d.Key)
+		} else if d.Type == kvzoo.DiffMissing {
+			if err := r.copyTable(d.Table); err != nil {
+				return err
+			}
+		} else if err := r.slave.DeleteTable(d.Table); err != nil {
+			return err
+		} else {
+			r.result.RepairedTables += 1
+		}
+	}
+
+	for _, tn := range tables {
+		if err := r.syncKeys(tn, keys[tn]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *replicaRepairer) copyTable(tableName kvzoo.TableName) error {
+	children, err := r.master.ListTables(tableName, true)
+	if err != nil {
+		return err
+	}
+
+	for _, tn := range append([]kvzoo.TableName{tableName}, children...) {
+		if err := r.syncKeys(tn, nil); err != nil {
+			return err
+		}
+	}
+	r.result.RepairedTables += 1
+	return nil
+}
+
+// syncKeys copies keys of table from master to slave, nil keys means all
+// the keys in master. the keys to copy are found by digests outside of
+// write transactions, then they are copied in chunks, the master transaction
+// of each chunk is held until slave commits, since proxy transactions lock
+// master before slaves, no write to the keys could sneak in between. only
+// keys which are changed in slave are rate limited, and the limiter waits
+// after transactions are finished
+func (r *replicaRepairer) syncKeys(tableName kvzoo.TableName, keys []string) error {
+	if exists, err := r.master.TableExists(tableName); err != nil || exists == false {
+		//table deleted from master after diff, leave it to next round
+		return err
+	}
+
+	if keys == nil {
+		var err error
+		if keys, err = r.differentKeys(tableName); err != nil {
+			return err
+		}
+	}
+
+	for len(keys) > 0 {
+		chunk := keys
+		if len(chunk) > repairChunkSize {
+			chunk = chunk[:repairChunkSize]
+		}
+		keys = keys[len(chunk):]
+
+		repaired, err := r.syncChunk(tableName, chunk)
+		if err != nil {
+			return err
+		}
+		r.result.RepairedKeys += repaired
+		r.limiter.wait(repaired)
+	}
+	return nil
+}
+
+// keys which are missing, extra or changed in slave, compared by digests
+// of all the keys in table, digests don't include child tables
+func (r *replicaRepairer) differentKeys(tableName kvzoo.TableName) ([]string, error) {
+	all := []kvzoo.KeyRange{{}}
+	masterDigests, err := r.master.KeyDigests(tableName, all)
+	if err != nil {
+		return nil, err
+	}
+
+	var slaveDigests map[string][]byte
+	if exists, err := r.slave.TableExists(tableName); err != nil {
+		return nil, err
+	} else if exists {
+		if slaveDigests, err = r.slave.KeyDigests(tableName, all); err != nil {
+			return nil, err
+		}
+	}
+
+	var keys []string
+	for k, digest := range masterDigests {
+		if bytes.Equal(digest, slaveDigests[k]) == false {
+			keys = append(keys, k)
+		}
+	}
+	for k := range slaveDigests {
+		if _, ok := masterDigests[k]; ok == false {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (r *replicaRepairer) syncChunk(tableName kvzoo.TableName, keys []string) (int, error) {
+	mtx, err := beginTransaction(r.master, tableName)
+	if err != nil {
+		return 0, err
+	}
+	defer mtx.Rollback()
+
+	stx, err := beginTransaction(r.slave, tableName)
+	if err != nil {
+		return 0, err
+	}
+	defer stx.Rollback()
+
+	repaired := 0
+	for _, k := range keys {
+		exists := true
+		value, err := mtx.Get(k)
+		if err == kvzoo.ErrNotFound {
+			exists = false
+		} else if err != nil {
+			return 0, err
+		}
+
+		if changed, err := putKey(stx, k, value, exists); err != nil {
+			return 0, err
+		} else if changed {
+			repaired += 1
+		}
+	}
+
+	if err := stx.Commit(); err != nil {
+		return 0, err
+	}
+	return repaired, nil
+}
+
+func beginTransaction(db *replicaDB, tableName kvzoo.TableName) (kvzoo.Transaction, error) {
+	table, err := db.CreateOrGetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return table.Begin()
+}
+
+func putKey(tx kvzoo.Transaction, key string, value []byte, exists bool) (bool, error) {
+	old, err := tx.Get(key)
+	if err == kvzoo.ErrNotFound {
+		if exists == false {
+			return false, nil
+		}
+		return true, tx.Add(key, value)
+	} else if err != nil {
+		return false, err
+	}
+
+	if exists == false {
+		return true, tx.Delete(key)
+	} else if bytes.Equal(old, value) {
+		return false, nil
+	} else {
+		return true, tx.Update(key, value)
+	}
+}
+
+type rateLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	if perSecond <= 0 {
+		return nil
+	}
+	return &rateLimiter{
+		interval: time.Second / time.Duration(perSecond),
+	}
+}
+
+// wait blocks until the n keys which are written are allowed by the rate
+func (l *rateLimiter) wait(n int) {
+	if l == nil || n == 0 {
+		return
+	}
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(n) * l.interval)
+	time.Sleep(l.next.Sub(now))
+}
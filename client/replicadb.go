@@ -0,0 +1,204 @@
+package client
+
+import (
+	"context"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+// replicaDB reads and writes one replica only, writes aren't replicated to
+// others, it's used to compare and repair replicas
+type replicaDB struct {
+	client *Client
+}
+
+func newReplicaDB(c *Client) *replicaDB {
+	return &replicaDB{
+		client: c,
+	}
+}
+
+func (db *replicaDB) Checksum() (string, error) {
+	reply, err := db.client.Checksum(context.TODO(), &pb.ChecksumRequest{})
+	if err != nil {
+		return "", err
+	}
+	return reply.Checksum, nil
+}
+
+func (db *replicaDB) CreateOrGetTable(tableName kvzoo.TableName) (kvzoo.Table, error) {
+	if _, err := db.client.CreateOrGetTable(context.TODO(), &pb.CreateOrGetTableRequest{
+		Name: string(tableName),
+	}); err != nil {
+		return nil, err
+	}
+
+	return &replicaTable{
+		client:    db.client,
+		tableName: string(tableName),
+	}, nil
+}
+
+func (db *replicaDB) DeleteTable(tableName kvzoo.TableName) error {
+	_, err := db.client.DeleteTable(context.TODO(), &pb.DeleteTableRequest{
+		Name: string(tableName),
+	})
+	return err
+}
+
+func (db *replicaDB) ListTables(parent kvzoo.TableName, recursive bool) ([]kvzoo.TableName, error) {
+	reply, err := db.client.ListTables(context.TODO(), &pb.ListTablesRequest{
+		Parent:    string(parent),
+		Recursive: recursive,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	tables := make([]kvzoo.TableName, 0, len(reply.Names))
+	for _, name := range reply.Names {
+		tables = append(tables, kvzoo.TableName(name))
+	}
+	return tables, nil
+}
+
+func (db *replicaDB) TableExists(tableName kvzoo.TableName) (bool, error) {
+	reply, err := db.client.TableExists(context.TODO(), &pb.TableExistsRequest{
+		Name: string(tableName),
+	})
+	if err != nil {
+		return false, err
+	}
+	return reply.Exists, nil
+}
+
+func (db *replicaDB) MerkleTree(tableName kvzoo.TableName, boundaries []string) (*kvzoo.MerkleTree, error) {
+	reply, err := db.client.MerkleTree(context.TODO(), &pb.MerkleTreeRequest{
+		Name:       string(tableName),
+		Boundaries: boundaries,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &kvzoo.MerkleTree{
+		Checksum:   reply.Checksum,
+		Boundaries: reply.Boundaries,
+		Nodes:      reply.Nodes,
+	}, nil
+}
+
+func (db *replicaDB) KeyDigests(tableName kvzoo.TableName, ranges []kvzoo.KeyRange) (map[string][]byte, error) {
+	req := &pb.KeyDigestsRequest{
+		Name:   string(tableName),
+		Ranges: make([]*pb.KeyRange, 0, len(ranges)),
+	}
+	for _, r := range ranges {
+		req.Ranges = append(req.Ranges, &pb.KeyRange{
+			Begin: r.Begin,
+			End:   r.End,
+		})
+	}
+
+	reply, err := db.client.KeyDigests(context.TODO(), req)
+	if err != nil {
+		return nil, err
+	}
+	return reply.Digests, nil
+}
+
+type replicaTable struct {
+	client    *Client
+	tableName string
+}
+
+func (tb *replicaTable) Begin() (kvzoo.Transaction, error) {
+	reply, err := tb.client.BeginTransaction(context.TODO(), &pb.BeginTransactionRequest{
+		TableName: tb.tableName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &replicaTransaction{
+		client: tb.client,
+		id:     reply.TxId,
+	}, nil
+}
+
+type replicaTransaction struct {
+	client *Client
+	id     int64
+	done   bool
+}
+
+func (tx *replicaTransaction) Commit() error {
+	tx.done = true
+	_, err := tx.client.CommitTransaction(context.TODO(), &pb.CommitTransactionRequest{
+		TxId: tx.id,
+	})
+	return err
+}
+
+// Rollback after commit or rollback does nothing
+func (tx *replicaTransaction) Rollback() error {
+	if tx.done {
+		return nil
+	}
+	tx.done = true
+	_, err := tx.client.RollbackTransaction(context.TODO(), &pb.RollbackTransactionRequest{
+		TxId: tx.id,
+	})
+	return err
+}
+
+func (tx *replicaTransaction) Add(key string, value []byte) error {
+	_, err := tx.client.Add(context.TODO(), &pb.AddRequest{
+		TxId:  tx.id,
+		Key:   key,
+		Value: value,
+	})
+	return err
+}
+
+func (tx *replicaTransaction) Delete(key string) error {
+	_, err := tx.client.Delete(context.TODO(), &pb.DeleteRequest{
+		TxId: tx.id,
+		Key:  key,
+	})
+	return err
+}
+
+func (tx *replicaTransaction) Update(key string, value []byte) error {
+	_, err := tx.client.Update(context.TODO(), &pb.UpdateRequest{
+		TxId:  tx.id,
+		Key:   key,
+		Value: value,
+	})
+	return err
+}
+
+func (tx *replicaTransaction) Get(key string) ([]byte, error) {
+	reply, err := tx.client.Get(context.TODO(), &pb.GetRequest{
+		TxId: tx.id,
+		Key:  key,
+	})
+	if err != nil {
+		if isNotFound(err) {
+			return nil, kvzoo.ErrNotFound
+		}
+		return nil, err
+	}
+	return reply.Value, nil
+}
+
+func (tx *replicaTransaction) List() (map[string][]byte, error) {
+	reply, err := tx.client.List(context.TODO(), &pb.ListRequest{
+		TxId: tx.id,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.Values, nil
+}
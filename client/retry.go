@@ -0,0 +1,166 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+type RetryPolicy struct {
+	//max attempts including the first one, <= 1 means no retry
+	MaxAttempts    int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+	//backoff is randomized in [backoff*(1-Jitter), backoff*(1+Jitter)]
+	Jitter float64
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:    5,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     5 * time.Second,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+// Backoff returns the time to wait after attempt fails, attempt starts from 1
+func (p RetryPolicy) Backoff(attempt int) time.Duration {
+	backoff := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(attempt-1))
+	if max := float64(p.MaxBackoff); max > 0 && backoff > max {
+		backoff = max
+	}
+	if p.Jitter > 0 {
+		backoff *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(backoff)
+}
+
+// Retry implements kvzoo.Retrier, transaction is run again if it's rolled
+// back by server or master is unavailable before commit
+func (p RetryPolicy) Retry(attempt int, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || isReplayable(err) == false {
+		return 0, false
+	}
+	return p.Backoff(attempt), true
+}
+
+// wait sleeps backoff of attempt, false is returned if no more attempt is
+// allowed or ctx is done
+func (p RetryPolicy) wait(ctx context.Context, attempt int) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+
+	timer := time.NewTimer(p.Backoff(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// IsRetriable returns whether the request failed without side effect and
+// may succeed later
+func IsRetriable(err error) bool {
+	code := status.Code(err)
+	return code == codes.Unavailable || code == codes.ResourceExhausted
+}
+
+// isReplayable returns whether the whole transaction could be replayed,
+// besides retriable errors, transaction rolled back by server is included.
+// failed commit is only replayed if master rejected it without applying
+func isReplayable(err error) bool {
+	if e, ok := err.(*commitError); ok {
+		code := status.Code(e.err)
+		return code == codes.Aborted || code == codes.ResourceExhausted
+	}
+	return IsRetriable(err) || status.Code(err) == codes.Aborted
+}
+
+// commitError is returned by Commit if master fails to commit, unreachable
+// master may have committed before the reply is lost, so it's kept from
+// replaying. it has the same status with err
+type commitError struct {
+	err error
+}
+
+func (e *commitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *commitError) GRPCStatus() *status.Status {
+	return status.Convert(e.err)
+}
+
+// requests which don't change data and aren't bound to transaction are safe
+// to retry, requests in transaction like Get and List are not, since the
+// transaction may already be rolled back by server, they fail the
+// transaction which could be replayed as a whole by Update. Batch without
+// writes is also retried
+var idempotentMethods = map[string]bool{
+	"/pb.KVS/Checksum":         true,
+	"/pb.KVS/ListTables":       true,
+	"/pb.KVS/TableExists":      true,
+	"/pb.KVS/Stats":            true,
+	"/pb.KVS/MerkleTree":       true,
+	"/pb.KVS/KeyDigests":       true,
+	"/pb.KVS/ListTransactions": true,
+	"/pb.KVS/Revision":         true,
+}
+
+func isIdempotent(method string, req interface{}) bool {
+	if batch, ok := req.(*pb.BatchRequest); ok {
+		return batch.HasWrites() == false
+	}
+	return idempotentMethods[method]
+}
+
+// RetryInterceptor retries idempotent requests with policy
+func RetryInterceptor(policy RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if isIdempotent(method, req) == false {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || IsRetriable(err) == false || policy.wait(ctx, attempt) == false {
+				return err
+			}
+		}
+	}
+}
+
+// Update runs f in a transaction of table and commits it, if f fails with
+// retriable error, the transaction is rolled back and replayed with the
+// retry policy of proxy. f may run several times, it shouldn't have side
+// effect out of the transaction
+func (p *Proxy) Update(tableName kvzoo.TableName, f func(kvzoo.Transaction) error) error {
+	return kvzoo.UpdateWithRetry(&reopenedTable{proxy: p, name: tableName}, f, p.options.retryPolicy)
+}
+
+// reopenedTable opens table in each attempt, since restarted server forgets
+// the opened tables
+type reopenedTable struct {
+	proxy *Proxy
+	name  kvzoo.TableName
+}
+
+func (t *reopenedTable) Begin() (kvzoo.Transaction, error) {
+	table, err := t.proxy.CreateOrGetTable(t.name)
+	if err != nil {
+		return nil, err
+	}
+	return table.Begin()
+}
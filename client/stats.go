@@ -0,0 +1,92 @@
+package client
+
+import (
+	"context"
+
+	"github.com/zdnscloud/cement/log"
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+type ReplicaStats struct {
+	Addr  string
+	Stats *kvzoo.TableStats
+	Err   error
+}
+
+// Stats returns the stats of master, divergence of slaves is logged
+func (p *Proxy) Stats(tableName kvzoo.TableName) (*kvzoo.TableStats, error) {
+	stats, err := p.ReplicaStats(tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	master := stats[0].Stats
+	for _, s := range stats[1:] {
+		if s.Err != nil {
+			log.Warnf("%s get stats of %s failed:%s", s.Addr, tableName, s.Err.Error())
+		} else if master.SameSize(s.Stats) == false {
+			log.Warnf("size of table %s in %s isn't same with master %s", tableName, s.Addr, stats[0].Addr)
+		}
+	}
+	return master, nil
+}
+
+// ReplicaStats returns the stats of all the replicas, master comes first,
+// failure of slaves is returned in Err of each replica
+func (p *Proxy) ReplicaStats(tableName kvzoo.TableName) ([]ReplicaStats, error) {
+	master, err := getStats(p.master, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	slaves := p.getSlaves()
+	stats := make([]ReplicaStats, 0, 1+len(slaves))
+	stats = append(stats, ReplicaStats{
+		Addr:  p.master.Target(),
+		Stats: master,
+	})
+	for _, slave := range slaves {
+		s, err := getStats(slave.Client, tableName)
+		stats = append(stats, ReplicaStats{
+			Addr:  slave.Target(),
+			Stats: s,
+			Err:   err,
+		})
+	}
+	return stats, nil
+}
+
+func getStats(c *Client, tableName kvzoo.TableName) (*kvzoo.TableStats, error) {
+	reply, err := c.Stats(context.TODO(), &pb.StatsRequest{
+		Name: string(tableName),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &kvzoo.TableStats{
+		KeyCount:        int(reply.KeyCount),
+		KeySize:         reply.KeySize,
+		ValueSize:       reply.ValueSize,
+		ChildTableCount: int(reply.ChildTableCount),
+	}
+	if storage := reply.Storage; storage != nil {
+		stats.Storage = kvzoo.StorageStats{
+			BranchPageN:       int(storage.BranchPageN),
+			BranchOverflowN:   int(storage.BranchOverflowN),
+			LeafPageN:         int(storage.LeafPageN),
+			LeafOverflowN:     int(storage.LeafOverflowN),
+			KeyN:              int(storage.KeyN),
+			Depth:             int(storage.Depth),
+			BranchAlloc:       int(storage.BranchAlloc),
+			BranchInuse:       int(storage.BranchInuse),
+			LeafAlloc:         int(storage.LeafAlloc),
+			LeafInuse:         int(storage.LeafInuse),
+			BucketN:           int(storage.BucketN),
+			InlineBucketN:     int(storage.InlineBucketN),
+			InlineBucketInuse: int(storage.InlineBucketInuse),
+		}
+	}
+	return stats, nil
+}
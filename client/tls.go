@@ -0,0 +1,94 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc/credentials"
+
+	"github.com/zdnscloud/kvzoo/internal/tlsutil"
+)
+
+// client certificate and ca are reloaded once their files are modified
+type TLSConfig struct {
+	//ca to verify server, system cas are used if it's empty
+	CAFile string
+	//client certificate, required by server with client ca
+	CertFile string
+	KeyFile  string
+	//name in server certificate, default to the host of server address
+	ServerName string
+}
+
+// credentials of connection to addr, server certificate is verified by the
+// ca pool when it's presented, so modified ca file takes effect on new
+// connections
+func (c *TLSConfig) credentials(addr string) (credentials.TransportCredentials, error) {
+	conf := &tls.Config{
+		ServerName: c.ServerName,
+	}
+
+	if c.CAFile != "" {
+		caPool, err := tlsutil.NewCertPool(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+
+		serverName := c.ServerName
+		if serverName == "" {
+			if serverName, _, err = net.SplitHostPort(addr); err != nil {
+				return nil, err
+			}
+		}
+		//default verification uses the fixed RootCAs, it's replaced by
+		//verifyServerCertificate
+		conf.InsecureSkipVerify = true
+		conf.VerifyPeerCertificate = func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return verifyServerCertificate(caPool, serverName, rawCerts)
+		}
+	}
+
+	if c.CertFile != "" {
+		keyPair, err := tlsutil.NewKeyPair(c.CertFile, c.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		conf.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return keyPair.Certificate()
+		}
+	}
+
+	return credentials.NewTLS(conf), nil
+}
+
+func verifyServerCertificate(caPool *tlsutil.CertPool, serverName string, rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		return fmt.Errorf("server doesn't present certificate")
+	}
+
+	certs := make([]*x509.Certificate, 0, len(rawCerts))
+	for _, raw := range rawCerts {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("parse server certificate failed:%s", err.Error())
+		}
+		certs = append(certs, cert)
+	}
+
+	pool, err := caPool.Pool()
+	if err != nil {
+		return err
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, err = certs[0].Verify(x509.VerifyOptions{
+		DNSName:       serverName,
+		Roots:         pool,
+		Intermediates: intermediates,
+	})
+	return err
+}
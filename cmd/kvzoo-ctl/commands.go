@@ -0,0 +1,585 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/dump"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+func checkArgs(args []string, count int, usage string) error {
+	if len(args) != count {
+		return fmt.Errorf("invalid arguments, usage: %s", usage)
+	}
+	return nil
+}
+
+func withTransaction(ctx *cmdContext, name string, f func(tx kvzoo.Transaction) error) error {
+	return withTransactionEx(ctx, name, true, f)
+}
+
+// when create is false, non-exist table will return error instead of being created
+func withTransactionEx(ctx *cmdContext, name string, create bool, f func(tx kvzoo.Transaction) error) error {
+	return withTable(ctx, name, create, func(table kvzoo.Table) error {
+		return kvzoo.Update(table, f)
+	})
+}
+
+// withView runs f in read only transaction of table which should exist, so
+// it only requires read permission
+func withView(ctx *cmdContext, name string, f func(tx kvzoo.Transaction) error) error {
+	return withTable(ctx, name, false, func(table kvzoo.Table) error {
+		return kvzoo.View(table, f)
+	})
+}
+
+func withTable(ctx *cmdContext, name string, create bool, f func(table kvzoo.Table) error) error {
+	tn, err := kvzoo.NewTableName(name)
+	if err != nil {
+		return err
+	}
+
+	db, err := ctx.proxy()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if create == false {
+		if exists, err := db.TableExists(tn); err != nil {
+			return err
+		} else if exists == false {
+			return fmt.Errorf("table %s doesn't exist", tn)
+		}
+	}
+
+	table, err := db.CreateOrGetTable(tn)
+	if err != nil {
+		return err
+	}
+	return f(table)
+}
+
+func runTables(ctx *cmdContext, args []string) error {
+	fs := flag.NewFlagSet("tables", flag.ContinueOnError)
+	recursive := fs.Bool("r", false, "list all the descendant tables")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("invalid arguments, usage: %s", commands["tables"].usage)
+	}
+
+	parent := kvzoo.TableName(kvzoo.Root)
+	if fs.NArg() == 1 && fs.Arg(0) != kvzoo.Root {
+		tn, err := kvzoo.NewTableName(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		parent = tn
+	}
+
+	db, err := ctx.proxy()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	tables, err := db.ListTables(parent, *recursive)
+	if err != nil {
+		return err
+	}
+
+	if tables == nil {
+		tables = []kvzoo.TableName{}
+	}
+	ctx.output(tables, func() {
+		for _, tn := range tables {
+			fmt.Println(tn)
+		}
+	})
+	return nil
+}
+
+func runGet(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 2, commands["get"].usage); err != nil {
+		return err
+	}
+
+	return withView(ctx, args[0], func(tx kvzoo.Transaction) error {
+		value, err := tx.Get(args[1])
+		if err != nil {
+			return err
+		}
+
+		ctx.output(map[string]string{"key": args[1], "value": string(value)}, func() {
+			fmt.Println(string(value))
+		})
+		return nil
+	})
+}
+
+func runPut(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 3, commands["put"].usage); err != nil {
+		return err
+	}
+
+	return withTransaction(ctx, args[0], func(tx kvzoo.Transaction) error {
+		key, value := args[1], []byte(args[2])
+		if _, err := tx.Get(key); err == kvzoo.ErrNotFound {
+			return tx.Add(key, value)
+		} else if err != nil {
+			return err
+		}
+		return tx.Update(key, value)
+	})
+}
+
+func runDelete(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 2, commands["delete"].usage); err != nil {
+		return err
+	}
+
+	return withTransactionEx(ctx, args[0], false, func(tx kvzoo.Transaction) error {
+		return tx.Delete(args[1])
+	})
+}
+
+func runList(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 1, commands["ls"].usage); err != nil {
+		return err
+	}
+
+	return withView(ctx, args[0], func(tx kvzoo.Transaction) error {
+		values, err := tx.List()
+		if err != nil {
+			return err
+		}
+
+		kvs := make(map[string]string, len(values))
+		for k, v := range values {
+			kvs[k] = string(v)
+		}
+		ctx.output(kvs, func() {
+			for _, k := range sortedKeys(values) {
+				fmt.Printf("%s\t%s\n", k, values[k])
+			}
+		})
+		return nil
+	})
+}
+
+type serverStats struct {
+	Server string            `json:"server"`
+	Stats  *kvzoo.TableStats `json:"stats,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+func runStats(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 1, commands["stats"].usage); err != nil {
+		return err
+	}
+
+	tn, err := kvzoo.NewTableName(args[0])
+	if err != nil {
+		return err
+	}
+
+	db, err := ctx.proxy()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	replicaStats, err := db.(*client.Proxy).ReplicaStats(tn)
+	if err != nil {
+		return err
+	}
+
+	stats := make([]serverStats, 0, len(replicaStats))
+	for _, s := range replicaStats {
+		ss := serverStats{
+			Server: s.Addr,
+			Stats:  s.Stats,
+		}
+		if s.Err != nil {
+			ss.Error = s.Err.Error()
+		}
+		stats = append(stats, ss)
+	}
+
+	ctx.output(stats, func() {
+		fmt.Printf("%-24s %10s %12s %12s %8s %8s %8s\n", "SERVER", "KEYS", "KEY BYTES", "VALUE BYTES", "TABLES", "PAGES", "DEPTH")
+		for _, s := range stats {
+			if s.Stats == nil {
+				fmt.Printf("%-24s %s\n", s.Server, s.Error)
+				continue
+			}
+			storage := s.Stats.Storage
+			fmt.Printf("%-24s %10d %12d %12d %8d %8d %8d\n", s.Server, s.Stats.KeyCount, s.Stats.KeySize, s.Stats.ValueSize,
+				s.Stats.ChildTableCount, storage.BranchPageN+storage.LeafPageN, storage.Depth)
+		}
+	})
+	return nil
+}
+
+type serverChecksum struct {
+	Server   string `json:"server"`
+	Checksum string `json:"checksum"`
+	Same     bool   `json:"sameWithMaster"`
+}
+
+func runChecksum(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 0, commands["checksum"].usage); err != nil {
+		return err
+	}
+
+	var checksums []serverChecksum
+	if err := ctx.forEachServer(func(c *client.Client) error {
+		reply, err := c.Checksum(context.Background(), &pb.ChecksumRequest{})
+		if err != nil {
+			return err
+		}
+		checksums = append(checksums, serverChecksum{
+			Server:   c.Target(),
+			Checksum: reply.Checksum,
+			Same:     len(checksums) == 0 || reply.Checksum == checksums[0].Checksum,
+		})
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	ctx.output(checksums, func() {
+		for i, cs := range checksums {
+			state := "ok"
+			if i == 0 {
+				state = "master"
+			} else if cs.Same == false {
+				state = "mismatch"
+			}
+			fmt.Printf("%s\t%s\t%s\n", cs.Server, cs.Checksum, state)
+		}
+	})
+	return nil
+}
+
+type serverDiff struct {
+	Server string          `json:"server"`
+	Table  kvzoo.TableName `json:"table"`
+	Key    string          `json:"key,omitempty"`
+	Type   kvzoo.DiffType  `json:"type"`
+}
+
+func runDiff(ctx *cmdContext, args []string) error {
+	if len(args) > 1 {
+		return fmt.Errorf("invalid arguments, usage: %s", commands["diff"].usage)
+	}
+
+	tn := kvzoo.TableName(kvzoo.Root)
+	if len(args) == 1 && args[0] != kvzoo.Root {
+		var err error
+		if tn, err = kvzoo.NewTableName(args[0]); err != nil {
+			return err
+		}
+	}
+
+	db, err := ctx.proxy()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	replicaDiffs, err := db.(*client.Proxy).Diff(tn)
+	if err != nil {
+		return err
+	}
+
+	diffs := []serverDiff{}
+	for _, server := range ctx.slaves() {
+		for _, d := range replicaDiffs[server] {
+			diffs = append(diffs, serverDiff{server, d.Table, d.Key, d.Type})
+		}
+	}
+
+	ctx.output(diffs, func() {
+		for _, d := range diffs {
+			fmt.Printf("%s\t%s\t%s\t%s\n", d.Server, d.Table, d.Key, d.Type)
+		}
+	})
+	return nil
+}
+
+func runRepair(ctx *cmdContext, args []string) error {
+	fs := flag.NewFlagSet("repair", flag.ContinueOnError)
+	rate := fs.Int("rate", 0, "max keys repaired per second, 0 means no limit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("invalid arguments, usage: %s", commands["repair"].usage)
+	}
+
+	tn := kvzoo.TableName(kvzoo.Root)
+	if fs.NArg() == 1 && fs.Arg(0) != kvzoo.Root {
+		var err error
+		if tn, err = kvzoo.NewTableName(fs.Arg(0)); err != nil {
+			return err
+		}
+	}
+
+	db, err := ctx.proxy()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.(*client.Proxy).RepairWithRate(tn, *rate)
+	if err != nil {
+		return err
+	}
+
+	ctx.output(result, func() {
+		fmt.Printf("divergent tables: %d\nrepaired tables: %d\nrepaired keys: %d\n",
+			result.DivergentTables, result.RepairedTables, result.RepairedKeys)
+	})
+	return nil
+}
+
+func runBackup(ctx *cmdContext, args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	retention := fs.Int("retention", 0, "number of backups to keep, 0 means keep all")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkArgs(fs.Args(), 1, commands["backup"].usage); err != nil {
+		return err
+	}
+
+	c, err := ctx.newClient(ctx.master())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	file, err := c.BackupToDir(fs.Arg(0), *retention)
+	if err != nil {
+		return err
+	}
+
+	ctx.output(map[string]string{"file": file}, func() {
+		fmt.Println(file)
+	})
+	return nil
+}
+
+func runRestore(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 1, commands["restore"].usage); err != nil {
+		return err
+	}
+
+	return ctx.forEachServer(func(c *client.Client) error {
+		return c.RestoreFromFile(args[0])
+	})
+}
+
+func runExport(ctx *cmdContext, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	output := fs.String("o", "", "output file, default is stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var tables []kvzoo.TableName
+	for _, name := range fs.Args() {
+		tn, err := kvzoo.NewTableName(name)
+		if err != nil {
+			return err
+		}
+		tables = append(tables, tn)
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	db, err := ctx.proxy()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return dump.Export(db, w, tables...)
+}
+
+func runImport(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 1, commands["import"].usage); err != nil {
+		return err
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := ctx.proxy()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return dump.Import(db, f)
+}
+
+func runCompact(ctx *cmdContext, args []string) error {
+	if err := checkArgs(args, 0, commands["compact"].usage); err != nil {
+		return err
+	}
+
+	return ctx.forEachServer(func(c *client.Client) error {
+		_, err := c.Compact(context.Background(), &pb.CompactRequest{})
+		return err
+	})
+}
+
+func runAudit(ctx *cmdContext, args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	since := fs.Duration("since", 0, "only print entries in the duration before now, 0 means no limit")
+	count := fs.Int64("n", 100, "print the newest n entries, 0 means no limit")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("invalid arguments, usage: %s", commands["audit"].usage)
+	}
+
+	req := &pb.QueryAuditRequest{
+		Limit: *count,
+	}
+	if *since > 0 {
+		req.Since = time.Now().Add(-*since).UnixNano()
+	}
+	if fs.NArg() == 1 {
+		req.Table = fs.Arg(0)
+	}
+
+	c, err := ctx.newClient(ctx.master())
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	reply, err := c.QueryAudit(context.Background(), req)
+	if err != nil {
+		return err
+	}
+
+	entries := reply.Entries
+	if entries == nil {
+		entries = []*pb.AuditEntry{}
+	}
+	ctx.output(entries, func() {
+		for _, e := range entries {
+			fmt.Printf("%s %s %s %s %s", time.Unix(0, e.Time).Format(time.RFC3339Nano), e.Identity, e.Peer, e.Operation, e.Table)
+			if e.Key != "" {
+				fmt.Printf(" %s size:%d hash:%s", e.Key, e.ValueSize, e.ValueHash)
+			}
+			if e.Err != "" {
+				fmt.Printf(" failed:%s", e.Err)
+			}
+			fmt.Println()
+		}
+	})
+	return nil
+}
+
+type serverTransaction struct {
+	Server string `json:"server"`
+	*pb.TransactionInfo
+}
+
+func runTransactions(ctx *cmdContext, args []string) error {
+	fs := flag.NewFlagSet("txs", flag.ContinueOnError)
+	minAge := fs.Duration("min-age", 0, "only print transactions opened longer than it")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkArgs(fs.Args(), 0, commands["txs"].usage); err != nil {
+		return err
+	}
+
+	txs := []serverTransaction{}
+	if err := ctx.forEachServer(func(c *client.Client) error {
+		reply, err := c.ListTransactions(context.Background(), &pb.ListTransactionsRequest{
+			MinAge: int64(*minAge),
+		})
+		if err != nil {
+			return err
+		}
+		for _, tx := range reply.Transactions {
+			txs = append(txs, serverTransaction{
+				Server:          c.Target(),
+				TransactionInfo: tx,
+			})
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	ctx.output(txs, func() {
+		for _, tx := range txs {
+			fmt.Printf("%s\t%d\t%s\t%s\t%s\t%s\t%d ops\n", tx.Server, tx.TxId, tx.Table, tx.Identity, tx.Peer,
+				time.Duration(tx.Age).Round(time.Millisecond), tx.OpCount)
+		}
+	})
+	return nil
+}
+
+func runAbortTransaction(ctx *cmdContext, args []string) error {
+	fs := flag.NewFlagSet("abort-tx", flag.ContinueOnError)
+	server := fs.String("server", ctx.master(), "server which opens the transaction")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if err := checkArgs(fs.Args(), 1, commands["abort-tx"].usage); err != nil {
+		return err
+	}
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid transaction id %s", fs.Arg(0))
+	}
+
+	c, err := ctx.newClient(*server)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	_, err = c.AbortTransaction(context.Background(), &pb.AbortTransactionRequest{
+		TxId: id,
+	})
+	return err
+}
+
+func sortedKeys(values map[string][]byte) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
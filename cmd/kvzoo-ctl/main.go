@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+	"google.golang.org/grpc"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+)
+
+type command struct {
+	usage string
+	desc  string
+	run   func(ctx *cmdContext, args []string) error
+}
+
+var commands map[string]*command
+
+func init() {
+	commands = map[string]*command{
+		"tables":   {"tables [-r] [parent]", "list the child tables of parent", runTables},
+		"get":      {"get <table> <key>", "print the value of key", runGet},
+		"put":      {"put <table> <key> <value>", "add or update key", runPut},
+		"delete":   {"delete <table> <key>", "delete key", runDelete},
+		"ls":       {"ls <table>", "list the keys and values in table", runList},
+		"stats":    {"stats <table>", "print the stats of table in master and slaves", runStats},
+		"checksum": {"checksum", "compare checksum of master and slaves", runChecksum},
+		"diff":     {"diff [table]", "print keys which are different between master and slaves", runDiff},
+		"repair":   {"repair [-rate n] [table]", "make slaves same with master", runRepair},
+		"backup":   {"backup [-retention n] <dir>", "backup master into dir", runBackup},
+		"restore":  {"restore <backup file>", "restore backup into all the servers", runRestore},
+		"export":   {"export [-o file] [table]...", "export tables in json lines format", runExport},
+		"import":   {"import <file>", "import the file generated by export", runImport},
+		"compact":  {"compact", "compact the db of all the servers", runCompact},
+		"audit":    {"audit [-since duration] [-n count] [table]", "print audit entries of master", runAudit},
+		"txs":      {"txs [-min-age duration]", "print opened transactions of all the servers", runTransactions},
+		"abort-tx": {"abort-tx [-server addr] <id>", "roll back transaction opened on server, default is master", runAbortTransaction},
+	}
+}
+
+type cmdContext struct {
+	servers []string
+	timeout time.Duration
+	json    bool
+	tls     *client.TLSConfig
+	token   string
+}
+
+func (ctx *cmdContext) master() string {
+	return ctx.servers[0]
+}
+
+func (ctx *cmdContext) slaves() []string {
+	return ctx.servers[1:]
+}
+
+// proxy writes into all the servers, so replicas are kept same
+func (ctx *cmdContext) proxy() (kvzoo.DB, error) {
+	var opts []client.Option
+	if ctx.tls != nil {
+		opts = append(opts, client.WithTLS(*ctx.tls))
+	}
+	if ctx.token != "" {
+		opts = append(opts, client.WithToken(ctx.token))
+	}
+	return client.New(ctx.master(), ctx.slaves(), opts...)
+}
+
+func (ctx *cmdContext) newClient(addr string) (*client.Client, error) {
+	var opts []grpc.DialOption
+	if ctx.token != "" {
+		opts = append(opts, client.WithTokenCredentials(ctx.token))
+	}
+	return client.NewTLSClient(addr, ctx.timeout, ctx.tls, opts...)
+}
+
+func (ctx *cmdContext) forEachServer(f func(c *client.Client) error) error {
+	for _, addr := range ctx.servers {
+		c, err := ctx.newClient(addr)
+		if err != nil {
+			return fmt.Errorf("connect to %s failed:%s", addr, err.Error())
+		}
+		err = f(c)
+		c.Close()
+		if err != nil {
+			return fmt.Errorf("%s:%s", addr, err.Error())
+		}
+	}
+	return nil
+}
+
+// print v as json in json mode, otherwise call human to print it
+func (ctx *cmdContext) output(v interface{}, human func()) {
+	if ctx.json {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(v)
+	} else {
+		human()
+	}
+}
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: kvzoo-ctl [options] <command> [args]\n\noptions:\n")
+	flag.PrintDefaults()
+	fmt.Fprintf(os.Stderr, "\ncommands:\n")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-32s %s\n", commands[name].usage, commands[name].desc)
+	}
+}
+
+func main() {
+	var servers string
+	var useTLS bool
+	var tlsConf client.TLSConfig
+	ctx := &cmdContext{}
+	flag.StringVar(&servers, "s", "127.0.0.1:5555", "server addresses separated by comma, the first one is master")
+	flag.DurationVar(&ctx.timeout, "timeout", client.ConnectTimeout, "timeout to connect to server")
+	flag.BoolVar(&ctx.json, "json", false, "output in json format")
+	flag.BoolVar(&useTLS, "tls", false, "connect to servers with tls, implied by other tls options")
+	flag.StringVar(&tlsConf.CAFile, "tls-ca", "", "ca file to verify servers")
+	flag.StringVar(&tlsConf.CertFile, "tls-cert", "", "client certificate file")
+	flag.StringVar(&tlsConf.KeyFile, "tls-key", "", "client private key file")
+	flag.StringVar(&tlsConf.ServerName, "tls-server-name", "", "name in server certificate")
+	flag.StringVar(&ctx.token, "token", os.Getenv("KVZOO_TOKEN"), "token to authenticate, default to env KVZOO_TOKEN")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	cmd, ok := commands[flag.Arg(0)]
+	if ok == false {
+		fmt.Fprintf(os.Stderr, "unknown command %s\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	for _, addr := range strings.Split(servers, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			ctx.servers = append(ctx.servers, addr)
+		}
+	}
+	if len(ctx.servers) == 0 {
+		fmt.Fprintf(os.Stderr, "no server is specified\n")
+		os.Exit(2)
+	}
+
+	if useTLS || tlsConf != (client.TLSConfig{}) {
+		ctx.tls = &tlsConf
+	}
+
+	log.InitLogger(log.Warn)
+	if err := cmd.run(ctx, flag.Args()[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %s\n", flag.Arg(0), err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+)
+
+const usage = `usage: kvzoo-inspect [-json] <command> <db file>...
+
+commands:
+  tree <file>             print the table tree with key count and size
+  check <file>            check the consistency of the db file
+  checksum <file>         print the checksum which is same with server
+  compare <file> <file>   compare two db files table by table
+`
+
+var jsonOutput bool
+
+func main() {
+	flag.BoolVar(&jsonOutput, "json", false, "output in json format")
+	flag.Usage = func() {
+		fmt.Fprint(os.Stderr, usage)
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) < 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch cmd, files := args[0], args[1:]; {
+	case cmd == "tree" && len(files) == 1:
+		err = printTree(files[0])
+	case cmd == "check" && len(files) == 1:
+		err = check(files[0])
+	case cmd == "checksum" && len(files) == 1:
+		err = printChecksum(files[0])
+	case cmd == "compare" && len(files) == 2:
+		var same bool
+		if same, err = compare(files[0], files[1]); err == nil && same == false {
+			os.Exit(1)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s failed: %s\n", args[0], err.Error())
+		os.Exit(1)
+	}
+}
+
+func output(v interface{}, human func()) {
+	if jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(v)
+	} else {
+		human()
+	}
+}
+
+func inspect(file string) ([]*bolt.TableInfo, error) {
+	db, err := bolt.OpenReadOnly(file)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	return db.Inspect()
+}
+
+func printTree(file string) error {
+	tables, err := inspect(file)
+	if err != nil {
+		return err
+	}
+
+	output(tables, func() {
+		fmt.Printf("%-40s %10s %12s %12s\n", "TABLE", "KEYS", "KEY BYTES", "VALUE BYTES")
+		walk(tables, func(info *bolt.TableInfo, depth int) {
+			name := strings.Repeat("  ", depth) + info.Name.Segments()[depth]
+			fmt.Printf("%-40s %10d %12d %12d\n", name, info.KeyCount, info.KeySize, info.ValueSize)
+		})
+	})
+	return nil
+}
+
+func walk(tables []*bolt.TableInfo, f func(*bolt.TableInfo, int)) {
+	var walkTables func([]*bolt.TableInfo, int)
+	walkTables = func(tables []*bolt.TableInfo, depth int) {
+		for _, info := range tables {
+			f(info, depth)
+			walkTables(info.Children, depth+1)
+		}
+	}
+	walkTables(tables, 0)
+}
+
+func check(file string) error {
+	db, err := bolt.OpenReadOnly(file)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.Check(); err != nil {
+		return err
+	}
+
+	output(map[string]bool{"ok": true}, func() {
+		fmt.Println("ok")
+	})
+	return nil
+}
+
+func printChecksum(file string) error {
+	db, err := bolt.OpenReadOnly(file)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	cs, err := db.Checksum()
+	if err != nil {
+		return err
+	}
+
+	output(map[string]string{"checksum": cs}, func() {
+		fmt.Println(cs)
+	})
+	return nil
+}
+
+type tableDiff struct {
+	Table  kvzoo.TableName `json:"table"`
+	Reason string          `json:"reason"`
+}
+
+func compare(file1, file2 string) (bool, error) {
+	tables1, err := inspect(file1)
+	if err != nil {
+		return false, err
+	}
+
+	tables2, err := inspect(file2)
+	if err != nil {
+		return false, err
+	}
+
+	infos1, infos2 := flatten(tables1), flatten(tables2)
+	diffs := []tableDiff{}
+	for _, name := range sortedNames(infos1) {
+		info1 := infos1[name]
+		if info2, ok := infos2[name]; ok == false {
+			diffs = append(diffs, tableDiff{name, "only exists in " + file1})
+		} else if info1.KeyCount != info2.KeyCount {
+			diffs = append(diffs, tableDiff{name, fmt.Sprintf("key count %d != %d", info1.KeyCount, info2.KeyCount)})
+		} else if info1.Checksum != info2.Checksum {
+			diffs = append(diffs, tableDiff{name, "checksum mismatch"})
+		}
+	}
+	for _, name := range sortedNames(infos2) {
+		if _, ok := infos1[name]; ok == false {
+			diffs = append(diffs, tableDiff{name, "only exists in " + file2})
+		}
+	}
+
+	output(diffs, func() {
+		if len(diffs) == 0 {
+			fmt.Println("same")
+		}
+		for _, d := range diffs {
+			fmt.Printf("%s\t%s\n", d.Table, d.Reason)
+		}
+	})
+	return len(diffs) == 0, nil
+}
+
+func flatten(tables []*bolt.TableInfo) map[kvzoo.TableName]*bolt.TableInfo {
+	infos := make(map[kvzoo.TableName]*bolt.TableInfo)
+	walk(tables, func(info *bolt.TableInfo, depth int) {
+		infos[info.Name] = info
+	})
+	return infos
+}
+
+func sortedNames(infos map[kvzoo.TableName]*bolt.TableInfo) []kvzoo.TableName {
+	names := make([]kvzoo.TableName, 0, len(infos))
+	for name := range infos {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+	yaml "gopkg.in/yaml.v2"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+type BoltConf struct {
+	Timeout         time.Duration `yaml:"timeout"`
+	NoSync          bool          `yaml:"no_sync"`
+	InitialMmapSize int           `yaml:"initial_mmap_size"`
+}
+
+type TLSConf struct {
+	CertFile     string `yaml:"cert_file"`
+	KeyFile      string `yaml:"key_file"`
+	ClientCAFile string `yaml:"client_ca_file"`
+}
+
+type ACLConf struct {
+	Identity   string `yaml:"identity"`
+	Table      string `yaml:"table"`
+	Permission string `yaml:"permission"`
+}
+
+// authentication is enabled if tokens or cert identity is set
+type AuthConf struct {
+	//token to identity
+	Tokens map[string]string `yaml:"tokens"`
+	//use common name of client certificate as identity
+	CertIdentity bool      `yaml:"cert_identity"`
+	ACL          []ACLConf `yaml:"acl"`
+}
+
+func (c *AuthConf) enabled() bool {
+	return len(c.Tokens) != 0 || c.CertIdentity
+}
+
+func (c *AuthConf) acl() ([]server.ACLRule, error) {
+	rules := make([]server.ACLRule, 0, len(c.ACL))
+	for _, conf := range c.ACL {
+		perm, err := server.ParsePermission(conf.Permission)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, server.ACLRule{
+			Identity:   conf.Identity,
+			Table:      conf.Table,
+			Permission: perm,
+		})
+	}
+	return rules, nil
+}
+
+type DestroyConf struct {
+	//allow, disable or confirm
+	Mode      string `yaml:"mode"`
+	BackupDir string `yaml:"backup_dir"`
+}
+
+var destroyModes = map[string]server.DestroyMode{
+	"allow":   server.DestroyAllowed,
+	"disable": server.DestroyDisabled,
+	"confirm": server.DestroyConfirmed,
+}
+
+// audit is enabled if file or db is set
+type AuditConf struct {
+	//json lines file, rotated once its size exceeds max size
+	File       string `yaml:"file"`
+	MaxSize    int64  `yaml:"max_size"`
+	MaxBackups int    `yaml:"max_backups"`
+	//dedicated bolt db file, exclusive with file
+	DB string `yaml:"db"`
+}
+
+const auditTable = "/audit"
+
+// gateways write through a proxy of this server and the slaves if slaves
+// are set, otherwise they are read only
+type WriterConf struct {
+	Slaves []string `yaml:"slaves"`
+	//token of the proxy, it needs write permission on server and slaves
+	Token string `yaml:"token"`
+	//used if tls of server is set
+	TLS WriterTLSConf `yaml:"tls"`
+}
+
+type WriterTLSConf struct {
+	CAFile     string `yaml:"ca_file"`
+	CertFile   string `yaml:"cert_file"`
+	KeyFile    string `yaml:"key_file"`
+	ServerName string `yaml:"server_name"`
+}
+
+// Config is loaded from yaml file, since json is subset of yaml, json file
+// is supported too
+type Config struct {
+	Listen string `yaml:"listen"`
+	//serve prometheus metrics on /metrics if it's not empty
+	MetricsListen string `yaml:"metrics_listen"`
+	//serve http and json gateway if it's not empty
+	GatewayListen string `yaml:"gateway_listen"`
+	//serve redis protocol if it's not empty
+	RESPListen string `yaml:"resp_listen"`
+	//redis database index to table, database n is /redis/n if it's empty
+	RESPDatabases map[int]kvzoo.TableName `yaml:"resp_databases"`
+	Writer        WriterConf              `yaml:"writer"`
+	//write spans into the file in json lines, "-" means stdout
+	TraceFile      string      `yaml:"trace_file"`
+	DBPath         string      `yaml:"db_path"`
+	Bolt           BoltConf    `yaml:"bolt"`
+	TLS            TLSConf     `yaml:"tls"`
+	Auth           AuthConf    `yaml:"auth"`
+	Destroy        DestroyConf `yaml:"destroy"`
+	Audit          AuditConf   `yaml:"audit"`
+	MaxOpenTxCount int         `yaml:"max_open_tx_count"`
+	//transactions opened longer are reported, 0 disables reporting
+	SlowTxThreshold time.Duration `yaml:"slow_tx_threshold"`
+	LogLevel        string        `yaml:"log_level"`
+	PidFile         string        `yaml:"pid_file"`
+}
+
+func defaultConfig() *Config {
+	return &Config{
+		Listen: "0.0.0.0:5555",
+		DBPath: "/var/lib/kvzoo/kvzoo.db",
+		Bolt: BoltConf{
+			Timeout: 5 * time.Second,
+		},
+		Destroy: DestroyConf{
+			Mode: "allow",
+		},
+		Audit: AuditConf{
+			MaxSize:    100 * 1024 * 1024,
+			MaxBackups: 10,
+		},
+		MaxOpenTxCount:  server.MaxOpenTxCount,
+		SlowTxThreshold: server.DefaultSlowTxThreshold,
+		LogLevel:        string(log.Info),
+	}
+}
+
+func loadConfig(file string) (*Config, error) {
+	conf := defaultConfig()
+	if file == "" {
+		return conf, nil
+	}
+
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := yaml.UnmarshalStrict(data, conf); err != nil {
+		return nil, fmt.Errorf("parse config file %s failed:%s", file, err.Error())
+	}
+	return conf, nil
+}
+
+func (c *Config) Validate() error {
+	if c.Listen == "" {
+		return fmt.Errorf("listen address is empty")
+	}
+
+	if c.DBPath == "" {
+		return fmt.Errorf("db path is empty")
+	}
+
+	if c.MaxOpenTxCount <= 0 {
+		return fmt.Errorf("max open tx count should be positive")
+	}
+
+	if c.SlowTxThreshold < 0 {
+		return fmt.Errorf("slow tx threshold shouldn't be negative")
+	}
+
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("cert file and key file of tls should be set together")
+	}
+
+	if c.TLS.ClientCAFile != "" && c.TLS.CertFile == "" {
+		return fmt.Errorf("client ca of tls requires cert file and key file")
+	}
+
+	if c.Auth.CertIdentity && c.TLS.ClientCAFile == "" {
+		return fmt.Errorf("cert identity of auth requires client ca of tls")
+	}
+
+	if len(c.Auth.ACL) != 0 && c.Auth.enabled() == false {
+		return fmt.Errorf("acl requires tokens or cert identity")
+	}
+
+	if _, err := c.Auth.acl(); err != nil {
+		return err
+	}
+
+	if _, ok := c.RESPDatabases[0]; len(c.RESPDatabases) != 0 && ok == false {
+		return fmt.Errorf("redis database 0 isn't mapped to any table")
+	}
+	for index, table := range c.RESPDatabases {
+		if index < 0 {
+			return fmt.Errorf("redis database index %d shouldn't be negative", index)
+		}
+		if _, err := kvzoo.NewTableName(string(table)); err != nil {
+			return fmt.Errorf("table of redis database %d is invalid:%s", index, err.Error())
+		}
+	}
+
+	if len(c.Writer.Slaves) != 0 && c.GatewayListen == "" && c.RESPListen == "" {
+		return fmt.Errorf("writer requires gateway listen or resp listen")
+	}
+
+	if _, ok := destroyModes[c.Destroy.Mode]; ok == false {
+		return fmt.Errorf("unknown destroy mode %s", c.Destroy.Mode)
+	}
+
+	if c.Audit.File != "" && c.Audit.DB != "" {
+		return fmt.Errorf("file and db of audit are exclusive")
+	}
+
+	if c.Audit.DB != "" && c.Audit.DB == c.DBPath {
+		return fmt.Errorf("db of audit should not be the db of server")
+	}
+
+	switch log.LogLevel(c.LogLevel) {
+	case log.Debug, log.Info, log.Warn, log.Error:
+		return nil
+	default:
+		return fmt.Errorf("unknown log level %s", c.LogLevel)
+	}
+}
+
+func (c *Config) boltOptions() bolt.Options {
+	return bolt.Options{
+		Timeout:         c.Bolt.Timeout,
+		NoSync:          c.Bolt.NoSync,
+		InitialMmapSize: c.Bolt.InitialMmapSize,
+	}
+}
+
+// tokens are set into tokenAuth, so they could be reloaded
+func (c *Config) serverOptions(tokenAuth *server.TokenAuthenticator) []server.Option {
+	opts := []server.Option{
+		server.WithMaxOpenTxCount(c.MaxOpenTxCount),
+		server.WithSlowTxThreshold(c.SlowTxThreshold),
+		server.WithDestroyGuard(server.DestroyGuard{
+			Mode:      destroyModes[c.Destroy.Mode],
+			BackupDir: c.Destroy.BackupDir,
+		}),
+	}
+	if c.GatewayListen != "" {
+		opts = append(opts, server.WithGateway(c.GatewayListen))
+	}
+	if c.RESPListen != "" {
+		opts = append(opts, server.WithRESP(c.RESPListen, c.RESPDatabases))
+	}
+	if c.TLS.CertFile != "" {
+		opts = append(opts, server.WithTLS(server.TLSConfig{
+			CertFile:     c.TLS.CertFile,
+			KeyFile:      c.TLS.KeyFile,
+			ClientCAFile: c.TLS.ClientCAFile,
+		}))
+	}
+
+	if c.Auth.enabled() {
+		var authenticators []server.Authenticator
+		if len(c.Auth.Tokens) != 0 {
+			tokenAuth.SetTokens(c.Auth.Tokens)
+			authenticators = append(authenticators, tokenAuth)
+		}
+		if c.Auth.CertIdentity {
+			authenticators = append(authenticators, server.CertAuthenticator{})
+		}
+		//acl is validated already
+		acl, _ := c.Auth.acl()
+		opts = append(opts, server.WithAuthenticator(authenticators...), server.WithACL(acl))
+	}
+	return opts
+}
+
+// newWriter returns nil if writer isn't enabled, its master is this server,
+// which is connected through loopback if it listens on all the addresses
+func (c *Config) newWriter() (kvzoo.DB, error) {
+	if len(c.Writer.Slaves) == 0 {
+		return nil, nil
+	}
+
+	host, port, err := net.SplitHostPort(c.Listen)
+	if err != nil {
+		return nil, err
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+
+	opts := []client.Option{client.WithRetryPolicy(client.DefaultRetryPolicy)}
+	if c.Writer.Token != "" {
+		opts = append(opts, client.WithToken(c.Writer.Token))
+	}
+	if c.TLS.CertFile != "" {
+		opts = append(opts, client.WithTLS(client.TLSConfig{
+			CAFile:     c.Writer.TLS.CAFile,
+			CertFile:   c.Writer.TLS.CertFile,
+			KeyFile:    c.Writer.TLS.KeyFile,
+			ServerName: c.Writer.TLS.ServerName,
+		}))
+	}
+	return client.New(net.JoinHostPort(host, port), c.Writer.Slaves, opts...)
+}
+
+// newAuditor returns nil if audit isn't enabled
+func (c *Config) newAuditor() (server.Auditor, error) {
+	if c.Audit.File != "" {
+		return server.NewFileAuditor(c.Audit.File, c.Audit.MaxSize, c.Audit.MaxBackups)
+	} else if c.Audit.DB == "" {
+		return nil, nil
+	}
+
+	db, err := bolt.New(c.Audit.DB)
+	if err != nil {
+		return nil, err
+	}
+	auditor, err := server.NewDBAuditor(db, auditTable)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return auditor, nil
+}
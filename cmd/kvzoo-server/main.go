@@ -0,0 +1,290 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"reflect"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/zdnscloud/cement/log"
+
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/server"
+	"github.com/zdnscloud/kvzoo/tracing"
+)
+
+type flags struct {
+	configFile string
+	conf       Config
+	set        map[string]bool
+}
+
+func parseFlags() *flags {
+	f := &flags{
+		set: make(map[string]bool),
+	}
+	def := defaultConfig()
+	flag.StringVar(&f.configFile, "c", "", "config file in yaml or json format")
+	flag.StringVar(&f.conf.Listen, "listen", def.Listen, "address to listen on")
+	flag.StringVar(&f.conf.MetricsListen, "metrics-listen", def.MetricsListen, "address to serve metrics on")
+	flag.StringVar(&f.conf.GatewayListen, "gateway-listen", def.GatewayListen, "address to serve http and json gateway on")
+	flag.StringVar(&f.conf.RESPListen, "resp-listen", def.RESPListen, "address to serve redis protocol on")
+	flag.StringVar(&f.conf.TraceFile, "trace-file", def.TraceFile, "file to write trace spans into, - means stdout")
+	flag.StringVar(&f.conf.DBPath, "db", def.DBPath, "path of the db file")
+	flag.DurationVar(&f.conf.Bolt.Timeout, "bolt-timeout", def.Bolt.Timeout, "time to wait to obtain the db file lock")
+	flag.BoolVar(&f.conf.Bolt.NoSync, "bolt-nosync", def.Bolt.NoSync, "skip fsync after each commit")
+	flag.IntVar(&f.conf.Bolt.InitialMmapSize, "bolt-mmap-size", def.Bolt.InitialMmapSize, "initial mmap size of the db file")
+	flag.StringVar(&f.conf.TLS.CertFile, "tls-cert", def.TLS.CertFile, "tls certificate file")
+	flag.StringVar(&f.conf.TLS.KeyFile, "tls-key", def.TLS.KeyFile, "tls private key file")
+	flag.StringVar(&f.conf.TLS.ClientCAFile, "tls-client-ca", def.TLS.ClientCAFile, "ca file to verify client certificates")
+	flag.StringVar(&f.conf.Destroy.Mode, "destroy-mode", def.Destroy.Mode, "allow, disable or confirm destroying db")
+	flag.StringVar(&f.conf.Destroy.BackupDir, "destroy-backup-dir", def.Destroy.BackupDir, "dir to back up db before it's destroyed")
+	flag.StringVar(&f.conf.Audit.File, "audit-file", def.Audit.File, "file to write audit entries into")
+	flag.StringVar(&f.conf.Audit.DB, "audit-db", def.Audit.DB, "db file to store audit entries into")
+	flag.IntVar(&f.conf.MaxOpenTxCount, "max-open-tx", def.MaxOpenTxCount, "max number of transactions opened at the same time")
+	flag.DurationVar(&f.conf.SlowTxThreshold, "slow-tx-threshold", def.SlowTxThreshold, "report transactions opened longer than it, 0 disables reporting")
+	flag.StringVar(&f.conf.LogLevel, "log-level", def.LogLevel, "log level: debug, info, warn or error")
+	flag.StringVar(&f.conf.PidFile, "pid-file", def.PidFile, "file to write pid into")
+	flag.Parse()
+	flag.Visit(func(fl *flag.Flag) {
+		f.set[fl.Name] = true
+	})
+	return f
+}
+
+// load config file first, flags set in command line have higher priority
+func (f *flags) loadConfig() (*Config, error) {
+	conf, err := loadConfig(f.configFile)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides := map[string]func(){
+		"listen":             func() { conf.Listen = f.conf.Listen },
+		"metrics-listen":     func() { conf.MetricsListen = f.conf.MetricsListen },
+		"gateway-listen":     func() { conf.GatewayListen = f.conf.GatewayListen },
+		"resp-listen":        func() { conf.RESPListen = f.conf.RESPListen },
+		"trace-file":         func() { conf.TraceFile = f.conf.TraceFile },
+		"db":                 func() { conf.DBPath = f.conf.DBPath },
+		"bolt-timeout":       func() { conf.Bolt.Timeout = f.conf.Bolt.Timeout },
+		"bolt-nosync":        func() { conf.Bolt.NoSync = f.conf.Bolt.NoSync },
+		"bolt-mmap-size":     func() { conf.Bolt.InitialMmapSize = f.conf.Bolt.InitialMmapSize },
+		"tls-cert":           func() { conf.TLS.CertFile = f.conf.TLS.CertFile },
+		"tls-key":            func() { conf.TLS.KeyFile = f.conf.TLS.KeyFile },
+		"tls-client-ca":      func() { conf.TLS.ClientCAFile = f.conf.TLS.ClientCAFile },
+		"destroy-mode":       func() { conf.Destroy.Mode = f.conf.Destroy.Mode },
+		"destroy-backup-dir": func() { conf.Destroy.BackupDir = f.conf.Destroy.BackupDir },
+		"audit-file":         func() { conf.Audit.File = f.conf.Audit.File },
+		"audit-db":           func() { conf.Audit.DB = f.conf.Audit.DB },
+		"max-open-tx":        func() { conf.MaxOpenTxCount = f.conf.MaxOpenTxCount },
+		"slow-tx-threshold":  func() { conf.SlowTxThreshold = f.conf.SlowTxThreshold },
+		"log-level":          func() { conf.LogLevel = f.conf.LogLevel },
+		"pid-file":           func() { conf.PidFile = f.conf.PidFile },
+	}
+	for name, override := range overrides {
+		if f.set[name] {
+			override()
+		}
+	}
+
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
+func main() {
+	f := parseFlags()
+	conf, err := f.loadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "load config failed:%s\n", err.Error())
+		os.Exit(1)
+	}
+	log.InitLogger(log.LogLevel(conf.LogLevel))
+
+	//exit after deferred cleanups in run, like removing pid file, are done
+	err = run(f, conf)
+	if err != nil {
+		log.Errorf("%s", err.Error())
+	}
+	log.CloseLogger()
+	if err != nil {
+		os.Exit(1)
+	}
+}
+
+func run(f *flags, conf *Config) error {
+	if conf.PidFile != "" {
+		if err := ioutil.WriteFile(conf.PidFile, []byte(strconv.Itoa(os.Getpid())), 0644); err != nil {
+			return fmt.Errorf("write pid file failed:%s", err.Error())
+		}
+		defer os.Remove(conf.PidFile)
+	}
+
+	db, err := bolt.NewWithOptions(conf.DBPath, conf.boltOptions())
+	if err != nil {
+		return fmt.Errorf("open db %s failed:%s", conf.DBPath, err.Error())
+	}
+
+	tokenAuth := server.NewTokenAuthenticator(nil)
+	opts := conf.serverOptions(tokenAuth)
+	auditor, err := conf.newAuditor()
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("open audit failed:%s", err.Error())
+	} else if auditor != nil {
+		opts = append(opts, server.WithAuditor(auditor))
+	}
+
+	writer, err := conf.newWriter()
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("create writer failed:%s", err.Error())
+	} else if writer != nil {
+		defer writer.Close()
+		opts = append(opts, server.WithWriter(writer))
+	}
+
+	var registry *prometheus.Registry
+	if conf.MetricsListen != "" {
+		registry = prometheus.NewRegistry()
+		opts = append(opts, server.WithMetrics(registry))
+	}
+
+	if conf.TraceFile != "" {
+		exporter := tracing.NewStdoutExporter()
+		if conf.TraceFile != "-" {
+			if exporter, err = tracing.NewFileExporter(conf.TraceFile); err != nil {
+				db.Close()
+				return fmt.Errorf("open trace file failed:%s", err.Error())
+			}
+			defer exporter.Close()
+		}
+		opts = append(opts, server.WithTracer(tracing.NewTracer("kvzoo-server", exporter)))
+	}
+
+	s, err := server.New(conf.Listen, db, opts...)
+	if err != nil {
+		db.Close()
+		return fmt.Errorf("create server failed:%s", err.Error())
+	}
+
+	if registry != nil {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+		metricsServer := &http.Server{Addr: conf.MetricsListen, Handler: mux}
+		defer metricsServer.Close()
+		go func() {
+			log.Infof("serve metrics on %s", conf.MetricsListen)
+			if err := metricsServer.ListenAndServe(); err != http.ErrServerClosed {
+				log.Errorf("serve metrics failed:%v", err)
+			}
+		}()
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Infof("kvzoo server listen on %s with db %s", conf.Listen, conf.DBPath)
+		errCh <- s.Start()
+	}()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	for {
+		select {
+		case err := <-errCh:
+			s.Stop()
+			return fmt.Errorf("server exit:%v", err)
+		case sig := <-sigCh:
+			if sig == syscall.SIGHUP {
+				reload(f, conf, s, tokenAuth)
+				continue
+			}
+			log.Infof("receive signal %s, stop server", sig)
+			s.Stop()
+			return nil
+		}
+	}
+}
+
+// only log level, transaction limit, slow transaction threshold, tokens and
+// acl can be changed without restart, content of tls files is reloaded
+// automatically once they are modified
+func reload(f *flags, conf *Config, s *server.KVGRPCServer, tokenAuth *server.TokenAuthenticator) {
+	newConf, err := f.loadConfig()
+	if err != nil {
+		log.Errorf("reload config failed:%s", err.Error())
+		return
+	}
+
+	//changes of these fields are ignored, they are reported by name
+	restartOnly := []struct {
+		name    string
+		changed bool
+	}{
+		{"listen", newConf.Listen != conf.Listen},
+		{"metrics_listen", newConf.MetricsListen != conf.MetricsListen},
+		{"gateway_listen", newConf.GatewayListen != conf.GatewayListen},
+		{"resp_listen", newConf.RESPListen != conf.RESPListen},
+		{"resp_databases", reflect.DeepEqual(newConf.RESPDatabases, conf.RESPDatabases) == false},
+		{"writer", reflect.DeepEqual(newConf.Writer, conf.Writer) == false},
+		{"trace_file", newConf.TraceFile != conf.TraceFile},
+		{"db_path", newConf.DBPath != conf.DBPath},
+		{"bolt", newConf.Bolt != conf.Bolt},
+		{"tls", newConf.TLS != conf.TLS},
+		{"destroy", newConf.Destroy != conf.Destroy},
+		{"audit", newConf.Audit != conf.Audit},
+		{"pid_file", newConf.PidFile != conf.PidFile},
+		{"auth.cert_identity", newConf.Auth.CertIdentity != conf.Auth.CertIdentity},
+		//enabling or disabling token authenticator
+		{"auth.tokens", (len(newConf.Auth.Tokens) != 0) != (len(conf.Auth.Tokens) != 0)},
+	}
+	var ignored []string
+	for _, field := range restartOnly {
+		if field.changed {
+			ignored = append(ignored, field.name)
+		}
+	}
+
+	if newConf.LogLevel != conf.LogLevel {
+		log.InitLogger(log.LogLevel(newConf.LogLevel))
+		conf.LogLevel = newConf.LogLevel
+	}
+
+	if newConf.MaxOpenTxCount != conf.MaxOpenTxCount {
+		s.SetMaxOpenTxCount(newConf.MaxOpenTxCount)
+		conf.MaxOpenTxCount = newConf.MaxOpenTxCount
+	}
+	if newConf.SlowTxThreshold != conf.SlowTxThreshold {
+		s.SetSlowTxThreshold(newConf.SlowTxThreshold)
+		conf.SlowTxThreshold = newConf.SlowTxThreshold
+	}
+	if (len(newConf.Auth.Tokens) != 0) != (len(conf.Auth.Tokens) != 0) ||
+		newConf.Auth.CertIdentity != conf.Auth.CertIdentity {
+		if reflect.DeepEqual(newConf.Auth.ACL, conf.Auth.ACL) == false {
+			ignored = append(ignored, "auth.acl")
+		}
+	} else if conf.Auth.enabled() {
+		acl, _ := newConf.Auth.acl()
+		if err := s.SetACL(acl); err != nil {
+			log.Errorf("reload acl failed:%s", err.Error())
+		} else {
+			tokenAuth.SetTokens(newConf.Auth.Tokens)
+			conf.Auth = newConf.Auth
+		}
+	}
+
+	if len(ignored) != 0 {
+		log.Warnf("config reloaded, changes of %s only take effect after restart", strings.Join(ignored, ", "))
+	} else {
+		log.Infof("config reloaded")
+	}
+}
@@ -2,6 +2,7 @@ package kvzoo
 
 import (
 	"errors"
+	"io"
 )
 
 var ErrNotFound = errors.New("key doesn't exist")
@@ -21,6 +22,11 @@ type DB interface {
 	CreateOrGetTable(TableName) (Table, error)
 	//delete parent table will delete all child table
 	DeleteTable(TableName) error
+	//return the child tables of parent, use Root to list first level tables
+	//with recursive set, all the descendant tables are returned
+	ListTables(parent TableName, recursive bool) ([]TableName, error)
+	TableExists(TableName) (bool, error)
+	Stats(TableName) (*TableStats, error)
 }
 
 type Table interface {
@@ -39,3 +45,61 @@ type Transaction interface {
 	Get(string) ([]byte, error)
 	List() (map[string][]byte, error)
 }
+
+// optional interface, implemented by table which supports read only
+// transaction, it doesn't block and isn't blocked by writers, writes in it
+// fail, and commit is same with rollback
+type ReadOnlyTable interface {
+	BeginReadOnly() (Transaction, error)
+}
+
+// optional interface, implemented by transaction which could walk keys in
+// order without loading the whole table
+type SeekableTransaction interface {
+	Cursor() Cursor
+}
+
+// Cursor moves over the keys of table in order, empty key is returned once
+// it moves out of the keys
+type Cursor interface {
+	//move to the first key which isn't less than key
+	Seek(string) (string, []byte)
+	First() (string, []byte)
+	Last() (string, []byte)
+	Next() (string, []byte)
+	Prev() (string, []byte)
+}
+
+// optional interface, implemented by backend which supports hot backup
+type Backupable interface {
+	//write a consistent snapshot of the whole db, return its checksum
+	Backup(io.Writer) (string, error)
+	//replace the db with the snapshot, the snapshot is verified against
+	//the checksum before it's used
+	Restore(io.Reader, string) error
+}
+
+// optional interface, implemented by backend which could reclaim the space
+// of deleted data
+type Compactable interface {
+	Compact() error
+}
+
+// optional interface, implemented by backend which could be compared with
+// another db by Diff
+type Diffable interface {
+	Checksum() (string, error)
+	ListTables(parent TableName, recursive bool) ([]TableName, error)
+	TableExists(TableName) (bool, error)
+	//merkle tree of the keys in table, keys are split into leaves by the
+	//boundaries, nil boundaries means splitting the keys evenly
+	MerkleTree(TableName, []string) (*MerkleTree, error)
+	//return digest of value for keys in the ranges
+	KeyDigests(TableName, []KeyRange) (map[string][]byte, error)
+}
+
+// optional interface, implemented by backend which reports the statistics
+// of its storage
+type DBStatsReporter interface {
+	DBStats() (*DBStats, error)
+}
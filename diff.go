@@ -0,0 +1,170 @@
+package kvzoo
+
+import (
+	"bytes"
+	"sort"
+)
+
+type DiffType string
+
+const (
+	//exists in source but not in target
+	DiffMissing DiffType = "missing"
+	//exists in target but not in source
+	DiffExtra DiffType = "extra"
+	//value in target isn't same with source
+	DiffChanged DiffType = "changed"
+)
+
+type Difference struct {
+	Table TableName
+	//empty key means the whole table is missing or extra
+	Key  string
+	Type DiffType
+}
+
+// Diff compares table and all its child tables in target with source, use
+// Root to compare the whole db, only the different parts of merkle trees
+// are fetched, so cost of diff is proportional to the difference. table
+// which only exists in one db is reported as missing or extra
+func Diff(source, target Diffable, tableName TableName) ([]Difference, error) {
+	if tableName != Root {
+		sourceExists, err := source.TableExists(tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		targetExists, err := target.TableExists(tableName)
+		if err != nil {
+			return nil, err
+		}
+
+		if sourceExists && targetExists == false {
+			return []Difference{{Table: tableName, Type: DiffMissing}}, nil
+		} else if sourceExists == false && targetExists {
+			return []Difference{{Table: tableName, Type: DiffExtra}}, nil
+		} else if sourceExists == false {
+			return nil, nil
+		}
+	}
+
+	var diffs []Difference
+	if err := diffTable(source, target, tableName, &diffs); err != nil {
+		return nil, err
+	}
+	return diffs, nil
+}
+
+func diffTable(source, target Diffable, tableName TableName, diffs *[]Difference) error {
+	if tableName == Root {
+		if same, err := sameChecksum(source, target); err != nil || same {
+			return err
+		}
+	} else {
+		sourceTree, err := source.MerkleTree(tableName, nil)
+		if err != nil {
+			return err
+		}
+
+		//split keys of target with the same ranges, so the trees are
+		//comparable
+		targetTree, err := target.MerkleTree(tableName, sourceTree.Boundaries)
+		if err != nil {
+			return err
+		}
+
+		if sourceTree.Checksum == targetTree.Checksum {
+			return nil
+		}
+
+		if leaves := sourceTree.DiffLeaves(targetTree); len(leaves) > 0 {
+			ranges := make([]KeyRange, 0, len(leaves))
+			for _, leaf := range leaves {
+				ranges = append(ranges, sourceTree.LeafRange(leaf))
+			}
+			if err := diffKeys(source, target, tableName, ranges, diffs); err != nil {
+				return err
+			}
+		}
+	}
+
+	sourceChildren, err := source.ListTables(tableName, false)
+	if err != nil {
+		return err
+	}
+
+	targetChildren, err := target.ListTables(tableName, false)
+	if err != nil {
+		return err
+	}
+
+	targetTables := make(map[TableName]bool, len(targetChildren))
+	for _, tn := range targetChildren {
+		targetTables[tn] = true
+	}
+
+	for _, tn := range sourceChildren {
+		if targetTables[tn] {
+			if err := diffTable(source, target, tn, diffs); err != nil {
+				return err
+			}
+			delete(targetTables, tn)
+		} else {
+			*diffs = append(*diffs, Difference{Table: tn, Type: DiffMissing})
+		}
+	}
+
+	for _, tn := range targetChildren {
+		if targetTables[tn] {
+			*diffs = append(*diffs, Difference{Table: tn, Type: DiffExtra})
+		}
+	}
+	return nil
+}
+
+func sameChecksum(source, target Diffable) (bool, error) {
+	sourceChecksum, err := source.Checksum()
+	if err != nil {
+		return false, err
+	}
+
+	targetChecksum, err := target.Checksum()
+	if err != nil {
+		return false, err
+	}
+
+	return sourceChecksum == targetChecksum, nil
+}
+
+func diffKeys(source, target Diffable, tableName TableName, ranges []KeyRange, diffs *[]Difference) error {
+	sourceDigests, err := source.KeyDigests(tableName, ranges)
+	if err != nil {
+		return err
+	}
+
+	targetDigests, err := target.KeyDigests(tableName, ranges)
+	if err != nil {
+		return err
+	}
+
+	var tableDiffs []Difference
+	for k, digest := range sourceDigests {
+		if targetDigest, ok := targetDigests[k]; ok == false {
+			tableDiffs = append(tableDiffs, Difference{Table: tableName, Key: k, Type: DiffMissing})
+		} else if bytes.Equal(digest, targetDigest) == false {
+			tableDiffs = append(tableDiffs, Difference{Table: tableName, Key: k, Type: DiffChanged})
+		}
+	}
+
+	for k := range targetDigests {
+		if _, ok := sourceDigests[k]; ok == false {
+			tableDiffs = append(tableDiffs, Difference{Table: tableName, Key: k, Type: DiffExtra})
+		}
+	}
+
+	sort.Slice(tableDiffs, func(i, j int) bool {
+		return tableDiffs[i].Key < tableDiffs[j].Key
+	})
+	*diffs = append(*diffs, tableDiffs...)
+	return nil
+}
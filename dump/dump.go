@@ -0,0 +1,208 @@
+package dump
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/zdnscloud/kvzoo"
+)
+
+const maxRecordSize = 64 * 1024 * 1024
+
+// Record is one line of the dump, a record without key declares the table,
+// so empty table is kept too, value is base64 encoded by json
+type Record struct {
+	Table kvzoo.TableName `json:"table"`
+	Key   string          `json:"key,omitempty"`
+	Value []byte          `json:"value,omitempty"`
+}
+
+// Export writes the data of tables and their child tables into w in json
+// lines format, all the tables are exported if no table is specified, keys
+// of each table are sorted, so dump of same data is always same
+func Export(db kvzoo.DB, w io.Writer, tables ...kvzoo.TableName) error {
+	if len(tables) == 0 {
+		tables = []kvzoo.TableName{kvzoo.Root}
+	}
+
+	bw := bufio.NewWriter(w)
+	encoder := json.NewEncoder(bw)
+	exported := make(map[kvzoo.TableName]bool)
+	for _, tn := range tables {
+		subtree, err := getSubtree(db, tn)
+		if err != nil {
+			return err
+		}
+
+		for _, tn := range subtree {
+			if exported[tn] {
+				continue
+			}
+			if err := exportTable(db, encoder, tn); err != nil {
+				return err
+			}
+			exported[tn] = true
+		}
+	}
+	return bw.Flush()
+}
+
+func getSubtree(db kvzoo.DB, tn kvzoo.TableName) ([]kvzoo.TableName, error) {
+	if tn == kvzoo.Root {
+		return db.ListTables(tn, true)
+	}
+
+	if exists, err := db.TableExists(tn); err != nil {
+		return nil, err
+	} else if exists == false {
+		return nil, fmt.Errorf("table %s doesn't exist", tn)
+	}
+
+	children, err := db.ListTables(tn, true)
+	if err != nil {
+		return nil, err
+	}
+	return append([]kvzoo.TableName{tn}, children...), nil
+}
+
+// table is checked before it's opened, since opening table through proxy
+// creates it on replicas which miss it
+func exportTable(db kvzoo.DB, encoder *json.Encoder, tn kvzoo.TableName) error {
+	if exists, err := db.TableExists(tn); err != nil {
+		return err
+	} else if exists == false {
+		return fmt.Errorf("table %s doesn't exist", tn)
+	}
+
+	//child tables are listed as keys with empty value
+	children, err := db.ListTables(tn, false)
+	if err != nil {
+		return err
+	}
+
+	table, err := db.CreateOrGetTable(tn)
+	if err != nil {
+		return err
+	}
+
+	//read only transaction doesn't block writers, and only requires read
+	//permission of table
+	var tx kvzoo.Transaction
+	if t, ok := table.(kvzoo.ReadOnlyTable); ok {
+		tx, err = t.BeginReadOnly()
+	} else {
+		tx, err = table.Begin()
+	}
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	values, err := tx.List()
+	if err != nil {
+		return err
+	}
+
+	if err := encoder.Encode(&Record{Table: tn}); err != nil {
+		return err
+	}
+
+	for _, child := range children {
+		delete(values, child.Segments()[len(child.Segments())-1])
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := encoder.Encode(&Record{
+			Table: tn,
+			Key:   k,
+			Value: values[k],
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Import loads the dump generated by Export into db, records of same table
+// are written in one transaction, existing key will be overwritten
+func Import(db kvzoo.DB, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordSize)
+
+	var tx kvzoo.Transaction
+	var currentTable kvzoo.TableName
+	defer func() {
+		if tx != nil {
+			tx.Rollback()
+		}
+	}()
+
+	line := 0
+	for scanner.Scan() {
+		line += 1
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var record Record
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return fmt.Errorf("invalid record at line %d:%s", line, err.Error())
+		}
+
+		if _, err := kvzoo.NewTableName(string(record.Table)); err != nil {
+			return fmt.Errorf("invalid record at line %d:%s", line, err.Error())
+		}
+
+		if tx == nil || record.Table != currentTable {
+			if tx != nil {
+				err := tx.Commit()
+				tx = nil
+				if err != nil {
+					return err
+				}
+			}
+
+			table, err := db.CreateOrGetTable(record.Table)
+			if err != nil {
+				return err
+			}
+			if tx, err = table.Begin(); err != nil {
+				return err
+			}
+			currentTable = record.Table
+		}
+
+		if record.Key != "" {
+			if err := put(tx, record.Key, record.Value); err != nil {
+				return fmt.Errorf("import %s to table %s failed:%s", record.Key, record.Table, err.Error())
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	if tx != nil {
+		err := tx.Commit()
+		tx = nil
+		return err
+	}
+	return nil
+}
+
+func put(tx kvzoo.Transaction, key string, value []byte) error {
+	if _, err := tx.Get(key); err == kvzoo.ErrNotFound {
+		return tx.Add(key, value)
+	} else if err != nil {
+		return err
+	}
+	return tx.Update(key, value)
+}
@@ -4,11 +4,10 @@ go 1.13
 
 require (
 	github.com/boltdb/bolt v1.3.2-0.20180302180052-fd01fc79c553
-	github.com/golang/protobuf v1.3.2
+	github.com/golang/protobuf v1.4.3
+	github.com/prometheus/client_golang v1.11.0
 	github.com/zdnscloud/cement v0.0.0-20190814053439-7eb205536ab8
-	golang.org/x/net v0.0.0-20191009170851-d66e71096ffb // indirect
-	golang.org/x/sys v0.0.0-20191008105621-543471e840be // indirect
-	golang.org/x/text v0.3.2 // indirect
 	google.golang.org/genproto v0.0.0-20191009194640-548a555dbc03 // indirect
 	google.golang.org/grpc v1.24.0
+	gopkg.in/yaml.v2 v2.4.0
 )
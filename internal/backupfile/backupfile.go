@@ -0,0 +1,97 @@
+// Package backupfile defines the layout of backups in a dir, each backup is
+// a timestamped db file with its checksum in a file beside it, so backups
+// written by server before destroy and by client could be restored in the
+// same way
+package backupfile
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	filePrefix         = "kvzoo-"
+	fileSuffix         = ".db"
+	checksumFileSuffix = ".checksum"
+	timeFormat         = "20060102-150405.000000000"
+)
+
+// Write creates a backup in dir, backup writes the snapshot and returns its
+// checksum, the backup file only appears after all are written
+func Write(dir string, backup func(io.Writer) (string, error)) (string, error) {
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+
+	name := filePrefix + time.Now().Format(timeFormat)
+	backupFile := path.Join(dir, name+fileSuffix)
+	tmpFile := backupFile + ".tmp"
+	f, err := os.Create(tmpFile)
+	if err != nil {
+		return "", err
+	}
+
+	cs, err := backup(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if err_ := f.Close(); err == nil {
+		err = err_
+	}
+	if err == nil {
+		err = ioutil.WriteFile(checksumFile(backupFile), []byte(cs), 0664)
+	}
+	if err == nil {
+		err = os.Rename(tmpFile, backupFile)
+	}
+	if err != nil {
+		os.Remove(tmpFile)
+		return "", err
+	}
+	return backupFile, nil
+}
+
+func checksumFile(backupFile string) string {
+	return strings.TrimSuffix(backupFile, fileSuffix) + checksumFileSuffix
+}
+
+// Checksum returns the checksum saved with the backup file
+func Checksum(backupFile string) (string, error) {
+	cs, err := ioutil.ReadFile(checksumFile(backupFile))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(cs)), nil
+}
+
+// List returns the backup files in dir, the newest comes first
+func List(dir string) ([]string, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []string
+	for _, f := range files {
+		name := f.Name()
+		if f.IsDir() == false && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			backups = append(backups, path.Join(dir, name))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(backups)))
+	return backups, nil
+}
+
+// Remove deletes the backup file and its checksum
+func Remove(backupFile string) error {
+	if err := os.Remove(backupFile); err != nil {
+		return err
+	}
+	os.Remove(checksumFile(backupFile))
+	return nil
+}
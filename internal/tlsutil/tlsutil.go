@@ -0,0 +1,143 @@
+// Package tlsutil loads certificates which are reloaded once their files
+// are modified, so certificates could be renewed without restart
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+)
+
+// watchedFiles tracks the latest modify time of files
+type watchedFiles struct {
+	files   []string
+	modTime time.Time
+}
+
+func (w *watchedFiles) changed() (bool, error) {
+	var latest time.Time
+	for _, f := range w.files {
+		info, err := os.Stat(f)
+		if err != nil {
+			return false, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	if latest.Equal(w.modTime) {
+		return false, nil
+	}
+	w.modTime = latest
+	return true, nil
+}
+
+type KeyPair struct {
+	certFile string
+	keyFile  string
+
+	lock  sync.Mutex
+	files watchedFiles
+	cert  *tls.Certificate
+}
+
+func NewKeyPair(certFile, keyFile string) (*KeyPair, error) {
+	kp := &KeyPair{
+		certFile: certFile,
+		keyFile:  keyFile,
+		files:    watchedFiles{files: []string{certFile, keyFile}},
+	}
+	if _, err := kp.Certificate(); err != nil {
+		return nil, err
+	}
+	return kp, nil
+}
+
+// Certificate returns the current certificate, if reloading the modified
+// files fails, the old certificate is kept
+func (kp *KeyPair) Certificate() (*tls.Certificate, error) {
+	kp.lock.Lock()
+	defer kp.lock.Unlock()
+
+	if changed, err := kp.files.changed(); err != nil || changed == false {
+		if kp.cert == nil {
+			return nil, err
+		}
+		return kp.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(kp.certFile, kp.keyFile)
+	if err != nil {
+		if kp.cert == nil {
+			return nil, err
+		}
+		log.Warnf("reload certificate %s failed:%s", kp.certFile, err.Error())
+		return kp.cert, nil
+	}
+	kp.cert = &cert
+	return kp.cert, nil
+}
+
+type CertPool struct {
+	caFile string
+
+	lock  sync.Mutex
+	files watchedFiles
+	pool  *x509.CertPool
+}
+
+func NewCertPool(caFile string) (*CertPool, error) {
+	cp := &CertPool{
+		caFile: caFile,
+		files:  watchedFiles{files: []string{caFile}},
+	}
+	if _, err := cp.Pool(); err != nil {
+		return nil, err
+	}
+	return cp, nil
+}
+
+// Pool returns the current ca pool, if reloading the modified file fails,
+// the old pool is kept
+func (cp *CertPool) Pool() (*x509.CertPool, error) {
+	cp.lock.Lock()
+	defer cp.lock.Unlock()
+
+	if changed, err := cp.files.changed(); err != nil || changed == false {
+		if cp.pool == nil {
+			return nil, err
+		}
+		return cp.pool, nil
+	}
+
+	pool, err := loadCertPool(cp.caFile)
+	if err != nil {
+		if cp.pool == nil {
+			return nil, err
+		}
+		log.Warnf("reload ca %s failed:%s", cp.caFile, err.Error())
+		return cp.pool, nil
+	}
+	cp.pool = pool
+	return cp.pool, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if pool.AppendCertsFromPEM(data) == false {
+		return nil, fmt.Errorf("no valid certificate in %s", caFile)
+	}
+	return pool, nil
+}
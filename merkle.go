@@ -0,0 +1,153 @@
+package kvzoo
+
+import (
+	"bytes"
+	"crypto/md5"
+	"hash"
+	"sort"
+)
+
+// keys of a table are partitioned into leaves by key range, each leaf covers
+// the keys from its boundary to the boundary of next leaf
+const MerkleLeafCount = 256
+
+type MerkleTree struct {
+	//checksum of the table, checksums of child tables are included, it
+	//doesn't depend on the boundaries
+	Checksum string
+	//start key of each leaf, the first one is always empty and the last
+	//leaf covers all the keys after its boundary, trees are comparable
+	//only if they have same boundaries
+	Boundaries []string
+	//complete binary tree stored in array, Nodes[0] is the root which covers
+	//all the keys of the table, the last MerkleLeafCount nodes are leaves
+	Nodes [][]byte
+}
+
+// KeyRange covers keys >= Begin and < End, empty End means no upper bound
+type KeyRange struct {
+	Begin string
+	End   string
+}
+
+func (r KeyRange) Contains(key string) bool {
+	return key >= r.Begin && (r.End == "" || key < r.End)
+}
+
+// MerkleBoundaryIndex returns the index of the first key of leaf, when count
+// ordered keys are split into leaves with about same number of keys, leaves
+// without key have same boundary with the next one
+func MerkleBoundaryIndex(leaf, count int) int {
+	return leaf * count / MerkleLeafCount
+}
+
+// MerkleLeaf returns the leaf whose range covers the key, which is the last
+// leaf with boundary not greater than key
+func MerkleLeaf(boundaries []string, key string) int {
+	return sort.Search(len(boundaries), func(i int) bool {
+		return boundaries[i] > key
+	}) - 1
+}
+
+func ValueDigest(value []byte) []byte {
+	sum := md5.Sum(value)
+	return sum[:]
+}
+
+// MerkleBuilder builds the tree, keys should be added in order so the
+// tree of same data is always same
+type MerkleBuilder struct {
+	boundaries []string
+	leaves     []hash.Hash
+	checksum   hash.Hash
+}
+
+func NewMerkleBuilder(boundaries []string) *MerkleBuilder {
+	leaves := make([]hash.Hash, MerkleLeafCount)
+	for i := range leaves {
+		leaves[i] = md5.New()
+	}
+	return &MerkleBuilder{
+		boundaries: boundaries,
+		leaves:     leaves,
+		checksum:   md5.New(),
+	}
+}
+
+func (b *MerkleBuilder) Add(key, value []byte) {
+	digest := ValueDigest(value)
+	for _, h := range []hash.Hash{b.leaves[MerkleLeaf(b.boundaries, string(key))], b.checksum} {
+		h.Write(key)
+		h.Write(digest)
+	}
+}
+
+// Build returns the nodes of tree and the checksum of all the added keys
+func (b *MerkleBuilder) Build() ([][]byte, []byte) {
+	nodes := make([][]byte, 2*MerkleLeafCount-1)
+	for i, h := range b.leaves {
+		nodes[MerkleLeafCount-1+i] = h.Sum(nil)
+	}
+
+	for i := MerkleLeafCount - 2; i >= 0; i-- {
+		h := md5.New()
+		h.Write(nodes[2*i+1])
+		h.Write(nodes[2*i+2])
+		nodes[i] = h.Sum(nil)
+	}
+	return nodes, b.checksum.Sum(nil)
+}
+
+func (t *MerkleTree) Root() []byte {
+	return t.Nodes[0]
+}
+
+// LeafRange returns the key range covered by the leaf
+func (t *MerkleTree) LeafRange(leaf int) KeyRange {
+	r := KeyRange{Begin: t.Boundaries[leaf]}
+	if leaf < len(t.Boundaries)-1 {
+		r.End = t.Boundaries[leaf+1]
+	}
+	return r
+}
+
+// DiffLeaves walks down from root and only descends into the different
+// nodes, returns the index of leaves which are different, all the leaves
+// are different if the trees have different boundaries
+func (t *MerkleTree) DiffLeaves(other *MerkleTree) []int {
+	var leaves []int
+	if sameBoundaries(t.Boundaries, other.Boundaries) == false {
+		for i := 0; i < MerkleLeafCount; i++ {
+			leaves = append(leaves, i)
+		}
+		return leaves
+	}
+
+	var walk func(int)
+	walk = func(i int) {
+		if bytes.Equal(t.Nodes[i], other.Nodes[i]) {
+			return
+		}
+
+		if i >= MerkleLeafCount-1 {
+			leaves = append(leaves, i-(MerkleLeafCount-1))
+		} else {
+			walk(2*i + 1)
+			walk(2*i + 2)
+		}
+	}
+	walk(0)
+	return leaves
+}
+
+func sameBoundaries(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
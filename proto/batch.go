@@ -0,0 +1,13 @@
+package pb
+
+// HasWrites returns whether any operation of batch changes data, batch
+// without writes runs in read only transaction, requires only read
+// permission and is safe to retry
+func (m *BatchRequest) HasWrites() bool {
+	for _, op := range m.GetOperations() {
+		if op.Type != BatchOperation_GET && op.Type != BatchOperation_LIST {
+			return true
+		}
+	}
+	return false
+}
@@ -25,17 +25,105 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
 
-type ChecksumRequest struct {
+type BatchOperation_Type int32
+
+const (
+	BatchOperation_GET    BatchOperation_Type = 0
+	BatchOperation_ADD    BatchOperation_Type = 1
+	BatchOperation_UPDATE BatchOperation_Type = 2
+	BatchOperation_DELETE BatchOperation_Type = 3
+	BatchOperation_LIST   BatchOperation_Type = 4
+)
+
+var BatchOperation_Type_name = map[int32]string{
+	0: "GET",
+	1: "ADD",
+	2: "UPDATE",
+	3: "DELETE",
+	4: "LIST",
+}
+
+var BatchOperation_Type_value = map[string]int32{
+	"GET":    0,
+	"ADD":    1,
+	"UPDATE": 2,
+	"DELETE": 3,
+	"LIST":   4,
+}
+
+func (x BatchOperation_Type) String() string {
+	return proto.EnumName(BatchOperation_Type_name, int32(x))
+}
+
+func (BatchOperation_Type) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{42, 0}
+}
+
+// writes committed by master are numbered in its epoch, epoch changes when
+// server starts or its data is replaced, revisions of different epochs
+// can't be compared
+type Revision struct {
+	Epoch                int64    `protobuf:"varint,1,opt,name=epoch,proto3" json:"epoch,omitempty"`
+	Number               int64    `protobuf:"varint,2,opt,name=number,proto3" json:"number,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
+func (m *Revision) Reset()         { *m = Revision{} }
+func (m *Revision) String() string { return proto.CompactTextString(m) }
+func (*Revision) ProtoMessage()    {}
+func (*Revision) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{0}
+}
+
+func (m *Revision) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Revision.Unmarshal(m, b)
+}
+func (m *Revision) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Revision.Marshal(b, m, deterministic)
+}
+func (m *Revision) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Revision.Merge(m, src)
+}
+func (m *Revision) XXX_Size() int {
+	return xxx_messageInfo_Revision.Size(m)
+}
+func (m *Revision) XXX_DiscardUnknown() {
+	xxx_messageInfo_Revision.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Revision proto.InternalMessageInfo
+
+func (m *Revision) GetEpoch() int64 {
+	if m != nil {
+		return m.Epoch
+	}
+	return 0
+}
+
+func (m *Revision) GetNumber() int64 {
+	if m != nil {
+		return m.Number
+	}
+	return 0
+}
+
+type ChecksumRequest struct {
+	//checksum and revision of master, slave which has the same checksum
+	//has applied the writes of master until the revision
+	MasterChecksum       string    `protobuf:"bytes,1,opt,name=master_checksum,json=masterChecksum,proto3" json:"master_checksum,omitempty"`
+	Revision             *Revision `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
 func (m *ChecksumRequest) Reset()         { *m = ChecksumRequest{} }
 func (m *ChecksumRequest) String() string { return proto.CompactTextString(m) }
 func (*ChecksumRequest) ProtoMessage()    {}
 func (*ChecksumRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{0}
+	return fileDescriptor_1b14dcbe5169b67b, []int{1}
 }
 
 func (m *ChecksumRequest) XXX_Unmarshal(b []byte) error {
@@ -56,18 +144,34 @@ func (m *ChecksumRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_ChecksumRequest proto.InternalMessageInfo
 
+func (m *ChecksumRequest) GetMasterChecksum() string {
+	if m != nil {
+		return m.MasterChecksum
+	}
+	return ""
+}
+
+func (m *ChecksumRequest) GetRevision() *Revision {
+	if m != nil {
+		return m.Revision
+	}
+	return nil
+}
+
 type ChecksumReply struct {
-	Checksum             string   `protobuf:"bytes,1,opt,name=checksum,proto3" json:"checksum,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Checksum string `protobuf:"bytes,1,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	//revision of server before checksum is calculated
+	Revision             *Revision `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
 func (m *ChecksumReply) Reset()         { *m = ChecksumReply{} }
 func (m *ChecksumReply) String() string { return proto.CompactTextString(m) }
 func (*ChecksumReply) ProtoMessage()    {}
 func (*ChecksumReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{1}
+	return fileDescriptor_1b14dcbe5169b67b, []int{2}
 }
 
 func (m *ChecksumReply) XXX_Unmarshal(b []byte) error {
@@ -95,7 +199,16 @@ func (m *ChecksumReply) GetChecksum() string {
 	return ""
 }
 
+func (m *ChecksumReply) GetRevision() *Revision {
+	if m != nil {
+		return m.Revision
+	}
+	return nil
+}
+
 type DestroyRequest struct {
+	//checksum of db, required by server which only allows confirmed destroy
+	Confirm              string   `protobuf:"bytes,1,opt,name=confirm,proto3" json:"confirm,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -105,7 +218,7 @@ func (m *DestroyRequest) Reset()         { *m = DestroyRequest{} }
 func (m *DestroyRequest) String() string { return proto.CompactTextString(m) }
 func (*DestroyRequest) ProtoMessage()    {}
 func (*DestroyRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{2}
+	return fileDescriptor_1b14dcbe5169b67b, []int{3}
 }
 
 func (m *DestroyRequest) XXX_Unmarshal(b []byte) error {
@@ -126,6 +239,13 @@ func (m *DestroyRequest) XXX_DiscardUnknown() {
 
 var xxx_messageInfo_DestroyRequest proto.InternalMessageInfo
 
+func (m *DestroyRequest) GetConfirm() string {
+	if m != nil {
+		return m.Confirm
+	}
+	return ""
+}
+
 type CreateOrGetTableRequest struct {
 	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
@@ -137,7 +257,7 @@ func (m *CreateOrGetTableRequest) Reset()         { *m = CreateOrGetTableRequest
 func (m *CreateOrGetTableRequest) String() string { return proto.CompactTextString(m) }
 func (*CreateOrGetTableRequest) ProtoMessage()    {}
 func (*CreateOrGetTableRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{3}
+	return fileDescriptor_1b14dcbe5169b67b, []int{4}
 }
 
 func (m *CreateOrGetTableRequest) XXX_Unmarshal(b []byte) error {
@@ -166,17 +286,19 @@ func (m *CreateOrGetTableRequest) GetName() string {
 }
 
 type DeleteTableRequest struct {
-	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	//set when the delete of master is replicated to slave
+	Revision             *Revision `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
 func (m *DeleteTableRequest) Reset()         { *m = DeleteTableRequest{} }
 func (m *DeleteTableRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteTableRequest) ProtoMessage()    {}
 func (*DeleteTableRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{4}
+	return fileDescriptor_1b14dcbe5169b67b, []int{5}
 }
 
 func (m *DeleteTableRequest) XXX_Unmarshal(b []byte) error {
@@ -204,492 +326,2147 @@ func (m *DeleteTableRequest) GetName() string {
 	return ""
 }
 
-type BeginTransactionRequest struct {
-	TableName            string   `protobuf:"bytes,1,opt,name=table_name,json=tableName,proto3" json:"table_name,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *DeleteTableRequest) GetRevision() *Revision {
+	if m != nil {
+		return m.Revision
+	}
+	return nil
 }
 
-func (m *BeginTransactionRequest) Reset()         { *m = BeginTransactionRequest{} }
-func (m *BeginTransactionRequest) String() string { return proto.CompactTextString(m) }
-func (*BeginTransactionRequest) ProtoMessage()    {}
-func (*BeginTransactionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{5}
+type DeleteTableReply struct {
+	Revision             *Revision `protobuf:"bytes,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *BeginTransactionRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BeginTransactionRequest.Unmarshal(m, b)
+func (m *DeleteTableReply) Reset()         { *m = DeleteTableReply{} }
+func (m *DeleteTableReply) String() string { return proto.CompactTextString(m) }
+func (*DeleteTableReply) ProtoMessage()    {}
+func (*DeleteTableReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{6}
 }
-func (m *BeginTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BeginTransactionRequest.Marshal(b, m, deterministic)
+
+func (m *DeleteTableReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteTableReply.Unmarshal(m, b)
 }
-func (m *BeginTransactionRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BeginTransactionRequest.Merge(m, src)
+func (m *DeleteTableReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteTableReply.Marshal(b, m, deterministic)
 }
-func (m *BeginTransactionRequest) XXX_Size() int {
-	return xxx_messageInfo_BeginTransactionRequest.Size(m)
+func (m *DeleteTableReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteTableReply.Merge(m, src)
 }
-func (m *BeginTransactionRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_BeginTransactionRequest.DiscardUnknown(m)
+func (m *DeleteTableReply) XXX_Size() int {
+	return xxx_messageInfo_DeleteTableReply.Size(m)
+}
+func (m *DeleteTableReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteTableReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_BeginTransactionRequest proto.InternalMessageInfo
+var xxx_messageInfo_DeleteTableReply proto.InternalMessageInfo
 
-func (m *BeginTransactionRequest) GetTableName() string {
+func (m *DeleteTableReply) GetRevision() *Revision {
 	if m != nil {
-		return m.TableName
+		return m.Revision
 	}
-	return ""
+	return nil
 }
 
-type BeginTransactionReply struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+type ListTablesRequest struct {
+	Parent               string   `protobuf:"bytes,1,opt,name=parent,proto3" json:"parent,omitempty"`
+	Recursive            bool     `protobuf:"varint,2,opt,name=recursive,proto3" json:"recursive,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *BeginTransactionReply) Reset()         { *m = BeginTransactionReply{} }
-func (m *BeginTransactionReply) String() string { return proto.CompactTextString(m) }
-func (*BeginTransactionReply) ProtoMessage()    {}
-func (*BeginTransactionReply) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{6}
+func (m *ListTablesRequest) Reset()         { *m = ListTablesRequest{} }
+func (m *ListTablesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTablesRequest) ProtoMessage()    {}
+func (*ListTablesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{7}
 }
 
-func (m *BeginTransactionReply) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_BeginTransactionReply.Unmarshal(m, b)
+func (m *ListTablesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesRequest.Unmarshal(m, b)
 }
-func (m *BeginTransactionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_BeginTransactionReply.Marshal(b, m, deterministic)
+func (m *ListTablesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesRequest.Marshal(b, m, deterministic)
 }
-func (m *BeginTransactionReply) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_BeginTransactionReply.Merge(m, src)
+func (m *ListTablesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesRequest.Merge(m, src)
 }
-func (m *BeginTransactionReply) XXX_Size() int {
-	return xxx_messageInfo_BeginTransactionReply.Size(m)
+func (m *ListTablesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTablesRequest.Size(m)
 }
-func (m *BeginTransactionReply) XXX_DiscardUnknown() {
-	xxx_messageInfo_BeginTransactionReply.DiscardUnknown(m)
+func (m *ListTablesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_BeginTransactionReply proto.InternalMessageInfo
+var xxx_messageInfo_ListTablesRequest proto.InternalMessageInfo
 
-func (m *BeginTransactionReply) GetTxId() int64 {
+func (m *ListTablesRequest) GetParent() string {
 	if m != nil {
-		return m.TxId
+		return m.Parent
 	}
-	return 0
+	return ""
 }
 
-type CommitTransactionRequest struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+func (m *ListTablesRequest) GetRecursive() bool {
+	if m != nil {
+		return m.Recursive
+	}
+	return false
+}
+
+type ListTablesReply struct {
+	Names                []string `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *CommitTransactionRequest) Reset()         { *m = CommitTransactionRequest{} }
-func (m *CommitTransactionRequest) String() string { return proto.CompactTextString(m) }
-func (*CommitTransactionRequest) ProtoMessage()    {}
-func (*CommitTransactionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{7}
+func (m *ListTablesReply) Reset()         { *m = ListTablesReply{} }
+func (m *ListTablesReply) String() string { return proto.CompactTextString(m) }
+func (*ListTablesReply) ProtoMessage()    {}
+func (*ListTablesReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{8}
 }
 
-func (m *CommitTransactionRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_CommitTransactionRequest.Unmarshal(m, b)
+func (m *ListTablesReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTablesReply.Unmarshal(m, b)
 }
-func (m *CommitTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_CommitTransactionRequest.Marshal(b, m, deterministic)
+func (m *ListTablesReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTablesReply.Marshal(b, m, deterministic)
 }
-func (m *CommitTransactionRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_CommitTransactionRequest.Merge(m, src)
+func (m *ListTablesReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTablesReply.Merge(m, src)
 }
-func (m *CommitTransactionRequest) XXX_Size() int {
-	return xxx_messageInfo_CommitTransactionRequest.Size(m)
+func (m *ListTablesReply) XXX_Size() int {
+	return xxx_messageInfo_ListTablesReply.Size(m)
 }
-func (m *CommitTransactionRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_CommitTransactionRequest.DiscardUnknown(m)
+func (m *ListTablesReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTablesReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_CommitTransactionRequest proto.InternalMessageInfo
+var xxx_messageInfo_ListTablesReply proto.InternalMessageInfo
 
-func (m *CommitTransactionRequest) GetTxId() int64 {
+func (m *ListTablesReply) GetNames() []string {
 	if m != nil {
-		return m.TxId
+		return m.Names
 	}
-	return 0
+	return nil
 }
 
-type RollbackTransactionRequest struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+type TableExistsRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *RollbackTransactionRequest) Reset()         { *m = RollbackTransactionRequest{} }
-func (m *RollbackTransactionRequest) String() string { return proto.CompactTextString(m) }
-func (*RollbackTransactionRequest) ProtoMessage()    {}
-func (*RollbackTransactionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{8}
+func (m *TableExistsRequest) Reset()         { *m = TableExistsRequest{} }
+func (m *TableExistsRequest) String() string { return proto.CompactTextString(m) }
+func (*TableExistsRequest) ProtoMessage()    {}
+func (*TableExistsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{9}
 }
 
-func (m *RollbackTransactionRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_RollbackTransactionRequest.Unmarshal(m, b)
+func (m *TableExistsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TableExistsRequest.Unmarshal(m, b)
 }
-func (m *RollbackTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_RollbackTransactionRequest.Marshal(b, m, deterministic)
+func (m *TableExistsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TableExistsRequest.Marshal(b, m, deterministic)
 }
-func (m *RollbackTransactionRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_RollbackTransactionRequest.Merge(m, src)
+func (m *TableExistsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TableExistsRequest.Merge(m, src)
 }
-func (m *RollbackTransactionRequest) XXX_Size() int {
-	return xxx_messageInfo_RollbackTransactionRequest.Size(m)
+func (m *TableExistsRequest) XXX_Size() int {
+	return xxx_messageInfo_TableExistsRequest.Size(m)
 }
-func (m *RollbackTransactionRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_RollbackTransactionRequest.DiscardUnknown(m)
+func (m *TableExistsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TableExistsRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_RollbackTransactionRequest proto.InternalMessageInfo
+var xxx_messageInfo_TableExistsRequest proto.InternalMessageInfo
 
-func (m *RollbackTransactionRequest) GetTxId() int64 {
+func (m *TableExistsRequest) GetName() string {
 	if m != nil {
-		return m.TxId
+		return m.Name
 	}
-	return 0
+	return ""
 }
 
-type AddRequest struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
-	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+type TableExistsReply struct {
+	Exists               bool     `protobuf:"varint,1,opt,name=exists,proto3" json:"exists,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *AddRequest) Reset()         { *m = AddRequest{} }
-func (m *AddRequest) String() string { return proto.CompactTextString(m) }
-func (*AddRequest) ProtoMessage()    {}
-func (*AddRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{9}
+func (m *TableExistsReply) Reset()         { *m = TableExistsReply{} }
+func (m *TableExistsReply) String() string { return proto.CompactTextString(m) }
+func (*TableExistsReply) ProtoMessage()    {}
+func (*TableExistsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{10}
 }
 
-func (m *AddRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_AddRequest.Unmarshal(m, b)
+func (m *TableExistsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TableExistsReply.Unmarshal(m, b)
 }
-func (m *AddRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_AddRequest.Marshal(b, m, deterministic)
+func (m *TableExistsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TableExistsReply.Marshal(b, m, deterministic)
 }
-func (m *AddRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_AddRequest.Merge(m, src)
+func (m *TableExistsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TableExistsReply.Merge(m, src)
 }
-func (m *AddRequest) XXX_Size() int {
-	return xxx_messageInfo_AddRequest.Size(m)
+func (m *TableExistsReply) XXX_Size() int {
+	return xxx_messageInfo_TableExistsReply.Size(m)
 }
-func (m *AddRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_AddRequest.DiscardUnknown(m)
+func (m *TableExistsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_TableExistsReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_AddRequest proto.InternalMessageInfo
+var xxx_messageInfo_TableExistsReply proto.InternalMessageInfo
 
-func (m *AddRequest) GetTxId() int64 {
+func (m *TableExistsReply) GetExists() bool {
 	if m != nil {
-		return m.TxId
+		return m.Exists
 	}
-	return 0
+	return false
 }
 
-func (m *AddRequest) GetKey() string {
-	if m != nil {
-		return m.Key
-	}
-	return ""
+type StatsRequest struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *AddRequest) GetValue() []byte {
+func (m *StatsRequest) Reset()         { *m = StatsRequest{} }
+func (m *StatsRequest) String() string { return proto.CompactTextString(m) }
+func (*StatsRequest) ProtoMessage()    {}
+func (*StatsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{11}
+}
+
+func (m *StatsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsRequest.Unmarshal(m, b)
+}
+func (m *StatsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsRequest.Marshal(b, m, deterministic)
+}
+func (m *StatsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsRequest.Merge(m, src)
+}
+func (m *StatsRequest) XXX_Size() int {
+	return xxx_messageInfo_StatsRequest.Size(m)
+}
+func (m *StatsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsRequest proto.InternalMessageInfo
+
+func (m *StatsRequest) GetName() string {
 	if m != nil {
-		return m.Value
+		return m.Name
 	}
-	return nil
+	return ""
 }
 
-type DeleteRequest struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
-	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+type StorageStats struct {
+	BranchPageN          int64    `protobuf:"varint,1,opt,name=branch_page_n,json=branchPageN,proto3" json:"branch_page_n,omitempty"`
+	BranchOverflowN      int64    `protobuf:"varint,2,opt,name=branch_overflow_n,json=branchOverflowN,proto3" json:"branch_overflow_n,omitempty"`
+	LeafPageN            int64    `protobuf:"varint,3,opt,name=leaf_page_n,json=leafPageN,proto3" json:"leaf_page_n,omitempty"`
+	LeafOverflowN        int64    `protobuf:"varint,4,opt,name=leaf_overflow_n,json=leafOverflowN,proto3" json:"leaf_overflow_n,omitempty"`
+	KeyN                 int64    `protobuf:"varint,5,opt,name=key_n,json=keyN,proto3" json:"key_n,omitempty"`
+	Depth                int64    `protobuf:"varint,6,opt,name=depth,proto3" json:"depth,omitempty"`
+	BranchAlloc          int64    `protobuf:"varint,7,opt,name=branch_alloc,json=branchAlloc,proto3" json:"branch_alloc,omitempty"`
+	BranchInuse          int64    `protobuf:"varint,8,opt,name=branch_inuse,json=branchInuse,proto3" json:"branch_inuse,omitempty"`
+	LeafAlloc            int64    `protobuf:"varint,9,opt,name=leaf_alloc,json=leafAlloc,proto3" json:"leaf_alloc,omitempty"`
+	LeafInuse            int64    `protobuf:"varint,10,opt,name=leaf_inuse,json=leafInuse,proto3" json:"leaf_inuse,omitempty"`
+	BucketN              int64    `protobuf:"varint,11,opt,name=bucket_n,json=bucketN,proto3" json:"bucket_n,omitempty"`
+	InlineBucketN        int64    `protobuf:"varint,12,opt,name=inline_bucket_n,json=inlineBucketN,proto3" json:"inline_bucket_n,omitempty"`
+	InlineBucketInuse    int64    `protobuf:"varint,13,opt,name=inline_bucket_inuse,json=inlineBucketInuse,proto3" json:"inline_bucket_inuse,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
-func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
-func (*DeleteRequest) ProtoMessage()    {}
-func (*DeleteRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{10}
+func (m *StorageStats) Reset()         { *m = StorageStats{} }
+func (m *StorageStats) String() string { return proto.CompactTextString(m) }
+func (*StorageStats) ProtoMessage()    {}
+func (*StorageStats) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{12}
 }
 
-func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+func (m *StorageStats) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StorageStats.Unmarshal(m, b)
 }
-func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+func (m *StorageStats) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StorageStats.Marshal(b, m, deterministic)
 }
-func (m *DeleteRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_DeleteRequest.Merge(m, src)
+func (m *StorageStats) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StorageStats.Merge(m, src)
 }
-func (m *DeleteRequest) XXX_Size() int {
-	return xxx_messageInfo_DeleteRequest.Size(m)
+func (m *StorageStats) XXX_Size() int {
+	return xxx_messageInfo_StorageStats.Size(m)
 }
-func (m *DeleteRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+func (m *StorageStats) XXX_DiscardUnknown() {
+	xxx_messageInfo_StorageStats.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+var xxx_messageInfo_StorageStats proto.InternalMessageInfo
 
-func (m *DeleteRequest) GetTxId() int64 {
+func (m *StorageStats) GetBranchPageN() int64 {
 	if m != nil {
-		return m.TxId
+		return m.BranchPageN
 	}
 	return 0
 }
 
-func (m *DeleteRequest) GetKey() string {
+func (m *StorageStats) GetBranchOverflowN() int64 {
 	if m != nil {
-		return m.Key
+		return m.BranchOverflowN
 	}
-	return ""
+	return 0
 }
 
-type UpdateRequest struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
-	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+func (m *StorageStats) GetLeafPageN() int64 {
+	if m != nil {
+		return m.LeafPageN
+	}
+	return 0
+}
+
+func (m *StorageStats) GetLeafOverflowN() int64 {
+	if m != nil {
+		return m.LeafOverflowN
+	}
+	return 0
+}
+
+func (m *StorageStats) GetKeyN() int64 {
+	if m != nil {
+		return m.KeyN
+	}
+	return 0
+}
+
+func (m *StorageStats) GetDepth() int64 {
+	if m != nil {
+		return m.Depth
+	}
+	return 0
+}
+
+func (m *StorageStats) GetBranchAlloc() int64 {
+	if m != nil {
+		return m.BranchAlloc
+	}
+	return 0
+}
+
+func (m *StorageStats) GetBranchInuse() int64 {
+	if m != nil {
+		return m.BranchInuse
+	}
+	return 0
+}
+
+func (m *StorageStats) GetLeafAlloc() int64 {
+	if m != nil {
+		return m.LeafAlloc
+	}
+	return 0
+}
+
+func (m *StorageStats) GetLeafInuse() int64 {
+	if m != nil {
+		return m.LeafInuse
+	}
+	return 0
+}
+
+func (m *StorageStats) GetBucketN() int64 {
+	if m != nil {
+		return m.BucketN
+	}
+	return 0
+}
+
+func (m *StorageStats) GetInlineBucketN() int64 {
+	if m != nil {
+		return m.InlineBucketN
+	}
+	return 0
+}
+
+func (m *StorageStats) GetInlineBucketInuse() int64 {
+	if m != nil {
+		return m.InlineBucketInuse
+	}
+	return 0
+}
+
+type StatsReply struct {
+	KeyCount             int64         `protobuf:"varint,1,opt,name=key_count,json=keyCount,proto3" json:"key_count,omitempty"`
+	KeySize              int64         `protobuf:"varint,2,opt,name=key_size,json=keySize,proto3" json:"key_size,omitempty"`
+	ValueSize            int64         `protobuf:"varint,3,opt,name=value_size,json=valueSize,proto3" json:"value_size,omitempty"`
+	ChildTableCount      int64         `protobuf:"varint,4,opt,name=child_table_count,json=childTableCount,proto3" json:"child_table_count,omitempty"`
+	Storage              *StorageStats `protobuf:"bytes,5,opt,name=storage,proto3" json:"storage,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *StatsReply) Reset()         { *m = StatsReply{} }
+func (m *StatsReply) String() string { return proto.CompactTextString(m) }
+func (*StatsReply) ProtoMessage()    {}
+func (*StatsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{13}
+}
+
+func (m *StatsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StatsReply.Unmarshal(m, b)
+}
+func (m *StatsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StatsReply.Marshal(b, m, deterministic)
+}
+func (m *StatsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StatsReply.Merge(m, src)
+}
+func (m *StatsReply) XXX_Size() int {
+	return xxx_messageInfo_StatsReply.Size(m)
+}
+func (m *StatsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_StatsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StatsReply proto.InternalMessageInfo
+
+func (m *StatsReply) GetKeyCount() int64 {
+	if m != nil {
+		return m.KeyCount
+	}
+	return 0
+}
+
+func (m *StatsReply) GetKeySize() int64 {
+	if m != nil {
+		return m.KeySize
+	}
+	return 0
+}
+
+func (m *StatsReply) GetValueSize() int64 {
+	if m != nil {
+		return m.ValueSize
+	}
+	return 0
+}
+
+func (m *StatsReply) GetChildTableCount() int64 {
+	if m != nil {
+		return m.ChildTableCount
+	}
+	return 0
+}
+
+func (m *StatsReply) GetStorage() *StorageStats {
+	if m != nil {
+		return m.Storage
+	}
+	return nil
+}
+
+type MerkleTreeRequest struct {
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	//empty boundaries means splitting the keys evenly
+	Boundaries           []string `protobuf:"bytes,2,rep,name=boundaries,proto3" json:"boundaries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleTreeRequest) Reset()         { *m = MerkleTreeRequest{} }
+func (m *MerkleTreeRequest) String() string { return proto.CompactTextString(m) }
+func (*MerkleTreeRequest) ProtoMessage()    {}
+func (*MerkleTreeRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{14}
+}
+
+func (m *MerkleTreeRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleTreeRequest.Unmarshal(m, b)
+}
+func (m *MerkleTreeRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleTreeRequest.Marshal(b, m, deterministic)
+}
+func (m *MerkleTreeRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleTreeRequest.Merge(m, src)
+}
+func (m *MerkleTreeRequest) XXX_Size() int {
+	return xxx_messageInfo_MerkleTreeRequest.Size(m)
+}
+func (m *MerkleTreeRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleTreeRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleTreeRequest proto.InternalMessageInfo
+
+func (m *MerkleTreeRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *MerkleTreeRequest) GetBoundaries() []string {
+	if m != nil {
+		return m.Boundaries
+	}
+	return nil
+}
+
+type MerkleTreeReply struct {
+	Checksum             string   `protobuf:"bytes,1,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	Nodes                [][]byte `protobuf:"bytes,2,rep,name=nodes,proto3" json:"nodes,omitempty"`
+	Boundaries           []string `protobuf:"bytes,3,rep,name=boundaries,proto3" json:"boundaries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *MerkleTreeReply) Reset()         { *m = MerkleTreeReply{} }
+func (m *MerkleTreeReply) String() string { return proto.CompactTextString(m) }
+func (*MerkleTreeReply) ProtoMessage()    {}
+func (*MerkleTreeReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{15}
+}
+
+func (m *MerkleTreeReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_MerkleTreeReply.Unmarshal(m, b)
+}
+func (m *MerkleTreeReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_MerkleTreeReply.Marshal(b, m, deterministic)
+}
+func (m *MerkleTreeReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MerkleTreeReply.Merge(m, src)
+}
+func (m *MerkleTreeReply) XXX_Size() int {
+	return xxx_messageInfo_MerkleTreeReply.Size(m)
+}
+func (m *MerkleTreeReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_MerkleTreeReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MerkleTreeReply proto.InternalMessageInfo
+
+func (m *MerkleTreeReply) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+func (m *MerkleTreeReply) GetNodes() [][]byte {
+	if m != nil {
+		return m.Nodes
+	}
+	return nil
+}
+
+func (m *MerkleTreeReply) GetBoundaries() []string {
+	if m != nil {
+		return m.Boundaries
+	}
+	return nil
+}
+
+type KeyRange struct {
+	Begin string `protobuf:"bytes,1,opt,name=begin,proto3" json:"begin,omitempty"`
+	//empty end means no upper bound
+	End                  string   `protobuf:"bytes,2,opt,name=end,proto3" json:"end,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *KeyRange) Reset()         { *m = KeyRange{} }
+func (m *KeyRange) String() string { return proto.CompactTextString(m) }
+func (*KeyRange) ProtoMessage()    {}
+func (*KeyRange) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{16}
+}
+
+func (m *KeyRange) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyRange.Unmarshal(m, b)
+}
+func (m *KeyRange) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyRange.Marshal(b, m, deterministic)
+}
+func (m *KeyRange) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyRange.Merge(m, src)
+}
+func (m *KeyRange) XXX_Size() int {
+	return xxx_messageInfo_KeyRange.Size(m)
+}
+func (m *KeyRange) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyRange.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyRange proto.InternalMessageInfo
+
+func (m *KeyRange) GetBegin() string {
+	if m != nil {
+		return m.Begin
+	}
+	return ""
+}
+
+func (m *KeyRange) GetEnd() string {
+	if m != nil {
+		return m.End
+	}
+	return ""
+}
+
+type KeyDigestsRequest struct {
+	Name                 string      `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ranges               []*KeyRange `protobuf:"bytes,3,rep,name=ranges,proto3" json:"ranges,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *KeyDigestsRequest) Reset()         { *m = KeyDigestsRequest{} }
+func (m *KeyDigestsRequest) String() string { return proto.CompactTextString(m) }
+func (*KeyDigestsRequest) ProtoMessage()    {}
+func (*KeyDigestsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{17}
+}
+
+func (m *KeyDigestsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyDigestsRequest.Unmarshal(m, b)
+}
+func (m *KeyDigestsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyDigestsRequest.Marshal(b, m, deterministic)
+}
+func (m *KeyDigestsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyDigestsRequest.Merge(m, src)
+}
+func (m *KeyDigestsRequest) XXX_Size() int {
+	return xxx_messageInfo_KeyDigestsRequest.Size(m)
+}
+func (m *KeyDigestsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyDigestsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyDigestsRequest proto.InternalMessageInfo
+
+func (m *KeyDigestsRequest) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *KeyDigestsRequest) GetRanges() []*KeyRange {
+	if m != nil {
+		return m.Ranges
+	}
+	return nil
+}
+
+type KeyDigestsReply struct {
+	Digests              map[string][]byte `protobuf:"bytes,1,rep,name=digests,proto3" json:"digests,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *KeyDigestsReply) Reset()         { *m = KeyDigestsReply{} }
+func (m *KeyDigestsReply) String() string { return proto.CompactTextString(m) }
+func (*KeyDigestsReply) ProtoMessage()    {}
+func (*KeyDigestsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{18}
+}
+
+func (m *KeyDigestsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_KeyDigestsReply.Unmarshal(m, b)
+}
+func (m *KeyDigestsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_KeyDigestsReply.Marshal(b, m, deterministic)
+}
+func (m *KeyDigestsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KeyDigestsReply.Merge(m, src)
+}
+func (m *KeyDigestsReply) XXX_Size() int {
+	return xxx_messageInfo_KeyDigestsReply.Size(m)
+}
+func (m *KeyDigestsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_KeyDigestsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_KeyDigestsReply proto.InternalMessageInfo
+
+func (m *KeyDigestsReply) GetDigests() map[string][]byte {
+	if m != nil {
+		return m.Digests
+	}
+	return nil
+}
+
+type BeginTransactionRequest struct {
+	TableName string `protobuf:"bytes,1,opt,name=table_name,json=tableName,proto3" json:"table_name,omitempty"`
+	//writes of read only transaction fail
+	ReadOnly             bool     `protobuf:"varint,2,opt,name=read_only,json=readOnly,proto3" json:"read_only,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BeginTransactionRequest) Reset()         { *m = BeginTransactionRequest{} }
+func (m *BeginTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*BeginTransactionRequest) ProtoMessage()    {}
+func (*BeginTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{19}
+}
+
+func (m *BeginTransactionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BeginTransactionRequest.Unmarshal(m, b)
+}
+func (m *BeginTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BeginTransactionRequest.Marshal(b, m, deterministic)
+}
+func (m *BeginTransactionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BeginTransactionRequest.Merge(m, src)
+}
+func (m *BeginTransactionRequest) XXX_Size() int {
+	return xxx_messageInfo_BeginTransactionRequest.Size(m)
+}
+func (m *BeginTransactionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BeginTransactionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BeginTransactionRequest proto.InternalMessageInfo
+
+func (m *BeginTransactionRequest) GetTableName() string {
+	if m != nil {
+		return m.TableName
+	}
+	return ""
+}
+
+func (m *BeginTransactionRequest) GetReadOnly() bool {
+	if m != nil {
+		return m.ReadOnly
+	}
+	return false
+}
+
+type BeginTransactionReply struct {
+	TxId int64 `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	//revision of server after transaction begins
+	Revision *Revision `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	//writes of master applied before transaction begins, unset if unknown
+	Applied              *Revision `protobuf:"bytes,3,opt,name=applied,proto3" json:"applied,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *BeginTransactionReply) Reset()         { *m = BeginTransactionReply{} }
+func (m *BeginTransactionReply) String() string { return proto.CompactTextString(m) }
+func (*BeginTransactionReply) ProtoMessage()    {}
+func (*BeginTransactionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{20}
+}
+
+func (m *BeginTransactionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BeginTransactionReply.Unmarshal(m, b)
+}
+func (m *BeginTransactionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BeginTransactionReply.Marshal(b, m, deterministic)
+}
+func (m *BeginTransactionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BeginTransactionReply.Merge(m, src)
+}
+func (m *BeginTransactionReply) XXX_Size() int {
+	return xxx_messageInfo_BeginTransactionReply.Size(m)
+}
+func (m *BeginTransactionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_BeginTransactionReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BeginTransactionReply proto.InternalMessageInfo
+
+func (m *BeginTransactionReply) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+func (m *BeginTransactionReply) GetRevision() *Revision {
+	if m != nil {
+		return m.Revision
+	}
+	return nil
+}
+
+func (m *BeginTransactionReply) GetApplied() *Revision {
+	if m != nil {
+		return m.Applied
+	}
+	return nil
+}
+
+type CommitTransactionRequest struct {
+	TxId int64 `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	//set when the transaction of master is replicated to slave
+	Revision             *Revision `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *CommitTransactionRequest) Reset()         { *m = CommitTransactionRequest{} }
+func (m *CommitTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*CommitTransactionRequest) ProtoMessage()    {}
+func (*CommitTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{21}
+}
+
+func (m *CommitTransactionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CommitTransactionRequest.Unmarshal(m, b)
+}
+func (m *CommitTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CommitTransactionRequest.Marshal(b, m, deterministic)
+}
+func (m *CommitTransactionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitTransactionRequest.Merge(m, src)
+}
+func (m *CommitTransactionRequest) XXX_Size() int {
+	return xxx_messageInfo_CommitTransactionRequest.Size(m)
+}
+func (m *CommitTransactionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitTransactionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitTransactionRequest proto.InternalMessageInfo
+
+func (m *CommitTransactionRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+func (m *CommitTransactionRequest) GetRevision() *Revision {
+	if m != nil {
+		return m.Revision
+	}
+	return nil
+}
+
+type CommitTransactionReply struct {
+	//unset if transaction doesn't write
+	Revision             *Revision `protobuf:"bytes,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *CommitTransactionReply) Reset()         { *m = CommitTransactionReply{} }
+func (m *CommitTransactionReply) String() string { return proto.CompactTextString(m) }
+func (*CommitTransactionReply) ProtoMessage()    {}
+func (*CommitTransactionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{22}
+}
+
+func (m *CommitTransactionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CommitTransactionReply.Unmarshal(m, b)
+}
+func (m *CommitTransactionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CommitTransactionReply.Marshal(b, m, deterministic)
+}
+func (m *CommitTransactionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CommitTransactionReply.Merge(m, src)
+}
+func (m *CommitTransactionReply) XXX_Size() int {
+	return xxx_messageInfo_CommitTransactionReply.Size(m)
+}
+func (m *CommitTransactionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_CommitTransactionReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CommitTransactionReply proto.InternalMessageInfo
+
+func (m *CommitTransactionReply) GetRevision() *Revision {
+	if m != nil {
+		return m.Revision
+	}
+	return nil
+}
+
+type RollbackTransactionRequest struct {
+	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RollbackTransactionRequest) Reset()         { *m = RollbackTransactionRequest{} }
+func (m *RollbackTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*RollbackTransactionRequest) ProtoMessage()    {}
+func (*RollbackTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{23}
+}
+
+func (m *RollbackTransactionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RollbackTransactionRequest.Unmarshal(m, b)
+}
+func (m *RollbackTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RollbackTransactionRequest.Marshal(b, m, deterministic)
+}
+func (m *RollbackTransactionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RollbackTransactionRequest.Merge(m, src)
+}
+func (m *RollbackTransactionRequest) XXX_Size() int {
+	return xxx_messageInfo_RollbackTransactionRequest.Size(m)
+}
+func (m *RollbackTransactionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RollbackTransactionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RollbackTransactionRequest proto.InternalMessageInfo
+
+func (m *RollbackTransactionRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+type AddRequest struct {
+	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AddRequest) Reset()         { *m = AddRequest{} }
+func (m *AddRequest) String() string { return proto.CompactTextString(m) }
+func (*AddRequest) ProtoMessage()    {}
+func (*AddRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{24}
+}
+
+func (m *AddRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AddRequest.Unmarshal(m, b)
+}
+func (m *AddRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AddRequest.Marshal(b, m, deterministic)
+}
+func (m *AddRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AddRequest.Merge(m, src)
+}
+func (m *AddRequest) XXX_Size() int {
+	return xxx_messageInfo_AddRequest.Size(m)
+}
+func (m *AddRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AddRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AddRequest proto.InternalMessageInfo
+
+func (m *AddRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+func (m *AddRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *AddRequest) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type DeleteRequest struct {
+	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteRequest) Reset()         { *m = DeleteRequest{} }
+func (m *DeleteRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteRequest) ProtoMessage()    {}
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{25}
+}
+
+func (m *DeleteRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteRequest.Unmarshal(m, b)
+}
+func (m *DeleteRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteRequest.Merge(m, src)
+}
+func (m *DeleteRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteRequest.Size(m)
+}
+func (m *DeleteRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteRequest proto.InternalMessageInfo
+
+func (m *DeleteRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+func (m *DeleteRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type UpdateRequest struct {
+	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value                []byte   `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdateRequest) Reset()         { *m = UpdateRequest{} }
+func (m *UpdateRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdateRequest) ProtoMessage()    {}
+func (*UpdateRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{26}
+}
+
+func (m *UpdateRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdateRequest.Unmarshal(m, b)
+}
+func (m *UpdateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdateRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdateRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdateRequest.Merge(m, src)
+}
+func (m *UpdateRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdateRequest.Size(m)
+}
+func (m *UpdateRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdateRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdateRequest proto.InternalMessageInfo
+
+func (m *UpdateRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+func (m *UpdateRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *UpdateRequest) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type GetRequest struct {
+	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetRequest) Reset()         { *m = GetRequest{} }
+func (m *GetRequest) String() string { return proto.CompactTextString(m) }
+func (*GetRequest) ProtoMessage()    {}
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{27}
+}
+
+func (m *GetRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
+}
+func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
+}
+func (m *GetRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetRequest.Merge(m, src)
+}
+func (m *GetRequest) XXX_Size() int {
+	return xxx_messageInfo_GetRequest.Size(m)
+}
+func (m *GetRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetRequest proto.InternalMessageInfo
+
+func (m *GetRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+func (m *GetRequest) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+type GetResponse struct {
+	Value                []byte   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetResponse) Reset()         { *m = GetResponse{} }
+func (m *GetResponse) String() string { return proto.CompactTextString(m) }
+func (*GetResponse) ProtoMessage()    {}
+func (*GetResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{28}
+}
+
+func (m *GetResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetResponse.Unmarshal(m, b)
+}
+func (m *GetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetResponse.Marshal(b, m, deterministic)
+}
+func (m *GetResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetResponse.Merge(m, src)
+}
+func (m *GetResponse) XXX_Size() int {
+	return xxx_messageInfo_GetResponse.Size(m)
+}
+func (m *GetResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetResponse proto.InternalMessageInfo
+
+func (m *GetResponse) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type ListRequest struct {
+	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListRequest) Reset()         { *m = ListRequest{} }
+func (m *ListRequest) String() string { return proto.CompactTextString(m) }
+func (*ListRequest) ProtoMessage()    {}
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{29}
+}
+
+func (m *ListRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListRequest.Unmarshal(m, b)
+}
+func (m *ListRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListRequest.Marshal(b, m, deterministic)
+}
+func (m *ListRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListRequest.Merge(m, src)
+}
+func (m *ListRequest) XXX_Size() int {
+	return xxx_messageInfo_ListRequest.Size(m)
+}
+func (m *ListRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListRequest proto.InternalMessageInfo
+
+func (m *ListRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+type ListResponse struct {
+	Values               map[string][]byte `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
+}
+
+func (m *ListResponse) Reset()         { *m = ListResponse{} }
+func (m *ListResponse) String() string { return proto.CompactTextString(m) }
+func (*ListResponse) ProtoMessage()    {}
+func (*ListResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{30}
+}
+
+func (m *ListResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListResponse.Unmarshal(m, b)
+}
+func (m *ListResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListResponse.Marshal(b, m, deterministic)
+}
+func (m *ListResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListResponse.Merge(m, src)
+}
+func (m *ListResponse) XXX_Size() int {
+	return xxx_messageInfo_ListResponse.Size(m)
+}
+func (m *ListResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListResponse proto.InternalMessageInfo
+
+func (m *ListResponse) GetValues() map[string][]byte {
+	if m != nil {
+		return m.Values
+	}
+	return nil
+}
+
+type BackupRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BackupRequest) Reset()         { *m = BackupRequest{} }
+func (m *BackupRequest) String() string { return proto.CompactTextString(m) }
+func (*BackupRequest) ProtoMessage()    {}
+func (*BackupRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{31}
+}
+
+func (m *BackupRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BackupRequest.Unmarshal(m, b)
+}
+func (m *BackupRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BackupRequest.Marshal(b, m, deterministic)
+}
+func (m *BackupRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BackupRequest.Merge(m, src)
+}
+func (m *BackupRequest) XXX_Size() int {
+	return xxx_messageInfo_BackupRequest.Size(m)
+}
+func (m *BackupRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BackupRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BackupRequest proto.InternalMessageInfo
+
+type BackupReply struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Checksum             string   `protobuf:"bytes,2,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BackupReply) Reset()         { *m = BackupReply{} }
+func (m *BackupReply) String() string { return proto.CompactTextString(m) }
+func (*BackupReply) ProtoMessage()    {}
+func (*BackupReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{32}
+}
+
+func (m *BackupReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BackupReply.Unmarshal(m, b)
+}
+func (m *BackupReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BackupReply.Marshal(b, m, deterministic)
+}
+func (m *BackupReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BackupReply.Merge(m, src)
+}
+func (m *BackupReply) XXX_Size() int {
+	return xxx_messageInfo_BackupReply.Size(m)
+}
+func (m *BackupReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_BackupReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BackupReply proto.InternalMessageInfo
+
+func (m *BackupReply) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *BackupReply) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+type RestoreRequest struct {
+	Data                 []byte   `protobuf:"bytes,1,opt,name=data,proto3" json:"data,omitempty"`
+	Checksum             string   `protobuf:"bytes,2,opt,name=checksum,proto3" json:"checksum,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *RestoreRequest) Reset()         { *m = RestoreRequest{} }
+func (m *RestoreRequest) String() string { return proto.CompactTextString(m) }
+func (*RestoreRequest) ProtoMessage()    {}
+func (*RestoreRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{33}
+}
+
+func (m *RestoreRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RestoreRequest.Unmarshal(m, b)
+}
+func (m *RestoreRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RestoreRequest.Marshal(b, m, deterministic)
+}
+func (m *RestoreRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RestoreRequest.Merge(m, src)
+}
+func (m *RestoreRequest) XXX_Size() int {
+	return xxx_messageInfo_RestoreRequest.Size(m)
+}
+func (m *RestoreRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RestoreRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_RestoreRequest proto.InternalMessageInfo
+
+func (m *RestoreRequest) GetData() []byte {
+	if m != nil {
+		return m.Data
+	}
+	return nil
+}
+
+func (m *RestoreRequest) GetChecksum() string {
+	if m != nil {
+		return m.Checksum
+	}
+	return ""
+}
+
+type CompactRequest struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CompactRequest) Reset()         { *m = CompactRequest{} }
+func (m *CompactRequest) String() string { return proto.CompactTextString(m) }
+func (*CompactRequest) ProtoMessage()    {}
+func (*CompactRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{34}
+}
+
+func (m *CompactRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CompactRequest.Unmarshal(m, b)
+}
+func (m *CompactRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CompactRequest.Marshal(b, m, deterministic)
+}
+func (m *CompactRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CompactRequest.Merge(m, src)
+}
+func (m *CompactRequest) XXX_Size() int {
+	return xxx_messageInfo_CompactRequest.Size(m)
+}
+func (m *CompactRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CompactRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CompactRequest proto.InternalMessageInfo
+
+type AuditEntry struct {
+	//unix time in nanoseconds
+	Time                 int64    `protobuf:"varint,1,opt,name=time,proto3" json:"time,omitempty"`
+	Identity             string   `protobuf:"bytes,2,opt,name=identity,proto3" json:"identity,omitempty"`
+	Peer                 string   `protobuf:"bytes,3,opt,name=peer,proto3" json:"peer,omitempty"`
+	Operation            string   `protobuf:"bytes,4,opt,name=operation,proto3" json:"operation,omitempty"`
+	Table                string   `protobuf:"bytes,5,opt,name=table,proto3" json:"table,omitempty"`
+	Key                  string   `protobuf:"bytes,6,opt,name=key,proto3" json:"key,omitempty"`
+	ValueSize            int64    `protobuf:"varint,7,opt,name=value_size,json=valueSize,proto3" json:"value_size,omitempty"`
+	ValueHash            string   `protobuf:"bytes,8,opt,name=value_hash,json=valueHash,proto3" json:"value_hash,omitempty"`
+	Err                  string   `protobuf:"bytes,9,opt,name=err,proto3" json:"err,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *UpdateRequest) Reset()         { *m = UpdateRequest{} }
-func (m *UpdateRequest) String() string { return proto.CompactTextString(m) }
-func (*UpdateRequest) ProtoMessage()    {}
-func (*UpdateRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{11}
+func (m *AuditEntry) Reset()         { *m = AuditEntry{} }
+func (m *AuditEntry) String() string { return proto.CompactTextString(m) }
+func (*AuditEntry) ProtoMessage()    {}
+func (*AuditEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{35}
 }
 
-func (m *UpdateRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_UpdateRequest.Unmarshal(m, b)
+func (m *AuditEntry) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AuditEntry.Unmarshal(m, b)
+}
+func (m *AuditEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AuditEntry.Marshal(b, m, deterministic)
+}
+func (m *AuditEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AuditEntry.Merge(m, src)
+}
+func (m *AuditEntry) XXX_Size() int {
+	return xxx_messageInfo_AuditEntry.Size(m)
+}
+func (m *AuditEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_AuditEntry.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AuditEntry proto.InternalMessageInfo
+
+func (m *AuditEntry) GetTime() int64 {
+	if m != nil {
+		return m.Time
+	}
+	return 0
+}
+
+func (m *AuditEntry) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetOperation() string {
+	if m != nil {
+		return m.Operation
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetValueSize() int64 {
+	if m != nil {
+		return m.ValueSize
+	}
+	return 0
+}
+
+func (m *AuditEntry) GetValueHash() string {
+	if m != nil {
+		return m.ValueHash
+	}
+	return ""
+}
+
+func (m *AuditEntry) GetErr() string {
+	if m != nil {
+		return m.Err
+	}
+	return ""
+}
+
+type QueryAuditRequest struct {
+	//unix time in nanoseconds, 0 means no limit
+	Since int64 `protobuf:"varint,1,opt,name=since,proto3" json:"since,omitempty"`
+	Until int64 `protobuf:"varint,2,opt,name=until,proto3" json:"until,omitempty"`
+	//entries of the table and its sub tables
+	Table string `protobuf:"bytes,3,opt,name=table,proto3" json:"table,omitempty"`
+	//only the newest entries are returned, 0 means no limit
+	Limit                int64    `protobuf:"varint,4,opt,name=limit,proto3" json:"limit,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *QueryAuditRequest) Reset()         { *m = QueryAuditRequest{} }
+func (m *QueryAuditRequest) String() string { return proto.CompactTextString(m) }
+func (*QueryAuditRequest) ProtoMessage()    {}
+func (*QueryAuditRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{36}
+}
+
+func (m *QueryAuditRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryAuditRequest.Unmarshal(m, b)
+}
+func (m *QueryAuditRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryAuditRequest.Marshal(b, m, deterministic)
+}
+func (m *QueryAuditRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryAuditRequest.Merge(m, src)
+}
+func (m *QueryAuditRequest) XXX_Size() int {
+	return xxx_messageInfo_QueryAuditRequest.Size(m)
+}
+func (m *QueryAuditRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryAuditRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryAuditRequest proto.InternalMessageInfo
+
+func (m *QueryAuditRequest) GetSince() int64 {
+	if m != nil {
+		return m.Since
+	}
+	return 0
+}
+
+func (m *QueryAuditRequest) GetUntil() int64 {
+	if m != nil {
+		return m.Until
+	}
+	return 0
+}
+
+func (m *QueryAuditRequest) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *QueryAuditRequest) GetLimit() int64 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+type QueryAuditReply struct {
+	Entries              []*AuditEntry `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *QueryAuditReply) Reset()         { *m = QueryAuditReply{} }
+func (m *QueryAuditReply) String() string { return proto.CompactTextString(m) }
+func (*QueryAuditReply) ProtoMessage()    {}
+func (*QueryAuditReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{37}
+}
+
+func (m *QueryAuditReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_QueryAuditReply.Unmarshal(m, b)
+}
+func (m *QueryAuditReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_QueryAuditReply.Marshal(b, m, deterministic)
+}
+func (m *QueryAuditReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_QueryAuditReply.Merge(m, src)
+}
+func (m *QueryAuditReply) XXX_Size() int {
+	return xxx_messageInfo_QueryAuditReply.Size(m)
+}
+func (m *QueryAuditReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_QueryAuditReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_QueryAuditReply proto.InternalMessageInfo
+
+func (m *QueryAuditReply) GetEntries() []*AuditEntry {
+	if m != nil {
+		return m.Entries
+	}
+	return nil
+}
+
+type ListTransactionsRequest struct {
+	//only transactions opened longer than min age in nanoseconds are returned
+	MinAge               int64    `protobuf:"varint,1,opt,name=min_age,json=minAge,proto3" json:"min_age,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListTransactionsRequest) Reset()         { *m = ListTransactionsRequest{} }
+func (m *ListTransactionsRequest) String() string { return proto.CompactTextString(m) }
+func (*ListTransactionsRequest) ProtoMessage()    {}
+func (*ListTransactionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{38}
+}
+
+func (m *ListTransactionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTransactionsRequest.Unmarshal(m, b)
+}
+func (m *ListTransactionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTransactionsRequest.Marshal(b, m, deterministic)
+}
+func (m *ListTransactionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTransactionsRequest.Merge(m, src)
+}
+func (m *ListTransactionsRequest) XXX_Size() int {
+	return xxx_messageInfo_ListTransactionsRequest.Size(m)
+}
+func (m *ListTransactionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTransactionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTransactionsRequest proto.InternalMessageInfo
+
+func (m *ListTransactionsRequest) GetMinAge() int64 {
+	if m != nil {
+		return m.MinAge
+	}
+	return 0
+}
+
+type TransactionInfo struct {
+	TxId     int64  `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	Table    string `protobuf:"bytes,2,opt,name=table,proto3" json:"table,omitempty"`
+	Peer     string `protobuf:"bytes,3,opt,name=peer,proto3" json:"peer,omitempty"`
+	Identity string `protobuf:"bytes,4,opt,name=identity,proto3" json:"identity,omitempty"`
+	//unix time in nanoseconds
+	Begin int64 `protobuf:"varint,5,opt,name=begin,proto3" json:"begin,omitempty"`
+	//nanoseconds since transaction begins
+	Age int64 `protobuf:"varint,6,opt,name=age,proto3" json:"age,omitempty"`
+	//count of get, list, add, delete and update
+	OpCount              int64    `protobuf:"varint,7,opt,name=op_count,json=opCount,proto3" json:"op_count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransactionInfo) Reset()         { *m = TransactionInfo{} }
+func (m *TransactionInfo) String() string { return proto.CompactTextString(m) }
+func (*TransactionInfo) ProtoMessage()    {}
+func (*TransactionInfo) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{39}
+}
+
+func (m *TransactionInfo) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransactionInfo.Unmarshal(m, b)
+}
+func (m *TransactionInfo) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransactionInfo.Marshal(b, m, deterministic)
+}
+func (m *TransactionInfo) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransactionInfo.Merge(m, src)
+}
+func (m *TransactionInfo) XXX_Size() int {
+	return xxx_messageInfo_TransactionInfo.Size(m)
+}
+func (m *TransactionInfo) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransactionInfo.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransactionInfo proto.InternalMessageInfo
+
+func (m *TransactionInfo) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+func (m *TransactionInfo) GetTable() string {
+	if m != nil {
+		return m.Table
+	}
+	return ""
+}
+
+func (m *TransactionInfo) GetPeer() string {
+	if m != nil {
+		return m.Peer
+	}
+	return ""
+}
+
+func (m *TransactionInfo) GetIdentity() string {
+	if m != nil {
+		return m.Identity
+	}
+	return ""
+}
+
+func (m *TransactionInfo) GetBegin() int64 {
+	if m != nil {
+		return m.Begin
+	}
+	return 0
+}
+
+func (m *TransactionInfo) GetAge() int64 {
+	if m != nil {
+		return m.Age
+	}
+	return 0
+}
+
+func (m *TransactionInfo) GetOpCount() int64 {
+	if m != nil {
+		return m.OpCount
+	}
+	return 0
+}
+
+type ListTransactionsReply struct {
+	Transactions         []*TransactionInfo `protobuf:"bytes,1,rep,name=transactions,proto3" json:"transactions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}           `json:"-"`
+	XXX_unrecognized     []byte             `json:"-"`
+	XXX_sizecache        int32              `json:"-"`
+}
+
+func (m *ListTransactionsReply) Reset()         { *m = ListTransactionsReply{} }
+func (m *ListTransactionsReply) String() string { return proto.CompactTextString(m) }
+func (*ListTransactionsReply) ProtoMessage()    {}
+func (*ListTransactionsReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{40}
+}
+
+func (m *ListTransactionsReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListTransactionsReply.Unmarshal(m, b)
+}
+func (m *ListTransactionsReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListTransactionsReply.Marshal(b, m, deterministic)
+}
+func (m *ListTransactionsReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListTransactionsReply.Merge(m, src)
+}
+func (m *ListTransactionsReply) XXX_Size() int {
+	return xxx_messageInfo_ListTransactionsReply.Size(m)
+}
+func (m *ListTransactionsReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListTransactionsReply.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListTransactionsReply proto.InternalMessageInfo
+
+func (m *ListTransactionsReply) GetTransactions() []*TransactionInfo {
+	if m != nil {
+		return m.Transactions
+	}
+	return nil
+}
+
+type AbortTransactionRequest struct {
+	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AbortTransactionRequest) Reset()         { *m = AbortTransactionRequest{} }
+func (m *AbortTransactionRequest) String() string { return proto.CompactTextString(m) }
+func (*AbortTransactionRequest) ProtoMessage()    {}
+func (*AbortTransactionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{41}
+}
+
+func (m *AbortTransactionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AbortTransactionRequest.Unmarshal(m, b)
+}
+func (m *AbortTransactionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AbortTransactionRequest.Marshal(b, m, deterministic)
+}
+func (m *AbortTransactionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AbortTransactionRequest.Merge(m, src)
+}
+func (m *AbortTransactionRequest) XXX_Size() int {
+	return xxx_messageInfo_AbortTransactionRequest.Size(m)
+}
+func (m *AbortTransactionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_AbortTransactionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AbortTransactionRequest proto.InternalMessageInfo
+
+func (m *AbortTransactionRequest) GetTxId() int64 {
+	if m != nil {
+		return m.TxId
+	}
+	return 0
+}
+
+type BatchOperation struct {
+	Type                 BatchOperation_Type `protobuf:"varint,1,opt,name=type,proto3,enum=pb.BatchOperation_Type" json:"type,omitempty"`
+	Key                  string              `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Value                []byte              `protobuf:"bytes,3,opt,name=value,proto3" json:"value,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *BatchOperation) Reset()         { *m = BatchOperation{} }
+func (m *BatchOperation) String() string { return proto.CompactTextString(m) }
+func (*BatchOperation) ProtoMessage()    {}
+func (*BatchOperation) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{42}
+}
+
+func (m *BatchOperation) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchOperation.Unmarshal(m, b)
+}
+func (m *BatchOperation) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchOperation.Marshal(b, m, deterministic)
+}
+func (m *BatchOperation) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchOperation.Merge(m, src)
+}
+func (m *BatchOperation) XXX_Size() int {
+	return xxx_messageInfo_BatchOperation.Size(m)
+}
+func (m *BatchOperation) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchOperation.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BatchOperation proto.InternalMessageInfo
+
+func (m *BatchOperation) GetType() BatchOperation_Type {
+	if m != nil {
+		return m.Type
+	}
+	return BatchOperation_GET
+}
+
+func (m *BatchOperation) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
+}
+
+func (m *BatchOperation) GetValue() []byte {
+	if m != nil {
+		return m.Value
+	}
+	return nil
+}
+
+type BatchRequest struct {
+	TableName string `protobuf:"bytes,1,opt,name=table_name,json=tableName,proto3" json:"table_name,omitempty"`
+	//applied in order in one transaction
+	Operations []*BatchOperation `protobuf:"bytes,2,rep,name=operations,proto3" json:"operations,omitempty"`
+	//set when the batch of master is replicated to slave
+	Revision             *Revision `protobuf:"bytes,3,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
+}
+
+func (m *BatchRequest) Reset()         { *m = BatchRequest{} }
+func (m *BatchRequest) String() string { return proto.CompactTextString(m) }
+func (*BatchRequest) ProtoMessage()    {}
+func (*BatchRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{43}
+}
+
+func (m *BatchRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchRequest.Unmarshal(m, b)
 }
-func (m *UpdateRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_UpdateRequest.Marshal(b, m, deterministic)
+func (m *BatchRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchRequest.Marshal(b, m, deterministic)
 }
-func (m *UpdateRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_UpdateRequest.Merge(m, src)
+func (m *BatchRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchRequest.Merge(m, src)
 }
-func (m *UpdateRequest) XXX_Size() int {
-	return xxx_messageInfo_UpdateRequest.Size(m)
+func (m *BatchRequest) XXX_Size() int {
+	return xxx_messageInfo_BatchRequest.Size(m)
 }
-func (m *UpdateRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_UpdateRequest.DiscardUnknown(m)
+func (m *BatchRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_UpdateRequest proto.InternalMessageInfo
+var xxx_messageInfo_BatchRequest proto.InternalMessageInfo
 
-func (m *UpdateRequest) GetTxId() int64 {
+func (m *BatchRequest) GetTableName() string {
 	if m != nil {
-		return m.TxId
+		return m.TableName
 	}
-	return 0
+	return ""
 }
 
-func (m *UpdateRequest) GetKey() string {
+func (m *BatchRequest) GetOperations() []*BatchOperation {
 	if m != nil {
-		return m.Key
+		return m.Operations
 	}
-	return ""
+	return nil
 }
 
-func (m *UpdateRequest) GetValue() []byte {
+func (m *BatchRequest) GetRevision() *Revision {
 	if m != nil {
-		return m.Value
+		return m.Revision
 	}
 	return nil
 }
 
-type GetRequest struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
-	Key                  string   `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+type BatchResult struct {
+	//value and found are only set for get
+	Value []byte `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
+	Found bool   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	//only set for list
+	Values               map[string][]byte `protobuf:"bytes,3,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
-func (m *GetRequest) Reset()         { *m = GetRequest{} }
-func (m *GetRequest) String() string { return proto.CompactTextString(m) }
-func (*GetRequest) ProtoMessage()    {}
-func (*GetRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{12}
+func (m *BatchResult) Reset()         { *m = BatchResult{} }
+func (m *BatchResult) String() string { return proto.CompactTextString(m) }
+func (*BatchResult) ProtoMessage()    {}
+func (*BatchResult) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{44}
 }
 
-func (m *GetRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetRequest.Unmarshal(m, b)
+func (m *BatchResult) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchResult.Unmarshal(m, b)
 }
-func (m *GetRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetRequest.Marshal(b, m, deterministic)
+func (m *BatchResult) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchResult.Marshal(b, m, deterministic)
 }
-func (m *GetRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetRequest.Merge(m, src)
+func (m *BatchResult) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchResult.Merge(m, src)
 }
-func (m *GetRequest) XXX_Size() int {
-	return xxx_messageInfo_GetRequest.Size(m)
+func (m *BatchResult) XXX_Size() int {
+	return xxx_messageInfo_BatchResult.Size(m)
 }
-func (m *GetRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetRequest.DiscardUnknown(m)
+func (m *BatchResult) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchResult.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetRequest proto.InternalMessageInfo
+var xxx_messageInfo_BatchResult proto.InternalMessageInfo
 
-func (m *GetRequest) GetTxId() int64 {
+func (m *BatchResult) GetValue() []byte {
 	if m != nil {
-		return m.TxId
+		return m.Value
 	}
-	return 0
+	return nil
 }
 
-func (m *GetRequest) GetKey() string {
+func (m *BatchResult) GetFound() bool {
 	if m != nil {
-		return m.Key
+		return m.Found
 	}
-	return ""
+	return false
 }
 
-type GetResponse struct {
-	Value                []byte   `protobuf:"bytes,1,opt,name=value,proto3" json:"value,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+func (m *BatchResult) GetValues() map[string][]byte {
+	if m != nil {
+		return m.Values
+	}
+	return nil
 }
 
-func (m *GetResponse) Reset()         { *m = GetResponse{} }
-func (m *GetResponse) String() string { return proto.CompactTextString(m) }
-func (*GetResponse) ProtoMessage()    {}
-func (*GetResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{13}
+type BatchReply struct {
+	//one result for each operation
+	Results []*BatchResult `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	//unset if batch doesn't write
+	Revision             *Revision `protobuf:"bytes,2,opt,name=revision,proto3" json:"revision,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *GetResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_GetResponse.Unmarshal(m, b)
+func (m *BatchReply) Reset()         { *m = BatchReply{} }
+func (m *BatchReply) String() string { return proto.CompactTextString(m) }
+func (*BatchReply) ProtoMessage()    {}
+func (*BatchReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{45}
 }
-func (m *GetResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_GetResponse.Marshal(b, m, deterministic)
+
+func (m *BatchReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_BatchReply.Unmarshal(m, b)
 }
-func (m *GetResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_GetResponse.Merge(m, src)
+func (m *BatchReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_BatchReply.Marshal(b, m, deterministic)
 }
-func (m *GetResponse) XXX_Size() int {
-	return xxx_messageInfo_GetResponse.Size(m)
+func (m *BatchReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BatchReply.Merge(m, src)
 }
-func (m *GetResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_GetResponse.DiscardUnknown(m)
+func (m *BatchReply) XXX_Size() int {
+	return xxx_messageInfo_BatchReply.Size(m)
+}
+func (m *BatchReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_BatchReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_GetResponse proto.InternalMessageInfo
+var xxx_messageInfo_BatchReply proto.InternalMessageInfo
 
-func (m *GetResponse) GetValue() []byte {
+func (m *BatchReply) GetResults() []*BatchResult {
 	if m != nil {
-		return m.Value
+		return m.Results
 	}
 	return nil
 }
 
-type ListRequest struct {
-	TxId                 int64    `protobuf:"varint,1,opt,name=tx_id,json=txId,proto3" json:"tx_id,omitempty"`
+func (m *BatchReply) GetRevision() *Revision {
+	if m != nil {
+		return m.Revision
+	}
+	return nil
+}
+
+type RevisionRequest struct {
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
 }
 
-func (m *ListRequest) Reset()         { *m = ListRequest{} }
-func (m *ListRequest) String() string { return proto.CompactTextString(m) }
-func (*ListRequest) ProtoMessage()    {}
-func (*ListRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{14}
+func (m *RevisionRequest) Reset()         { *m = RevisionRequest{} }
+func (m *RevisionRequest) String() string { return proto.CompactTextString(m) }
+func (*RevisionRequest) ProtoMessage()    {}
+func (*RevisionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{46}
 }
 
-func (m *ListRequest) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ListRequest.Unmarshal(m, b)
+func (m *RevisionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevisionRequest.Unmarshal(m, b)
 }
-func (m *ListRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ListRequest.Marshal(b, m, deterministic)
+func (m *RevisionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevisionRequest.Marshal(b, m, deterministic)
 }
-func (m *ListRequest) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ListRequest.Merge(m, src)
+func (m *RevisionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevisionRequest.Merge(m, src)
 }
-func (m *ListRequest) XXX_Size() int {
-	return xxx_messageInfo_ListRequest.Size(m)
+func (m *RevisionRequest) XXX_Size() int {
+	return xxx_messageInfo_RevisionRequest.Size(m)
 }
-func (m *ListRequest) XXX_DiscardUnknown() {
-	xxx_messageInfo_ListRequest.DiscardUnknown(m)
+func (m *RevisionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevisionRequest.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ListRequest proto.InternalMessageInfo
-
-func (m *ListRequest) GetTxId() int64 {
-	if m != nil {
-		return m.TxId
-	}
-	return 0
-}
+var xxx_messageInfo_RevisionRequest proto.InternalMessageInfo
 
-type ListResponse struct {
-	Values               map[string][]byte `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
-	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
-	XXX_unrecognized     []byte            `json:"-"`
-	XXX_sizecache        int32             `json:"-"`
+type RevisionReply struct {
+	Revision *Revision `protobuf:"bytes,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	//unset if unknown
+	Applied              *Revision `protobuf:"bytes,2,opt,name=applied,proto3" json:"applied,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
-func (m *ListResponse) Reset()         { *m = ListResponse{} }
-func (m *ListResponse) String() string { return proto.CompactTextString(m) }
-func (*ListResponse) ProtoMessage()    {}
-func (*ListResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_1b14dcbe5169b67b, []int{15}
+func (m *RevisionReply) Reset()         { *m = RevisionReply{} }
+func (m *RevisionReply) String() string { return proto.CompactTextString(m) }
+func (*RevisionReply) ProtoMessage()    {}
+func (*RevisionReply) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1b14dcbe5169b67b, []int{47}
 }
 
-func (m *ListResponse) XXX_Unmarshal(b []byte) error {
-	return xxx_messageInfo_ListResponse.Unmarshal(m, b)
+func (m *RevisionReply) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_RevisionReply.Unmarshal(m, b)
 }
-func (m *ListResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
-	return xxx_messageInfo_ListResponse.Marshal(b, m, deterministic)
+func (m *RevisionReply) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_RevisionReply.Marshal(b, m, deterministic)
 }
-func (m *ListResponse) XXX_Merge(src proto.Message) {
-	xxx_messageInfo_ListResponse.Merge(m, src)
+func (m *RevisionReply) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_RevisionReply.Merge(m, src)
 }
-func (m *ListResponse) XXX_Size() int {
-	return xxx_messageInfo_ListResponse.Size(m)
+func (m *RevisionReply) XXX_Size() int {
+	return xxx_messageInfo_RevisionReply.Size(m)
 }
-func (m *ListResponse) XXX_DiscardUnknown() {
-	xxx_messageInfo_ListResponse.DiscardUnknown(m)
+func (m *RevisionReply) XXX_DiscardUnknown() {
+	xxx_messageInfo_RevisionReply.DiscardUnknown(m)
 }
 
-var xxx_messageInfo_ListResponse proto.InternalMessageInfo
+var xxx_messageInfo_RevisionReply proto.InternalMessageInfo
 
-func (m *ListResponse) GetValues() map[string][]byte {
+func (m *RevisionReply) GetRevision() *Revision {
 	if m != nil {
-		return m.Values
+		return m.Revision
+	}
+	return nil
+}
+
+func (m *RevisionReply) GetApplied() *Revision {
+	if m != nil {
+		return m.Applied
 	}
 	return nil
 }
 
 func init() {
+	proto.RegisterEnum("pb.BatchOperation_Type", BatchOperation_Type_name, BatchOperation_Type_value)
+	proto.RegisterType((*Revision)(nil), "pb.Revision")
 	proto.RegisterType((*ChecksumRequest)(nil), "pb.ChecksumRequest")
 	proto.RegisterType((*ChecksumReply)(nil), "pb.ChecksumReply")
 	proto.RegisterType((*DestroyRequest)(nil), "pb.DestroyRequest")
 	proto.RegisterType((*CreateOrGetTableRequest)(nil), "pb.CreateOrGetTableRequest")
 	proto.RegisterType((*DeleteTableRequest)(nil), "pb.DeleteTableRequest")
+	proto.RegisterType((*DeleteTableReply)(nil), "pb.DeleteTableReply")
+	proto.RegisterType((*ListTablesRequest)(nil), "pb.ListTablesRequest")
+	proto.RegisterType((*ListTablesReply)(nil), "pb.ListTablesReply")
+	proto.RegisterType((*TableExistsRequest)(nil), "pb.TableExistsRequest")
+	proto.RegisterType((*TableExistsReply)(nil), "pb.TableExistsReply")
+	proto.RegisterType((*StatsRequest)(nil), "pb.StatsRequest")
+	proto.RegisterType((*StorageStats)(nil), "pb.StorageStats")
+	proto.RegisterType((*StatsReply)(nil), "pb.StatsReply")
+	proto.RegisterType((*MerkleTreeRequest)(nil), "pb.MerkleTreeRequest")
+	proto.RegisterType((*MerkleTreeReply)(nil), "pb.MerkleTreeReply")
+	proto.RegisterType((*KeyRange)(nil), "pb.KeyRange")
+	proto.RegisterType((*KeyDigestsRequest)(nil), "pb.KeyDigestsRequest")
+	proto.RegisterType((*KeyDigestsReply)(nil), "pb.KeyDigestsReply")
+	proto.RegisterMapType((map[string][]byte)(nil), "pb.KeyDigestsReply.DigestsEntry")
 	proto.RegisterType((*BeginTransactionRequest)(nil), "pb.BeginTransactionRequest")
 	proto.RegisterType((*BeginTransactionReply)(nil), "pb.BeginTransactionReply")
 	proto.RegisterType((*CommitTransactionRequest)(nil), "pb.CommitTransactionRequest")
+	proto.RegisterType((*CommitTransactionReply)(nil), "pb.CommitTransactionReply")
 	proto.RegisterType((*RollbackTransactionRequest)(nil), "pb.RollbackTransactionRequest")
 	proto.RegisterType((*AddRequest)(nil), "pb.AddRequest")
 	proto.RegisterType((*DeleteRequest)(nil), "pb.DeleteRequest")
@@ -699,49 +2476,152 @@ func init() {
 	proto.RegisterType((*ListRequest)(nil), "pb.ListRequest")
 	proto.RegisterType((*ListResponse)(nil), "pb.ListResponse")
 	proto.RegisterMapType((map[string][]byte)(nil), "pb.ListResponse.ValuesEntry")
+	proto.RegisterType((*BackupRequest)(nil), "pb.BackupRequest")
+	proto.RegisterType((*BackupReply)(nil), "pb.BackupReply")
+	proto.RegisterType((*RestoreRequest)(nil), "pb.RestoreRequest")
+	proto.RegisterType((*CompactRequest)(nil), "pb.CompactRequest")
+	proto.RegisterType((*AuditEntry)(nil), "pb.AuditEntry")
+	proto.RegisterType((*QueryAuditRequest)(nil), "pb.QueryAuditRequest")
+	proto.RegisterType((*QueryAuditReply)(nil), "pb.QueryAuditReply")
+	proto.RegisterType((*ListTransactionsRequest)(nil), "pb.ListTransactionsRequest")
+	proto.RegisterType((*TransactionInfo)(nil), "pb.TransactionInfo")
+	proto.RegisterType((*ListTransactionsReply)(nil), "pb.ListTransactionsReply")
+	proto.RegisterType((*AbortTransactionRequest)(nil), "pb.AbortTransactionRequest")
+	proto.RegisterType((*BatchOperation)(nil), "pb.BatchOperation")
+	proto.RegisterType((*BatchRequest)(nil), "pb.BatchRequest")
+	proto.RegisterType((*BatchResult)(nil), "pb.BatchResult")
+	proto.RegisterMapType((map[string][]byte)(nil), "pb.BatchResult.ValuesEntry")
+	proto.RegisterType((*BatchReply)(nil), "pb.BatchReply")
+	proto.RegisterType((*RevisionRequest)(nil), "pb.RevisionRequest")
+	proto.RegisterType((*RevisionReply)(nil), "pb.RevisionReply")
 }
 
 func init() { proto.RegisterFile("kvserver.proto", fileDescriptor_1b14dcbe5169b67b) }
 
 var fileDescriptor_1b14dcbe5169b67b = []byte{
-	// 580 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x94, 0xdf, 0x73, 0xd2, 0x40,
-	0x10, 0xc7, 0x09, 0x29, 0xd8, 0x2e, 0xe5, 0xd7, 0xa1, 0x2d, 0xa6, 0xea, 0x30, 0xe7, 0x0b, 0x33,
-	0xd5, 0x30, 0xb6, 0x55, 0xab, 0x2f, 0x4e, 0xa5, 0x1d, 0x86, 0x29, 0x63, 0x67, 0x62, 0xed, 0x6b,
-	0x27, 0x21, 0x2b, 0x32, 0x84, 0x24, 0x26, 0x07, 0xd3, 0x3c, 0xf9, 0xbf, 0xfa, 0x97, 0x38, 0xb9,
-	0x0b, 0x4d, 0xa0, 0x21, 0x96, 0xb7, 0xdc, 0xee, 0x7e, 0xbf, 0xb7, 0x97, 0xdb, 0xcf, 0x41, 0x65,
-	0x32, 0xf7, 0xd1, 0x9b, 0xa3, 0xa7, 0xba, 0x9e, 0xc3, 0x1c, 0x92, 0x77, 0x0d, 0xe5, 0x60, 0xe4,
-	0x38, 0x23, 0x0b, 0x3b, 0x3c, 0x62, 0xcc, 0x7e, 0x76, 0x70, 0xea, 0xb2, 0x40, 0x14, 0xd0, 0x3a,
-	0x54, 0xbb, 0xbf, 0x70, 0x38, 0xf1, 0x67, 0x53, 0x0d, 0x7f, 0xcf, 0xd0, 0x67, 0xf4, 0x10, 0xca,
-	0x71, 0xc8, 0xb5, 0x02, 0xa2, 0xc0, 0xf6, 0x30, 0x0a, 0x34, 0xa5, 0x96, 0xd4, 0xde, 0xd1, 0xee,
-	0xd7, 0xb4, 0x06, 0x95, 0x73, 0xf4, 0x99, 0xe7, 0x04, 0x0b, 0xf9, 0x5b, 0xd8, 0xef, 0x7a, 0xa8,
-	0x33, 0xbc, 0xf2, 0x7a, 0xc8, 0xae, 0x75, 0xc3, 0xc2, 0x28, 0x45, 0x08, 0x6c, 0xd9, 0xfa, 0x14,
-	0x23, 0x13, 0xfe, 0x4d, 0xdb, 0x40, 0xce, 0xd1, 0x42, 0x86, 0xff, 0xad, 0x3c, 0x85, 0xfd, 0xaf,
-	0x38, 0x1a, 0xdb, 0xd7, 0x9e, 0x6e, 0xfb, 0xfa, 0x90, 0x8d, 0x1d, 0x7b, 0x51, 0xfe, 0x12, 0x80,
-	0x85, 0xf2, 0xdb, 0x84, 0x68, 0x87, 0x47, 0xbe, 0x85, 0xca, 0x37, 0xf0, 0xec, 0xa1, 0x32, 0x3c,
-	0x59, 0x03, 0x0a, 0xec, 0xee, 0x76, 0x6c, 0x72, 0x89, 0xac, 0x6d, 0xb1, 0xbb, 0xbe, 0x49, 0x3b,
-	0xd0, 0xec, 0x3a, 0xd3, 0xe9, 0x98, 0xa5, 0x6c, 0x94, 0x2a, 0x78, 0x07, 0x8a, 0xe6, 0x58, 0x96,
-	0xa1, 0x0f, 0x27, 0x8f, 0x95, 0xf4, 0x01, 0xce, 0x4c, 0x33, 0xab, 0x84, 0xd4, 0x40, 0x9e, 0x60,
-	0xd0, 0xcc, 0xf3, 0xc3, 0x84, 0x9f, 0xe4, 0x29, 0x14, 0xe6, 0xba, 0x35, 0xc3, 0xa6, 0xdc, 0x92,
-	0xda, 0xbb, 0x9a, 0x58, 0xd0, 0x0f, 0x50, 0x16, 0x3f, 0x70, 0x33, 0x37, 0x3a, 0x80, 0xf2, 0x0f,
-	0xd7, 0xd4, 0x37, 0xd5, 0xad, 0xe9, 0xe2, 0x18, 0xa0, 0x87, 0x6c, 0xc3, 0x16, 0x5e, 0x43, 0x89,
-	0x8b, 0x7c, 0xd7, 0xb1, 0x7d, 0x8c, 0x9d, 0xa5, 0xa4, 0x33, 0x85, 0xd2, 0x60, 0xec, 0x67, 0x5a,
-	0xd3, 0x3f, 0xb0, 0x2b, 0x6a, 0x22, 0xa7, 0x13, 0x28, 0x72, 0xb1, 0xdf, 0x94, 0x5a, 0x72, 0xbb,
-	0x74, 0xf4, 0x42, 0x75, 0x0d, 0x35, 0x59, 0xa1, 0xde, 0xf0, 0xf4, 0x85, 0xcd, 0xbc, 0x40, 0x8b,
-	0x6a, 0x95, 0x4f, 0x50, 0x4a, 0x84, 0x17, 0xfd, 0x4a, 0x29, 0x47, 0xcf, 0x27, 0x1a, 0xfc, 0x9c,
-	0x3f, 0x95, 0x8e, 0xfe, 0x16, 0x40, 0xbe, 0xbc, 0xf9, 0x4e, 0x4e, 0x60, 0x7b, 0xc1, 0x0e, 0x69,
-	0x84, 0x9b, 0xae, 0xc0, 0xa5, 0xd4, 0x97, 0x83, 0xae, 0x15, 0xd0, 0x1c, 0xf9, 0x08, 0x4f, 0x22,
-	0x88, 0x08, 0x09, 0xf3, 0xcb, 0x44, 0x29, 0x7b, 0xaa, 0x20, 0x58, 0x5d, 0x10, 0xac, 0x5e, 0x84,
-	0x04, 0xd3, 0x1c, 0xe9, 0x43, 0x6d, 0x95, 0x35, 0x72, 0xc0, 0x77, 0x48, 0x27, 0x30, 0xc3, 0xea,
-	0x0b, 0x94, 0x12, 0x1c, 0x92, 0x3d, 0xd1, 0xc7, 0x2a, 0x98, 0x19, 0x06, 0x03, 0xa8, 0xad, 0x42,
-	0x26, 0x7a, 0x59, 0x03, 0xad, 0xf2, 0x3c, 0x3d, 0x29, 0x7e, 0xc9, 0x25, 0xd4, 0x1f, 0x40, 0x48,
-	0xf8, 0x35, 0xae, 0x63, 0x33, 0xa3, 0xb5, 0x2b, 0x68, 0xa4, 0x00, 0x4a, 0x5e, 0x85, 0x76, 0xeb,
-	0xc9, 0xcd, 0x30, 0x6c, 0x83, 0xdc, 0x43, 0x46, 0x2a, 0xa1, 0x41, 0x3c, 0xf6, 0x4a, 0xf5, 0x7e,
-	0x2d, 0xa6, 0x8c, 0xe6, 0xc8, 0x21, 0x6c, 0x85, 0x73, 0x47, 0xaa, 0xf1, 0x04, 0x8a, 0xda, 0xda,
-	0xea, 0x48, 0xd2, 0x1c, 0xe9, 0x80, 0x7c, 0x66, 0x9a, 0xc2, 0x36, 0x7e, 0x1e, 0x32, 0xfa, 0x78,
-	0x0f, 0x45, 0x71, 0x47, 0xa4, 0x1e, 0xdf, 0xd7, 0xa3, 0x64, 0x02, 0x7d, 0x21, 0x5b, 0x7a, 0x06,
-	0xd6, 0xcb, 0x8c, 0x22, 0x8f, 0x1c, 0xff, 0x0b, 0x00, 0x00, 0xff, 0xff, 0x05, 0xf8, 0xe3, 0x0b,
-	0x65, 0x06, 0x00, 0x00,
+	// 1948 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x58, 0xdd, 0x72, 0xe3, 0x48,
+	0x15, 0x1e, 0xff, 0xc4, 0x3f, 0xc7, 0x8e, 0x7f, 0xda, 0x99, 0xc4, 0xa3, 0xd9, 0x9d, 0x1a, 0x1a,
+	0x6a, 0x37, 0x64, 0x0a, 0xcf, 0xe2, 0x19, 0xd8, 0xdd, 0xc0, 0x54, 0x91, 0xbf, 0x0a, 0x61, 0xc2,
+	0x64, 0x50, 0x32, 0x5b, 0xc5, 0x95, 0x4b, 0x96, 0x3a, 0xb6, 0xca, 0xb2, 0x24, 0x24, 0x39, 0x8c,
+	0xf7, 0x02, 0xae, 0x29, 0x9e, 0x82, 0x4b, 0xae, 0x78, 0x00, 0x5e, 0x80, 0xe7, 0xe0, 0x82, 0xe7,
+	0xa0, 0xba, 0x4f, 0x4b, 0x6a, 0xd9, 0x8e, 0x37, 0xae, 0xdd, 0x3b, 0x9d, 0x9f, 0xfe, 0xfa, 0x9c,
+	0xee, 0xa3, 0xd3, 0x5f, 0x37, 0x34, 0x26, 0x77, 0x21, 0x0b, 0xee, 0x58, 0xd0, 0xf3, 0x03, 0x2f,
+	0xf2, 0x48, 0xde, 0x1f, 0x6a, 0x4f, 0x47, 0x9e, 0x37, 0x72, 0xd8, 0x4b, 0xa1, 0x19, 0xce, 0x6e,
+	0x5f, 0xb2, 0xa9, 0x1f, 0xcd, 0xd1, 0x81, 0x7e, 0x05, 0x15, 0x9d, 0xdd, 0xd9, 0xa1, 0xed, 0xb9,
+	0x64, 0x07, 0xb6, 0x98, 0xef, 0x99, 0xe3, 0x6e, 0xee, 0x79, 0x6e, 0xbf, 0xa0, 0xa3, 0x40, 0x76,
+	0xa1, 0xe4, 0xce, 0xa6, 0x43, 0x16, 0x74, 0xf3, 0x42, 0x2d, 0x25, 0x6a, 0x41, 0xf3, 0x64, 0xcc,
+	0xcc, 0x49, 0x38, 0x9b, 0xea, 0xec, 0x4f, 0x33, 0x16, 0x46, 0xe4, 0x73, 0x68, 0x4e, 0x8d, 0x30,
+	0x62, 0xc1, 0xc0, 0x94, 0x16, 0x01, 0x55, 0xd5, 0x1b, 0xa8, 0x8e, 0xfd, 0xc9, 0x3e, 0x54, 0x02,
+	0x39, 0xab, 0x40, 0xad, 0xf5, 0xeb, 0x3d, 0x7f, 0xd8, 0x8b, 0x23, 0xd1, 0x13, 0x2b, 0xfd, 0x00,
+	0xdb, 0xe9, 0x2c, 0xbe, 0x33, 0x27, 0x1a, 0x54, 0x16, 0xc0, 0x2b, 0xe6, 0xe6, 0xb0, 0x07, 0xd0,
+	0x38, 0x65, 0x61, 0x14, 0x78, 0xf3, 0x38, 0xf6, 0x2e, 0x94, 0x4d, 0xcf, 0xbd, 0xb5, 0x83, 0x18,
+	0x36, 0x16, 0xe9, 0xcf, 0x60, 0xef, 0x24, 0x60, 0x46, 0xc4, 0xae, 0x82, 0x73, 0x16, 0xdd, 0x18,
+	0x43, 0x87, 0xc5, 0x83, 0x08, 0x14, 0x5d, 0x63, 0xca, 0xe4, 0x08, 0xf1, 0x4d, 0x75, 0x20, 0xa7,
+	0xcc, 0x61, 0x11, 0xfb, 0x2e, 0xcf, 0x0d, 0xc2, 0xfd, 0x35, 0xb4, 0x32, 0x98, 0x7c, 0x21, 0xd4,
+	0xd1, 0xb9, 0xb5, 0xa3, 0x2f, 0xa0, 0x7d, 0x69, 0x87, 0x18, 0x79, 0x18, 0x07, 0xb4, 0x0b, 0x25,
+	0xdf, 0x08, 0x98, 0x1b, 0xc9, 0x90, 0xa4, 0x44, 0x3e, 0x81, 0x6a, 0xc0, 0xcc, 0x59, 0x10, 0xda,
+	0x77, 0x4c, 0x44, 0x55, 0xd1, 0x53, 0x05, 0xfd, 0x1c, 0x9a, 0x2a, 0x14, 0x8f, 0x63, 0x07, 0xb6,
+	0x78, 0x36, 0x61, 0x37, 0xf7, 0xbc, 0xb0, 0x5f, 0xd5, 0x51, 0xa0, 0xfb, 0x40, 0x84, 0xd3, 0xd9,
+	0x47, 0x3b, 0x8c, 0xc2, 0x75, 0xeb, 0x75, 0x00, 0xad, 0x8c, 0x27, 0xc7, 0xdc, 0x85, 0x12, 0x13,
+	0xa2, 0xf0, 0xac, 0xe8, 0x52, 0xa2, 0x14, 0xea, 0xd7, 0x91, 0xb1, 0x1e, 0xef, 0x3f, 0x05, 0xee,
+	0xe4, 0x05, 0xc6, 0x88, 0x09, 0x5f, 0x42, 0x61, 0x7b, 0x18, 0x18, 0xae, 0x39, 0x1e, 0xf8, 0xc6,
+	0x88, 0x0d, 0x5c, 0x59, 0xde, 0x35, 0x54, 0xbe, 0x37, 0x46, 0xec, 0x1d, 0x39, 0x80, 0xb6, 0xf4,
+	0xf1, 0xee, 0x58, 0x70, 0xeb, 0x78, 0x7f, 0x1e, 0xb8, 0xb2, 0xde, 0x9b, 0x68, 0xb8, 0x92, 0xfa,
+	0x77, 0xe4, 0x19, 0xd4, 0x1c, 0x66, 0xdc, 0xc6, 0x68, 0x05, 0xe1, 0x55, 0xe5, 0x2a, 0xc4, 0xfa,
+	0x0c, 0x9a, 0xc2, 0xae, 0x20, 0x15, 0x85, 0xcf, 0x36, 0x57, 0xa7, 0x38, 0x1d, 0xd8, 0x9a, 0xb0,
+	0xf9, 0xc0, 0xed, 0x6e, 0x09, 0x6b, 0x71, 0xc2, 0xe6, 0xef, 0xf8, 0x6a, 0x5a, 0xcc, 0x8f, 0xc6,
+	0xdd, 0x12, 0xfe, 0x83, 0x42, 0x20, 0x3f, 0x82, 0xba, 0x0c, 0xcf, 0x70, 0x1c, 0xcf, 0xec, 0x96,
+	0xd5, 0x0c, 0x8e, 0xb8, 0x4a, 0x71, 0xb1, 0xdd, 0x59, 0xc8, 0xba, 0x15, 0xd5, 0xe5, 0x82, 0xab,
+	0xc8, 0xa7, 0x00, 0x22, 0x30, 0xc4, 0xa8, 0xa6, 0x71, 0x23, 0x42, 0x6c, 0xc6, 0xf1, 0x90, 0x9a,
+	0x71, 0xf4, 0x13, 0xa8, 0x0c, 0x67, 0xe6, 0x84, 0x45, 0x03, 0xb7, 0x5b, 0x13, 0xc6, 0x32, 0xca,
+	0x22, 0x63, 0xdb, 0x75, 0x6c, 0x97, 0x0d, 0x12, 0x8f, 0x3a, 0x66, 0x8c, 0xea, 0x63, 0xe9, 0xd7,
+	0x83, 0x4e, 0xd6, 0x0f, 0xa7, 0xda, 0x16, 0xbe, 0x6d, 0xd5, 0x57, 0x4c, 0x49, 0xff, 0x9d, 0x03,
+	0x90, 0xfb, 0xcd, 0xab, 0xe2, 0x29, 0x54, 0xf9, 0x82, 0x99, 0xde, 0x4c, 0x56, 0x6d, 0x41, 0xaf,
+	0x4c, 0xd8, 0xfc, 0x84, 0xcb, 0x3c, 0x3c, 0x6e, 0x0c, 0xed, 0x6f, 0x99, 0xdc, 0xb8, 0xf2, 0x84,
+	0xcd, 0xaf, 0xed, 0x6f, 0x45, 0xde, 0x77, 0x86, 0x33, 0x63, 0x68, 0x94, 0xfb, 0x25, 0x34, 0xc2,
+	0x7c, 0x00, 0x6d, 0x73, 0x6c, 0x3b, 0xd6, 0x20, 0xe2, 0x65, 0x28, 0xe1, 0x71, 0xc7, 0x9a, 0xc2,
+	0x20, 0xca, 0x13, 0x67, 0x39, 0x80, 0x72, 0x88, 0xb5, 0x25, 0x76, 0xad, 0xd6, 0x6f, 0xf1, 0x7f,
+	0x4e, 0x2d, 0x37, 0x3d, 0x76, 0xa0, 0xe7, 0xd0, 0xfe, 0x3d, 0x0b, 0x26, 0x0e, 0xbb, 0x09, 0xd8,
+	0xda, 0x3e, 0xf0, 0x0c, 0x60, 0xe8, 0xcd, 0x5c, 0xcb, 0x08, 0x6c, 0x16, 0x76, 0xf3, 0xe2, 0x37,
+	0x52, 0x34, 0xd4, 0x84, 0xa6, 0x0a, 0xf4, 0x5d, 0x5d, 0x90, 0xff, 0x90, 0x9e, 0x25, 0x91, 0xea,
+	0x3a, 0x0a, 0x0b, 0x93, 0x14, 0x96, 0x26, 0xe9, 0x43, 0xe5, 0x2d, 0x9b, 0xeb, 0x86, 0x3b, 0x62,
+	0x1c, 0x61, 0xc8, 0x46, 0xb6, 0x2b, 0xa1, 0x51, 0x20, 0x2d, 0x28, 0x30, 0xd7, 0x12, 0x8b, 0x5b,
+	0xd5, 0xf9, 0x27, 0xbd, 0x86, 0xf6, 0x5b, 0x36, 0x3f, 0xb5, 0x47, 0x6c, 0xfd, 0x3f, 0x4e, 0x7e,
+	0x02, 0xa5, 0x80, 0x23, 0xe3, 0xc4, 0xb2, 0x53, 0xc5, 0xd3, 0xe9, 0xd2, 0xf6, 0xbb, 0x62, 0x25,
+	0xdf, 0x2a, 0xd0, 0xbf, 0xe5, 0xa0, 0xa9, 0xa2, 0xf2, 0x74, 0x0f, 0xa1, 0x6c, 0xa1, 0x2c, 0xba,
+	0x4c, 0xad, 0xff, 0x5c, 0x02, 0xa8, 0x5e, 0x3d, 0x29, 0x9c, 0xb9, 0x51, 0x30, 0xd7, 0xe3, 0x01,
+	0xda, 0x21, 0xd4, 0x55, 0x03, 0x4f, 0x63, 0xc2, 0xe6, 0x32, 0x3c, 0xfe, 0xc9, 0xd3, 0x15, 0xd5,
+	0x20, 0x52, 0xab, 0xeb, 0x28, 0x1c, 0xe6, 0xbf, 0xca, 0xd1, 0x0f, 0xb0, 0x77, 0xcc, 0x73, 0xbf,
+	0x09, 0x0c, 0x37, 0x34, 0xcc, 0x88, 0xf7, 0x55, 0x99, 0xe6, 0xa7, 0x00, 0x58, 0x2f, 0x4a, 0xb2,
+	0x55, 0xa1, 0x79, 0xc7, 0x33, 0x7e, 0xca, 0xdb, 0xa8, 0x61, 0x0d, 0x3c, 0xd7, 0x99, 0xcb, 0x36,
+	0x5a, 0xe1, 0x8a, 0x2b, 0xd7, 0x99, 0xd3, 0xbf, 0xc0, 0xe3, 0x65, 0x58, 0x9e, 0x67, 0x07, 0xb6,
+	0xa2, 0x8f, 0x03, 0xdb, 0x92, 0xd5, 0x5d, 0x8c, 0x3e, 0x5e, 0x58, 0x0f, 0x3f, 0x26, 0xc8, 0x67,
+	0x50, 0x36, 0x7c, 0xdf, 0xb1, 0x99, 0xd5, 0x2d, 0xac, 0x70, 0x8c, 0x8d, 0xf4, 0x8f, 0xd0, 0x3d,
+	0xf1, 0xa6, 0x53, 0x3b, 0x5a, 0x91, 0xd7, 0xf7, 0x0b, 0x81, 0x1e, 0xc3, 0xee, 0x0a, 0xe8, 0xcd,
+	0xce, 0xab, 0x9f, 0x83, 0xa6, 0x7b, 0x8e, 0x33, 0x34, 0xcc, 0xc9, 0x03, 0x03, 0xa4, 0x17, 0x00,
+	0x47, 0x96, 0xb5, 0x36, 0x07, 0xb9, 0xef, 0xf9, 0x15, 0xfb, 0x5e, 0x50, 0xf6, 0x9d, 0xfe, 0x12,
+	0xb6, 0xf1, 0xac, 0xdd, 0x0c, 0x8d, 0x5e, 0xc2, 0xf6, 0x07, 0xdf, 0x32, 0x22, 0xf6, 0x83, 0x44,
+	0xf1, 0x0a, 0xe0, 0x9c, 0x45, 0x1b, 0x86, 0xf0, 0x63, 0xa8, 0x89, 0x41, 0xa1, 0xef, 0xb9, 0x21,
+	0x4b, 0x91, 0x73, 0x2a, 0x32, 0x85, 0x1a, 0x3f, 0xc2, 0xd7, 0x2e, 0xe7, 0x5f, 0xa1, 0x8e, 0x3e,
+	0x12, 0xe9, 0x35, 0x94, 0xc4, 0xe0, 0xf8, 0xf7, 0xfb, 0x84, 0xef, 0x9c, 0xea, 0xd1, 0xfb, 0x46,
+	0x98, 0xf1, 0xd7, 0x93, 0xbe, 0xda, 0xd7, 0x50, 0x53, 0xd4, 0x1b, 0xfd, 0x78, 0x4d, 0xd8, 0x3e,
+	0x36, 0xcc, 0xc9, 0xcc, 0x97, 0x61, 0xd2, 0x37, 0x50, 0x8b, 0x15, 0xbc, 0x98, 0x08, 0x14, 0x2d,
+	0x23, 0x32, 0x64, 0x66, 0xe2, 0x3b, 0xd3, 0x13, 0xf3, 0xd9, 0x9e, 0x48, 0x7f, 0x03, 0x0d, 0x9d,
+	0xf1, 0xc6, 0xac, 0x36, 0xe2, 0x8d, 0x10, 0x5a, 0xd0, 0x38, 0xf1, 0xa6, 0xbe, 0x61, 0xc6, 0x2b,
+	0x47, 0xff, 0x97, 0x03, 0x38, 0x9a, 0x59, 0x76, 0x84, 0xe9, 0x11, 0x28, 0x46, 0xf6, 0x94, 0x25,
+	0xeb, 0x68, 0x4f, 0x19, 0x07, 0xb4, 0x2d, 0xe6, 0x46, 0x76, 0x14, 0xef, 0x53, 0x22, 0x73, 0x7f,
+	0x9f, 0xb1, 0x40, 0x6c, 0x7b, 0x55, 0x17, 0xdf, 0x9c, 0x7c, 0x79, 0x3e, 0x0b, 0x0c, 0x5e, 0xef,
+	0xe2, 0x08, 0xaa, 0xea, 0xa9, 0x82, 0x2f, 0x97, 0x68, 0x30, 0xe2, 0xe8, 0xa9, 0xea, 0x28, 0xc4,
+	0xcb, 0x5a, 0x4a, 0x97, 0x35, 0x7b, 0xde, 0x95, 0x17, 0xcf, 0xbb, 0xc4, 0x3c, 0x36, 0xc2, 0xb1,
+	0xe0, 0x09, 0x55, 0x69, 0xfe, 0xad, 0x11, 0x8e, 0x39, 0x1e, 0x0b, 0x82, 0x6e, 0x55, 0xb6, 0xf9,
+	0x20, 0xa0, 0x13, 0x68, 0xff, 0x61, 0xc6, 0x82, 0xb9, 0x48, 0x36, 0x5e, 0xbf, 0x1d, 0xd8, 0x0a,
+	0x6d, 0xd7, 0x8c, 0xf3, 0x45, 0x81, 0x6b, 0x67, 0x6e, 0x64, 0x3b, 0xf2, 0x08, 0x46, 0x21, 0x0d,
+	0xbc, 0xa0, 0x06, 0xbe, 0x03, 0x5b, 0x8e, 0x3d, 0xb5, 0xe3, 0xb3, 0x16, 0x05, 0xfa, 0x2b, 0x68,
+	0xaa, 0x93, 0x61, 0xe7, 0x28, 0x33, 0x37, 0x0a, 0xec, 0xa4, 0xfc, 0x1a, 0xbc, 0xfc, 0xd2, 0xa5,
+	0xd7, 0x63, 0x33, 0xed, 0xc3, 0x9e, 0xa0, 0xa7, 0x69, 0xd7, 0x48, 0x8e, 0xa5, 0x3d, 0x28, 0x4f,
+	0x6d, 0x77, 0x60, 0x8c, 0xe2, 0x88, 0x4b, 0x53, 0xdb, 0x3d, 0x1a, 0x31, 0xfa, 0xaf, 0x1c, 0x34,
+	0x95, 0x01, 0x17, 0xee, 0xad, 0xb7, 0xfa, 0x7f, 0x4b, 0xb2, 0xc8, 0xab, 0x59, 0xac, 0xda, 0x46,
+	0x75, 0xdb, 0x8b, 0x0b, 0xdb, 0x9e, 0x9c, 0xad, 0xc8, 0xfa, 0xd2, 0xb3, 0x95, 0x47, 0x86, 0xa4,
+	0x8f, 0x7f, 0x72, 0x3e, 0xe3, 0xf9, 0x92, 0x8c, 0xe0, 0x16, 0x96, 0x3d, 0x5f, 0x90, 0x10, 0xfa,
+	0x1e, 0x1e, 0x2f, 0x67, 0xc9, 0x17, 0xea, 0x4b, 0xa8, 0x47, 0x8a, 0x52, 0xae, 0x56, 0x87, 0xaf,
+	0xd6, 0x42, 0x86, 0x7a, 0xc6, 0x91, 0xf6, 0x60, 0xef, 0x68, 0xe8, 0x05, 0x0f, 0x3d, 0x0f, 0xe8,
+	0x3f, 0x72, 0xd0, 0x38, 0x36, 0x22, 0x73, 0x7c, 0x95, 0x14, 0xe7, 0x0b, 0x28, 0x46, 0x73, 0x1f,
+	0x17, 0xb7, 0xd1, 0xdf, 0xe3, 0x73, 0x66, 0x3d, 0x7a, 0x37, 0x73, 0x9f, 0xe9, 0xc2, 0xe9, 0xc1,
+	0x5d, 0xf0, 0x10, 0x8a, 0x7c, 0x14, 0x29, 0x43, 0xe1, 0xfc, 0xec, 0xa6, 0xf5, 0x88, 0x7f, 0x1c,
+	0x9d, 0x9e, 0xb6, 0x72, 0x04, 0xa0, 0xf4, 0xe1, 0xfd, 0xe9, 0xd1, 0xcd, 0x59, 0x2b, 0xcf, 0xbf,
+	0x4f, 0xcf, 0x2e, 0xcf, 0x6e, 0xce, 0x5a, 0x05, 0x52, 0x81, 0xe2, 0xe5, 0xc5, 0xf5, 0x4d, 0xab,
+	0x48, 0xff, 0x9e, 0x83, 0xba, 0x88, 0xe0, 0x81, 0x27, 0x76, 0x1f, 0x20, 0xf9, 0xd5, 0x90, 0x3b,
+	0xd5, 0xfa, 0x64, 0x39, 0x0d, 0x5d, 0xf1, 0xca, 0x9c, 0x69, 0x85, 0xb5, 0x67, 0xda, 0x3f, 0x73,
+	0x50, 0x93, 0xd1, 0x84, 0x33, 0x27, 0x5a, 0xdd, 0x9b, 0xb9, 0xf6, 0x96, 0x53, 0x32, 0xc9, 0x18,
+	0x50, 0x20, 0xaf, 0x92, 0xee, 0x8b, 0xec, 0xe9, 0x69, 0x12, 0x15, 0x82, 0xfd, 0xd0, 0xcd, 0xd7,
+	0x00, 0x90, 0xe8, 0xbc, 0xa8, 0x7e, 0x0a, 0xe5, 0x40, 0x4c, 0x13, 0xd7, 0x53, 0x73, 0x61, 0x7a,
+	0x3d, 0xb6, 0x6f, 0x40, 0x13, 0xda, 0xd0, 0x4c, 0xb4, 0xb2, 0x9d, 0x1a, 0xb0, 0x9d, 0xaa, 0x36,
+	0x22, 0x0c, 0x2a, 0xef, 0xc9, 0xaf, 0xe1, 0x3d, 0xfd, 0xff, 0xd6, 0xa0, 0xf0, 0xf6, 0x9b, 0x6b,
+	0xf2, 0x1a, 0x2a, 0xc9, 0x53, 0x84, 0xf8, 0x3b, 0x16, 0x1e, 0x32, 0xb4, 0x76, 0x56, 0xe9, 0x3b,
+	0x73, 0xfa, 0x88, 0x7c, 0x09, 0x65, 0xf9, 0x66, 0x40, 0x44, 0x5d, 0x64, 0x1f, 0x10, 0xb4, 0xdd,
+	0x1e, 0xbe, 0xb3, 0xf4, 0xe2, 0x77, 0x96, 0xde, 0x19, 0x7f, 0x67, 0xa1, 0x8f, 0xc8, 0x05, 0xb4,
+	0x16, 0x1f, 0x10, 0x88, 0xd8, 0xc3, 0x7b, 0x9e, 0x15, 0xd6, 0x40, 0xbd, 0x81, 0x9a, 0xf2, 0x10,
+	0x40, 0x76, 0x31, 0x8e, 0xc5, 0xd7, 0x06, 0x6d, 0x67, 0x49, 0x8f, 0x29, 0x1c, 0x02, 0xa4, 0xd7,
+	0x77, 0xf2, 0x38, 0x3e, 0xc5, 0x33, 0x2f, 0x03, 0x5a, 0x67, 0x51, 0x8d, 0x63, 0xdf, 0x40, 0x4d,
+	0xb9, 0xa7, 0xe3, 0xd4, 0xcb, 0x57, 0x7c, 0x6d, 0x67, 0x49, 0x8f, 0xc3, 0x5f, 0xc0, 0x16, 0x5e,
+	0xc7, 0xe5, 0x8d, 0x29, 0xbd, 0xc5, 0x6b, 0x0d, 0x45, 0x93, 0xc4, 0x99, 0xde, 0x78, 0x30, 0xce,
+	0xa5, 0xab, 0x94, 0xd6, 0x59, 0x54, 0x27, 0x63, 0xd3, 0x8b, 0x01, 0x8e, 0x5d, 0xba, 0xa4, 0x68,
+	0x9d, 0x45, 0x35, 0x8e, 0xbd, 0x84, 0xd6, 0x22, 0x31, 0xc7, 0x9d, 0xba, 0xe7, 0x16, 0xa0, 0x3d,
+	0x59, 0x6d, 0x44, 0xb4, 0x2b, 0x68, 0x2f, 0x71, 0x61, 0x22, 0xa8, 0xd3, 0x7d, 0xec, 0x5b, 0xd3,
+	0xee, 0xb1, 0xc6, 0x80, 0x9d, 0x15, 0xc4, 0x98, 0x3c, 0x13, 0xd5, 0x7e, 0x2f, 0x63, 0x5e, 0x53,
+	0x4e, 0xfb, 0x50, 0x38, 0x67, 0x11, 0x11, 0x1b, 0x90, 0xd2, 0x4d, 0xad, 0x99, 0xc8, 0xc8, 0xee,
+	0xc4, 0xf6, 0x15, 0x79, 0x49, 0x90, 0x66, 0xca, 0xfc, 0xd0, 0xb7, 0xb5, 0x48, 0x05, 0xe9, 0x23,
+	0xf2, 0x12, 0x0a, 0x47, 0x96, 0x85, 0xb0, 0x29, 0x2d, 0x5f, 0x13, 0xc7, 0x2f, 0xa0, 0x84, 0xd5,
+	0x4a, 0xda, 0x69, 0xe5, 0x3e, 0x68, 0x18, 0x52, 0x6e, 0x1c, 0x96, 0xa1, 0xdf, 0x6b, 0x86, 0xbd,
+	0x80, 0x2d, 0xd1, 0xbe, 0xb0, 0x14, 0xd5, 0x33, 0x42, 0x6b, 0x28, 0x1a, 0x5c, 0xf3, 0x2f, 0xa0,
+	0x84, 0xc4, 0x13, 0xe7, 0xc8, 0xb0, 0x52, 0xad, 0xa9, 0xaa, 0x84, 0xff, 0x17, 0x39, 0xf2, 0x35,
+	0x94, 0x25, 0xd7, 0xc4, 0x3e, 0x91, 0x25, 0x9e, 0xf7, 0xc7, 0xb5, 0x9f, 0xe3, 0x2d, 0x46, 0x92,
+	0x4c, 0x1c, 0x9a, 0x65, 0x9c, 0x6b, 0x52, 0x3a, 0x04, 0x48, 0x59, 0x13, 0x16, 0xfd, 0x12, 0x65,
+	0xd3, 0x3a, 0x8b, 0xea, 0xa4, 0xe8, 0x17, 0xe9, 0x04, 0x16, 0xfd, 0x3d, 0x54, 0x4a, 0x7b, 0xb2,
+	0xda, 0x88, 0x68, 0x17, 0xd0, 0x5a, 0xa4, 0x12, 0x88, 0x76, 0x0f, 0xc1, 0x58, 0x93, 0xd4, 0x6b,
+	0xe5, 0x6d, 0xba, 0x93, 0xe9, 0xe8, 0x6a, 0x9b, 0xce, 0x1c, 0x1a, 0xf4, 0xd1, 0xb0, 0x24, 0x70,
+	0x5e, 0xfd, 0x7f, 0x00, 0x4c, 0xa1, 0x39, 0x89, 0x0b, 0x17, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -759,15 +2639,28 @@ type KVSClient interface {
 	Checksum(ctx context.Context, in *ChecksumRequest, opts ...grpc.CallOption) (*ChecksumReply, error)
 	Destroy(ctx context.Context, in *DestroyRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	CreateOrGetTable(ctx context.Context, in *CreateOrGetTableRequest, opts ...grpc.CallOption) (*empty.Empty, error)
-	DeleteTable(ctx context.Context, in *DeleteTableRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	DeleteTable(ctx context.Context, in *DeleteTableRequest, opts ...grpc.CallOption) (*DeleteTableReply, error)
+	ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesReply, error)
+	TableExists(ctx context.Context, in *TableExistsRequest, opts ...grpc.CallOption) (*TableExistsReply, error)
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsReply, error)
+	MerkleTree(ctx context.Context, in *MerkleTreeRequest, opts ...grpc.CallOption) (*MerkleTreeReply, error)
+	KeyDigests(ctx context.Context, in *KeyDigestsRequest, opts ...grpc.CallOption) (*KeyDigestsReply, error)
 	BeginTransaction(ctx context.Context, in *BeginTransactionRequest, opts ...grpc.CallOption) (*BeginTransactionReply, error)
-	CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionReply, error)
 	RollbackTransaction(ctx context.Context, in *RollbackTransactionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
 	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
 	Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error)
 	Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchReply, error)
+	Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (KVS_BackupClient, error)
+	Restore(ctx context.Context, opts ...grpc.CallOption) (KVS_RestoreClient, error)
+	Compact(ctx context.Context, in *CompactRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	QueryAudit(ctx context.Context, in *QueryAuditRequest, opts ...grpc.CallOption) (*QueryAuditReply, error)
+	ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsReply, error)
+	AbortTransaction(ctx context.Context, in *AbortTransactionRequest, opts ...grpc.CallOption) (*empty.Empty, error)
+	Revision(ctx context.Context, in *RevisionRequest, opts ...grpc.CallOption) (*RevisionReply, error)
 }
 
 type kVSClient struct {
@@ -789,97 +2682,262 @@ func (c *kVSClient) Checksum(ctx context.Context, in *ChecksumRequest, opts ...g
 
 func (c *kVSClient) Destroy(ctx context.Context, in *DestroyRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/Destroy", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Destroy", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) CreateOrGetTable(ctx context.Context, in *CreateOrGetTableRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.KVS/CreateOrGetTable", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) DeleteTable(ctx context.Context, in *DeleteTableRequest, opts ...grpc.CallOption) (*DeleteTableReply, error) {
+	out := new(DeleteTableReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/DeleteTable", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) ListTables(ctx context.Context, in *ListTablesRequest, opts ...grpc.CallOption) (*ListTablesReply, error) {
+	out := new(ListTablesReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/ListTables", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) TableExists(ctx context.Context, in *TableExistsRequest, opts ...grpc.CallOption) (*TableExistsReply, error) {
+	out := new(TableExistsReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/TableExists", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsReply, error) {
+	out := new(StatsReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) MerkleTree(ctx context.Context, in *MerkleTreeRequest, opts ...grpc.CallOption) (*MerkleTreeReply, error) {
+	out := new(MerkleTreeReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/MerkleTree", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) KeyDigests(ctx context.Context, in *KeyDigestsRequest, opts ...grpc.CallOption) (*KeyDigestsReply, error) {
+	out := new(KeyDigestsReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/KeyDigests", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) BeginTransaction(ctx context.Context, in *BeginTransactionRequest, opts ...grpc.CallOption) (*BeginTransactionReply, error) {
+	out := new(BeginTransactionReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/BeginTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*CommitTransactionReply, error) {
+	out := new(CommitTransactionReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/CommitTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) RollbackTransaction(ctx context.Context, in *RollbackTransactionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.KVS/RollbackTransaction", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Get", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, "/pb.KVS/List", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Add", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Delete", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *kVSClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Update", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *kVSClient) CreateOrGetTable(ctx context.Context, in *CreateOrGetTableRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/CreateOrGetTable", in, out, opts...)
+func (c *kVSClient) Batch(ctx context.Context, in *BatchRequest, opts ...grpc.CallOption) (*BatchReply, error) {
+	out := new(BatchReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Batch", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *kVSClient) DeleteTable(ctx context.Context, in *DeleteTableRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/DeleteTable", in, out, opts...)
+func (c *kVSClient) Backup(ctx context.Context, in *BackupRequest, opts ...grpc.CallOption) (KVS_BackupClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_KVS_serviceDesc.Streams[0], "/pb.KVS/Backup", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	x := &kVSBackupClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
 }
 
-func (c *kVSClient) BeginTransaction(ctx context.Context, in *BeginTransactionRequest, opts ...grpc.CallOption) (*BeginTransactionReply, error) {
-	out := new(BeginTransactionReply)
-	err := c.cc.Invoke(ctx, "/pb.KVS/BeginTransaction", in, out, opts...)
-	if err != nil {
+type KVS_BackupClient interface {
+	Recv() (*BackupReply, error)
+	grpc.ClientStream
+}
+
+type kVSBackupClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVSBackupClient) Recv() (*BackupReply, error) {
+	m := new(BackupReply)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
 		return nil, err
 	}
-	return out, nil
+	return m, nil
 }
 
-func (c *kVSClient) CommitTransaction(ctx context.Context, in *CommitTransactionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/CommitTransaction", in, out, opts...)
+func (c *kVSClient) Restore(ctx context.Context, opts ...grpc.CallOption) (KVS_RestoreClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_KVS_serviceDesc.Streams[1], "/pb.KVS/Restore", opts...)
 	if err != nil {
 		return nil, err
 	}
-	return out, nil
+	x := &kVSRestoreClient{stream}
+	return x, nil
 }
 
-func (c *kVSClient) RollbackTransaction(ctx context.Context, in *RollbackTransactionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/RollbackTransaction", in, out, opts...)
-	if err != nil {
+type KVS_RestoreClient interface {
+	Send(*RestoreRequest) error
+	CloseAndRecv() (*empty.Empty, error)
+	grpc.ClientStream
+}
+
+type kVSRestoreClient struct {
+	grpc.ClientStream
+}
+
+func (x *kVSRestoreClient) Send(m *RestoreRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *kVSRestoreClient) CloseAndRecv() (*empty.Empty, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
 		return nil, err
 	}
-	return out, nil
+	m := new(empty.Empty)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
 }
 
-func (c *kVSClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
-	out := new(GetResponse)
-	err := c.cc.Invoke(ctx, "/pb.KVS/Get", in, out, opts...)
+func (c *kVSClient) Compact(ctx context.Context, in *CompactRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+	out := new(empty.Empty)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Compact", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *kVSClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
-	out := new(ListResponse)
-	err := c.cc.Invoke(ctx, "/pb.KVS/List", in, out, opts...)
+func (c *kVSClient) QueryAudit(ctx context.Context, in *QueryAuditRequest, opts ...grpc.CallOption) (*QueryAuditReply, error) {
+	out := new(QueryAuditReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/QueryAudit", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *kVSClient) Add(ctx context.Context, in *AddRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/Add", in, out, opts...)
+func (c *kVSClient) ListTransactions(ctx context.Context, in *ListTransactionsRequest, opts ...grpc.CallOption) (*ListTransactionsReply, error) {
+	out := new(ListTransactionsReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/ListTransactions", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *kVSClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
+func (c *kVSClient) AbortTransaction(ctx context.Context, in *AbortTransactionRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
 	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/Delete", in, out, opts...)
+	err := c.cc.Invoke(ctx, "/pb.KVS/AbortTransaction", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
 	return out, nil
 }
 
-func (c *kVSClient) Update(ctx context.Context, in *UpdateRequest, opts ...grpc.CallOption) (*empty.Empty, error) {
-	out := new(empty.Empty)
-	err := c.cc.Invoke(ctx, "/pb.KVS/Update", in, out, opts...)
+func (c *kVSClient) Revision(ctx context.Context, in *RevisionRequest, opts ...grpc.CallOption) (*RevisionReply, error) {
+	out := new(RevisionReply)
+	err := c.cc.Invoke(ctx, "/pb.KVS/Revision", in, out, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -891,15 +2949,28 @@ type KVSServer interface {
 	Checksum(context.Context, *ChecksumRequest) (*ChecksumReply, error)
 	Destroy(context.Context, *DestroyRequest) (*empty.Empty, error)
 	CreateOrGetTable(context.Context, *CreateOrGetTableRequest) (*empty.Empty, error)
-	DeleteTable(context.Context, *DeleteTableRequest) (*empty.Empty, error)
+	DeleteTable(context.Context, *DeleteTableRequest) (*DeleteTableReply, error)
+	ListTables(context.Context, *ListTablesRequest) (*ListTablesReply, error)
+	TableExists(context.Context, *TableExistsRequest) (*TableExistsReply, error)
+	Stats(context.Context, *StatsRequest) (*StatsReply, error)
+	MerkleTree(context.Context, *MerkleTreeRequest) (*MerkleTreeReply, error)
+	KeyDigests(context.Context, *KeyDigestsRequest) (*KeyDigestsReply, error)
 	BeginTransaction(context.Context, *BeginTransactionRequest) (*BeginTransactionReply, error)
-	CommitTransaction(context.Context, *CommitTransactionRequest) (*empty.Empty, error)
+	CommitTransaction(context.Context, *CommitTransactionRequest) (*CommitTransactionReply, error)
 	RollbackTransaction(context.Context, *RollbackTransactionRequest) (*empty.Empty, error)
 	Get(context.Context, *GetRequest) (*GetResponse, error)
 	List(context.Context, *ListRequest) (*ListResponse, error)
 	Add(context.Context, *AddRequest) (*empty.Empty, error)
 	Delete(context.Context, *DeleteRequest) (*empty.Empty, error)
 	Update(context.Context, *UpdateRequest) (*empty.Empty, error)
+	Batch(context.Context, *BatchRequest) (*BatchReply, error)
+	Backup(*BackupRequest, KVS_BackupServer) error
+	Restore(KVS_RestoreServer) error
+	Compact(context.Context, *CompactRequest) (*empty.Empty, error)
+	QueryAudit(context.Context, *QueryAuditRequest) (*QueryAuditReply, error)
+	ListTransactions(context.Context, *ListTransactionsRequest) (*ListTransactionsReply, error)
+	AbortTransaction(context.Context, *AbortTransactionRequest) (*empty.Empty, error)
+	Revision(context.Context, *RevisionRequest) (*RevisionReply, error)
 }
 
 // UnimplementedKVSServer can be embedded to have forward compatible implementations.
@@ -915,13 +2986,28 @@ func (*UnimplementedKVSServer) Destroy(ctx context.Context, req *DestroyRequest)
 func (*UnimplementedKVSServer) CreateOrGetTable(ctx context.Context, req *CreateOrGetTableRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CreateOrGetTable not implemented")
 }
-func (*UnimplementedKVSServer) DeleteTable(ctx context.Context, req *DeleteTableRequest) (*empty.Empty, error) {
+func (*UnimplementedKVSServer) DeleteTable(ctx context.Context, req *DeleteTableRequest) (*DeleteTableReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteTable not implemented")
 }
+func (*UnimplementedKVSServer) ListTables(ctx context.Context, req *ListTablesRequest) (*ListTablesReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTables not implemented")
+}
+func (*UnimplementedKVSServer) TableExists(ctx context.Context, req *TableExistsRequest) (*TableExistsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TableExists not implemented")
+}
+func (*UnimplementedKVSServer) Stats(ctx context.Context, req *StatsRequest) (*StatsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (*UnimplementedKVSServer) MerkleTree(ctx context.Context, req *MerkleTreeRequest) (*MerkleTreeReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MerkleTree not implemented")
+}
+func (*UnimplementedKVSServer) KeyDigests(ctx context.Context, req *KeyDigestsRequest) (*KeyDigestsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method KeyDigests not implemented")
+}
 func (*UnimplementedKVSServer) BeginTransaction(ctx context.Context, req *BeginTransactionRequest) (*BeginTransactionReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BeginTransaction not implemented")
 }
-func (*UnimplementedKVSServer) CommitTransaction(ctx context.Context, req *CommitTransactionRequest) (*empty.Empty, error) {
+func (*UnimplementedKVSServer) CommitTransaction(ctx context.Context, req *CommitTransactionRequest) (*CommitTransactionReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CommitTransaction not implemented")
 }
 func (*UnimplementedKVSServer) RollbackTransaction(ctx context.Context, req *RollbackTransactionRequest) (*empty.Empty, error) {
@@ -942,6 +3028,30 @@ func (*UnimplementedKVSServer) Delete(ctx context.Context, req *DeleteRequest) (
 func (*UnimplementedKVSServer) Update(ctx context.Context, req *UpdateRequest) (*empty.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Update not implemented")
 }
+func (*UnimplementedKVSServer) Batch(ctx context.Context, req *BatchRequest) (*BatchReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Batch not implemented")
+}
+func (*UnimplementedKVSServer) Backup(req *BackupRequest, srv KVS_BackupServer) error {
+	return status.Errorf(codes.Unimplemented, "method Backup not implemented")
+}
+func (*UnimplementedKVSServer) Restore(srv KVS_RestoreServer) error {
+	return status.Errorf(codes.Unimplemented, "method Restore not implemented")
+}
+func (*UnimplementedKVSServer) Compact(ctx context.Context, req *CompactRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Compact not implemented")
+}
+func (*UnimplementedKVSServer) QueryAudit(ctx context.Context, req *QueryAuditRequest) (*QueryAuditReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryAudit not implemented")
+}
+func (*UnimplementedKVSServer) ListTransactions(ctx context.Context, req *ListTransactionsRequest) (*ListTransactionsReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTransactions not implemented")
+}
+func (*UnimplementedKVSServer) AbortTransaction(ctx context.Context, req *AbortTransactionRequest) (*empty.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AbortTransaction not implemented")
+}
+func (*UnimplementedKVSServer) Revision(ctx context.Context, req *RevisionRequest) (*RevisionReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Revision not implemented")
+}
 
 func RegisterKVSServer(s *grpc.Server, srv KVSServer) {
 	s.RegisterService(&_KVS_serviceDesc, srv)
@@ -1019,6 +3129,96 @@ func _KVS_DeleteTable_Handler(srv interface{}, ctx context.Context, dec func(int
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KVS_ListTables_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTablesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).ListTables(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/ListTables",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).ListTables(ctx, req.(*ListTablesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_TableExists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TableExistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).TableExists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/TableExists",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).TableExists(ctx, req.(*TableExistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_MerkleTree_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MerkleTreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).MerkleTree(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/MerkleTree",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).MerkleTree(ctx, req.(*MerkleTreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_KeyDigests_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyDigestsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).KeyDigests(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/KeyDigests",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).KeyDigests(ctx, req.(*KeyDigestsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _KVS_BeginTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(BeginTransactionRequest)
 	if err := dec(in); err != nil {
@@ -1163,6 +3363,161 @@ func _KVS_Update_Handler(srv interface{}, ctx context.Context, dec func(interfac
 	return interceptor(ctx, in, info, handler)
 }
 
+func _KVS_Batch_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).Batch(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/Batch",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).Batch(ctx, req.(*BatchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_Backup_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(BackupRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(KVSServer).Backup(m, &kVSBackupServer{stream})
+}
+
+type KVS_BackupServer interface {
+	Send(*BackupReply) error
+	grpc.ServerStream
+}
+
+type kVSBackupServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVSBackupServer) Send(m *BackupReply) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _KVS_Restore_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(KVSServer).Restore(&kVSRestoreServer{stream})
+}
+
+type KVS_RestoreServer interface {
+	SendAndClose(*empty.Empty) error
+	Recv() (*RestoreRequest, error)
+	grpc.ServerStream
+}
+
+type kVSRestoreServer struct {
+	grpc.ServerStream
+}
+
+func (x *kVSRestoreServer) SendAndClose(m *empty.Empty) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *kVSRestoreServer) Recv() (*RestoreRequest, error) {
+	m := new(RestoreRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _KVS_Compact_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompactRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).Compact(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/Compact",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).Compact(ctx, req.(*CompactRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_QueryAudit_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryAuditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).QueryAudit(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/QueryAudit",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).QueryAudit(ctx, req.(*QueryAuditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_ListTransactions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTransactionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).ListTransactions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/ListTransactions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).ListTransactions(ctx, req.(*ListTransactionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_AbortTransaction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AbortTransactionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).AbortTransaction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/AbortTransaction",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).AbortTransaction(ctx, req.(*AbortTransactionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _KVS_Revision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(KVSServer).Revision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/pb.KVS/Revision",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(KVSServer).Revision(ctx, req.(*RevisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _KVS_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "pb.KVS",
 	HandlerType: (*KVSServer)(nil),
@@ -1183,6 +3538,26 @@ var _KVS_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteTable",
 			Handler:    _KVS_DeleteTable_Handler,
 		},
+		{
+			MethodName: "ListTables",
+			Handler:    _KVS_ListTables_Handler,
+		},
+		{
+			MethodName: "TableExists",
+			Handler:    _KVS_TableExists_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _KVS_Stats_Handler,
+		},
+		{
+			MethodName: "MerkleTree",
+			Handler:    _KVS_MerkleTree_Handler,
+		},
+		{
+			MethodName: "KeyDigests",
+			Handler:    _KVS_KeyDigests_Handler,
+		},
 		{
 			MethodName: "BeginTransaction",
 			Handler:    _KVS_BeginTransaction_Handler,
@@ -1215,7 +3590,42 @@ var _KVS_serviceDesc = grpc.ServiceDesc{
 			MethodName: "Update",
 			Handler:    _KVS_Update_Handler,
 		},
+		{
+			MethodName: "Batch",
+			Handler:    _KVS_Batch_Handler,
+		},
+		{
+			MethodName: "Compact",
+			Handler:    _KVS_Compact_Handler,
+		},
+		{
+			MethodName: "QueryAudit",
+			Handler:    _KVS_QueryAudit_Handler,
+		},
+		{
+			MethodName: "ListTransactions",
+			Handler:    _KVS_ListTransactions_Handler,
+		},
+		{
+			MethodName: "AbortTransaction",
+			Handler:    _KVS_AbortTransaction_Handler,
+		},
+		{
+			MethodName: "Revision",
+			Handler:    _KVS_Revision_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Backup",
+			Handler:       _KVS_Backup_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Restore",
+			Handler:       _KVS_Restore_Handler,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "kvserver.proto",
 }
@@ -0,0 +1,500 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+// AuditEntry records who changed which key or called a destructive method,
+// value is recorded as its size and hash
+type AuditEntry struct {
+	Time      time.Time `json:"time"`
+	Identity  string    `json:"identity,omitempty"`
+	Peer      string    `json:"peer,omitempty"`
+	Operation string    `json:"operation"`
+	Table     string    `json:"table"`
+	Key       string    `json:"key,omitempty"`
+	ValueSize int       `json:"valueSize,omitempty"`
+	ValueHash string    `json:"valueHash,omitempty"`
+	//empty means the call succeeded
+	Err string `json:"err,omitempty"`
+}
+
+type Auditor interface {
+	//entries of a committed transaction are recorded together
+	Audit(entries ...AuditEntry) error
+}
+
+// AuditFilter selects entries in [Since, Until) of Table and its sub
+// tables, zero value of each field means no limit
+type AuditFilter struct {
+	Since time.Time
+	Until time.Time
+	Table string
+	//only the newest Limit entries are returned
+	Limit int
+}
+
+// QueryableAuditor could be queried through QueryAudit
+type QueryableAuditor interface {
+	Auditor
+	Query(filter AuditFilter) ([]AuditEntry, error)
+}
+
+func (f *AuditFilter) match(e *AuditEntry) bool {
+	if f.Since.IsZero() == false && e.Time.Before(f.Since) {
+		return false
+	}
+	if f.Until.IsZero() == false && e.Time.Before(f.Until) == false {
+		return false
+	}
+	return f.Table == "" || f.Table == kvzoo.Root || f.Table == e.Table || strings.HasPrefix(e.Table, f.Table+"/")
+}
+
+// entries are sorted by time, only the newest Limit entries are kept
+func (f *AuditFilter) limit(entries []AuditEntry) []AuditEntry {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	if f.Limit > 0 && len(entries) > f.Limit {
+		entries = entries[len(entries)-f.Limit:]
+	}
+	return entries
+}
+
+// logAuditor writes entries into server log, it's used by default and only
+// records destructive calls
+type logAuditor struct{}
+
+func (a logAuditor) Audit(entries ...AuditEntry) error {
+	for _, e := range entries {
+		if e.Err == "" {
+			log.Infof("audit: %s from %s %s %s succeed", e.Identity, e.Peer, e.Operation, e.Table)
+		} else {
+			log.Warnf("audit: %s from %s %s %s failed:%s", e.Identity, e.Peer, e.Operation, e.Table, e.Err)
+		}
+	}
+	return nil
+}
+
+// FileAuditor writes entries into file in json lines, file is rotated once
+// its size exceeds maxSize, and at most maxBackups rotated files named
+// path.1, path.2 ... are kept, path.1 is the newest
+type FileAuditor struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+
+	lock sync.Mutex
+	file *os.File
+	size int64
+}
+
+func NewFileAuditor(path string, maxSize int64, maxBackups int) (*FileAuditor, error) {
+	a := &FileAuditor{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := a.open(); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func (a *FileAuditor) open() error {
+	f, err := os.OpenFile(a.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	a.file = f
+	a.size = info.Size()
+	return nil
+}
+
+func (a *FileAuditor) Audit(entries ...AuditEntry) error {
+	var buf []byte
+	for _, e := range entries {
+		line, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.file == nil {
+		return fmt.Errorf("audit file %s is closed", a.path)
+	}
+	n, err := a.file.Write(buf)
+	a.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	if a.maxSize > 0 && a.size >= a.maxSize {
+		return a.rotate()
+	}
+	return nil
+}
+
+func (a *FileAuditor) backupPath(i int) string {
+	return fmt.Sprintf("%s.%d", a.path, i)
+}
+
+func (a *FileAuditor) rotate() error {
+	if err := a.file.Close(); err != nil {
+		return err
+	}
+	a.file = nil
+
+	if a.maxBackups <= 0 {
+		os.Remove(a.path)
+	} else {
+		os.Remove(a.backupPath(a.maxBackups))
+		for i := a.maxBackups - 1; i > 0; i-- {
+			os.Rename(a.backupPath(i), a.backupPath(i+1))
+		}
+		if err := os.Rename(a.path, a.backupPath(1)); err != nil {
+			return err
+		}
+	}
+	return a.open()
+}
+
+func (a *FileAuditor) Query(filter AuditFilter) ([]AuditEntry, error) {
+	files, err := a.openFiles()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+
+	var entries []AuditEntry
+	for _, f := range files {
+		if err := readAuditFile(f, &filter, &entries); err != nil {
+			return nil, err
+		}
+	}
+	return filter.limit(entries), nil
+}
+
+// openFiles opens the files from the oldest to the newest with lock held,
+// opened files are still readable after rotation, so they are read
+// without lock and writers aren't blocked by query
+func (a *FileAuditor) openFiles() ([]*os.File, error) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	var files []*os.File
+	for i := a.maxBackups; i >= 0; i-- {
+		path := a.path
+		if i > 0 {
+			path = a.backupPath(i)
+		}
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			for _, f := range files {
+				f.Close()
+			}
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	return files, nil
+}
+
+func readAuditFile(f *os.File, filter *AuditFilter, entries *[]AuditEntry) error {
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1024*1024)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("parse audit file %s failed:%s", f.Name(), err.Error())
+		}
+		if filter.match(&e) {
+			*entries = append(*entries, e)
+		}
+	}
+	return scanner.Err()
+}
+
+func (a *FileAuditor) Close() error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// DBAuditor stores entries in a table of db, key is the time of entry.
+// db should be dedicated to audit, since entries differ between replicas,
+// they will break the checksum of replicated db
+type DBAuditor struct {
+	db    kvzoo.DB
+	table kvzoo.Table
+
+	lock sync.Mutex
+	last int64
+}
+
+func NewDBAuditor(db kvzoo.DB, tableName kvzoo.TableName) (*DBAuditor, error) {
+	table, err := db.CreateOrGetTable(tableName)
+	if err != nil {
+		return nil, err
+	}
+	return &DBAuditor{
+		db:    db,
+		table: table,
+	}, nil
+}
+
+func (a *DBAuditor) Audit(entries ...AuditEntry) error {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	return kvzoo.Update(a.table, func(tx kvzoo.Transaction) error {
+		for _, e := range entries {
+			value, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			//keys are unique and sorted by time
+			key := e.Time.UnixNano()
+			if key <= a.last {
+				key = a.last + 1
+			}
+			a.last = key
+			if err := tx.Add(auditKey(key), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (a *DBAuditor) Query(filter AuditFilter) ([]AuditEntry, error) {
+	var tx kvzoo.Transaction
+	var err error
+	if table, ok := a.table.(kvzoo.ReadOnlyTable); ok {
+		tx, err = table.BeginReadOnly()
+	} else {
+		tx, err = a.table.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var entries []AuditEntry
+	if seekable, ok := tx.(kvzoo.SeekableTransaction); ok {
+		err = seekAuditEntries(seekable.Cursor(), &filter, &entries)
+	} else {
+		err = listAuditEntries(tx, &filter, &entries)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return filter.limit(entries), nil
+}
+
+func auditKey(t int64) string {
+	return fmt.Sprintf("%020d", t)
+}
+
+// seekAuditEntries walks back from the newest entry before Until, and
+// stops at Since or once Limit entries are found. key of entry is its time,
+// it's only moved after the time when entries have same time, so no entry
+// since Since has key before Since
+func seekAuditEntries(c kvzoo.Cursor, filter *AuditFilter, entries *[]AuditEntry) error {
+	var key string
+	var value []byte
+	if filter.Until.IsZero() {
+		key, value = c.Last()
+	} else {
+		for key, value = c.Seek(auditKey(filter.Until.UnixNano())); key != ""; key, value = c.Next() {
+			var e AuditEntry
+			if err := json.Unmarshal(value, &e); err != nil {
+				return err
+			}
+			if e.Time.Before(filter.Until) == false {
+				break
+			}
+		}
+		if key == "" {
+			key, value = c.Last()
+		} else {
+			key, value = c.Prev()
+		}
+	}
+
+	var since string
+	if filter.Since.IsZero() == false {
+		since = auditKey(filter.Since.UnixNano())
+	}
+	var found []AuditEntry
+	for ; key != "" && key >= since; key, value = c.Prev() {
+		if filter.Limit > 0 && len(found) == filter.Limit {
+			break
+		}
+
+		var e AuditEntry
+		if err := json.Unmarshal(value, &e); err != nil {
+			return err
+		}
+		if filter.match(&e) {
+			found = append(found, e)
+		}
+	}
+
+	for i := len(found) - 1; i >= 0; i-- {
+		*entries = append(*entries, found[i])
+	}
+	return nil
+}
+
+func listAuditEntries(tx kvzoo.Transaction, filter *AuditFilter, entries *[]AuditEntry) error {
+	values, err := tx.List()
+	if err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	//entries committed together have same time, keep them in order
+	sort.Strings(keys)
+	for _, key := range keys {
+		var e AuditEntry
+		if err := json.Unmarshal(values[key], &e); err != nil {
+			return err
+		}
+		if filter.match(&e) {
+			*entries = append(*entries, e)
+		}
+	}
+	return nil
+}
+
+func (a *DBAuditor) Close() error {
+	return a.db.Close()
+}
+
+func newAuditEntry(ctx context.Context, operation, table string, err error) AuditEntry {
+	entry := AuditEntry{
+		Time:      time.Now(),
+		Operation: operation,
+		Table:     table,
+	}
+	entry.Identity, _ = IdentityFromContext(ctx)
+	if p, ok := peer.FromContext(ctx); ok {
+		entry.Peer = p.Addr.String()
+	}
+	if err != nil {
+		entry.Err = err.Error()
+	}
+	return entry
+}
+
+func newMutationEntry(ctx context.Context, operation, table, key string, value []byte) AuditEntry {
+	entry := newAuditEntry(ctx, operation, table, nil)
+	entry.Key = key
+	if value != nil {
+		hash := sha256.Sum256(value)
+		entry.ValueSize = len(value)
+		entry.ValueHash = hex.EncodeToString(hash[:])
+	}
+	return entry
+}
+
+func (s *KVService) audit(ctx context.Context, operation, table string, err error) {
+	s.writeAudit(newAuditEntry(ctx, operation, table, err))
+}
+
+func (s *KVService) writeAudit(entries ...AuditEntry) {
+	if err := s.auditor.Audit(entries...); err != nil {
+		log.Errorf("write audit failed:%s", err.Error())
+	}
+}
+
+// recordMutation keeps the change in transaction, it's recorded after the
+// transaction is committed
+func (s *KVService) recordMutation(ctx context.Context, tx *openedTx, operation, key string, value []byte) {
+	if s.auditMutations == false {
+		return
+	}
+	tx.lock.Lock()
+	tx.mutations = append(tx.mutations, newMutationEntry(ctx, operation, tx.table, key, value))
+	tx.lock.Unlock()
+}
+
+func (s *KVService) QueryAudit(ctx context.Context, in *pb.QueryAuditRequest) (*pb.QueryAuditReply, error) {
+	auditor, ok := s.auditor.(QueryableAuditor)
+	if ok == false {
+		return nil, status.Error(codes.Unimplemented, "audit isn't queryable")
+	}
+
+	filter := AuditFilter{
+		Table: in.Table,
+		Limit: int(in.Limit),
+	}
+	if in.Since != 0 {
+		filter.Since = time.Unix(0, in.Since)
+	}
+	if in.Until != 0 {
+		filter.Until = time.Unix(0, in.Until)
+	}
+	entries, err := auditor.Query(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &pb.QueryAuditReply{
+		Entries: make([]*pb.AuditEntry, 0, len(entries)),
+	}
+	for _, e := range entries {
+		reply.Entries = append(reply.Entries, &pb.AuditEntry{
+			Time:      e.Time.UnixNano(),
+			Identity:  e.Identity,
+			Peer:      e.Peer,
+			Operation: e.Operation,
+			Table:     e.Table,
+			Key:       e.Key,
+			ValueSize: int64(e.ValueSize),
+			ValueHash: e.ValueHash,
+			Err:       e.Err,
+		})
+	}
+	return reply, nil
+}
@@ -0,0 +1,363 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+// Authenticator finds out the identity of the caller, empty identity with
+// nil error means the request doesn't carry the credential it knows, so
+// the next authenticator is tried
+type Authenticator interface {
+	Authenticate(ctx context.Context) (string, error)
+}
+
+// TokenAuthenticator authenticates requests with the bearer token in
+// authorization metadata
+type TokenAuthenticator struct {
+	lock sync.RWMutex
+	//token to identity
+	tokens map[string]string
+}
+
+func NewTokenAuthenticator(tokens map[string]string) *TokenAuthenticator {
+	a := &TokenAuthenticator{}
+	a.SetTokens(tokens)
+	return a
+}
+
+// SetTokens replaces all the tokens, tokens could be rotated without restart
+func (a *TokenAuthenticator) SetTokens(tokens map[string]string) {
+	copied := make(map[string]string, len(tokens))
+	for token, identity := range tokens {
+		copied[token] = identity
+	}
+	a.lock.Lock()
+	a.tokens = copied
+	a.lock.Unlock()
+}
+
+func (a *TokenAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok == false {
+		return "", nil
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", nil
+	}
+
+	token := strings.TrimPrefix(values[0], "Bearer ")
+	a.lock.RLock()
+	identity, ok := a.tokens[token]
+	a.lock.RUnlock()
+	if ok == false {
+		return "", fmt.Errorf("invalid token")
+	}
+	return identity, nil
+}
+
+// CertAuthenticator uses the common name of the verified client certificate
+// as identity, it only works with tls config which has client ca
+type CertAuthenticator struct{}
+
+func (a CertAuthenticator) Authenticate(ctx context.Context) (string, error) {
+	p, ok := peer.FromContext(ctx)
+	if ok == false {
+		return "", nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if ok == false || len(info.State.VerifiedChains) == 0 {
+		return "", nil
+	}
+	return info.State.VerifiedChains[0][0].Subject.CommonName, nil
+}
+
+type Permission int
+
+const (
+	PermNone Permission = iota
+	PermRead
+	//write implies read
+	PermWrite
+	//admin implies write, and allows destroying and compacting db,
+	//deleting tables and restoring backup
+	PermAdmin
+)
+
+func ParsePermission(s string) (Permission, error) {
+	switch s {
+	case "read":
+		return PermRead, nil
+	case "write":
+		return PermWrite, nil
+	case "admin":
+		return PermAdmin, nil
+	default:
+		return PermNone, fmt.Errorf("unknown permission %s", s)
+	}
+}
+
+func (p Permission) String() string {
+	switch p {
+	case PermRead:
+		return "read"
+	case PermWrite:
+		return "write"
+	case PermAdmin:
+		return "admin"
+	default:
+		return "none"
+	}
+}
+
+// AnyIdentity in acl rule matches all the authenticated callers
+const AnyIdentity = "*"
+
+// ACLRule grants permission on table and all its sub tables, table Root
+// stands for the whole db
+type ACLRule struct {
+	Identity   string
+	Table      string
+	Permission Permission
+}
+
+func (r ACLRule) validate() error {
+	if r.Identity == "" {
+		return fmt.Errorf("identity of acl rule is empty")
+	}
+	if r.Table != kvzoo.Root {
+		if _, err := kvzoo.NewTableName(r.Table); err != nil {
+			return fmt.Errorf("table %s of acl rule is invalid:%s", r.Table, err.Error())
+		}
+	}
+	if r.Permission < PermRead || r.Permission > PermAdmin {
+		return fmt.Errorf("permission of acl rule is invalid")
+	}
+	return nil
+}
+
+func (r ACLRule) matches(identity, table string) bool {
+	if r.Identity != AnyIdentity && r.Identity != identity {
+		return false
+	}
+	return r.Table == kvzoo.Root || r.Table == table || strings.HasPrefix(table, r.Table+"/")
+}
+
+// permission required by each method, methods not listed require admin
+// permission on the whole db
+var methodPermissions = map[string]Permission{
+	"/pb.KVS/Checksum":            PermRead,
+	"/pb.KVS/Destroy":             PermAdmin,
+	"/pb.KVS/CreateOrGetTable":    PermRead,
+	"/pb.KVS/DeleteTable":         PermAdmin,
+	"/pb.KVS/ListTables":          PermRead,
+	"/pb.KVS/TableExists":         PermRead,
+	"/pb.KVS/Stats":               PermRead,
+	"/pb.KVS/MerkleTree":          PermRead,
+	"/pb.KVS/KeyDigests":          PermRead,
+	"/pb.KVS/BeginTransaction":    PermRead,
+	"/pb.KVS/CommitTransaction":   PermRead,
+	"/pb.KVS/RollbackTransaction": PermRead,
+	"/pb.KVS/Get":                 PermRead,
+	"/pb.KVS/List":                PermRead,
+	"/pb.KVS/Add":                 PermWrite,
+	"/pb.KVS/Delete":              PermWrite,
+	"/pb.KVS/Update":              PermWrite,
+	"/pb.KVS/Batch":               PermRead,
+	"/pb.KVS/Backup":              PermRead,
+	"/pb.KVS/Restore":             PermAdmin,
+	"/pb.KVS/Compact":             PermAdmin,
+	"/pb.KVS/QueryAudit":          PermAdmin,
+	"/pb.KVS/ListTransactions":    PermAdmin,
+	"/pb.KVS/AbortTransaction":    PermAdmin,
+	"/pb.KVS/Revision":            PermRead,
+}
+
+type identityKey struct{}
+
+// IdentityFromContext returns the identity of caller authenticated by server
+func IdentityFromContext(ctx context.Context) (string, bool) {
+	identity, ok := ctx.Value(identityKey{}).(string)
+	return identity, ok
+}
+
+// authorizer checks requests to kv service, requests to health service
+// are never checked
+type authorizer struct {
+	service        *KVService
+	authenticators []Authenticator
+
+	lock sync.RWMutex
+	acl  []ACLRule
+}
+
+func newAuthorizer(service *KVService, authenticators []Authenticator, acl []ACLRule) (*authorizer, error) {
+	a := &authorizer{
+		service:        service,
+		authenticators: authenticators,
+	}
+	if err := a.setACL(acl); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+func validateACL(acl []ACLRule) error {
+	for _, rule := range acl {
+		if err := rule.validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (a *authorizer) setACL(acl []ACLRule) error {
+	if err := validateACL(acl); err != nil {
+		return err
+	}
+
+	a.lock.Lock()
+	a.acl = append([]ACLRule(nil), acl...)
+	a.lock.Unlock()
+	return nil
+}
+
+func (a *authorizer) authenticate(ctx context.Context) (string, error) {
+	for _, auth := range a.authenticators {
+		identity, err := auth.Authenticate(ctx)
+		if err != nil {
+			return "", status.Error(codes.Unauthenticated, err.Error())
+		} else if identity != "" {
+			return identity, nil
+		}
+	}
+	return "", status.Error(codes.Unauthenticated, "no valid credential")
+}
+
+func (a *authorizer) permission(identity, table string) Permission {
+	a.lock.RLock()
+	defer a.lock.RUnlock()
+
+	perm := PermNone
+	for _, rule := range a.acl {
+		if rule.Permission > perm && rule.matches(identity, table) {
+			perm = rule.Permission
+		}
+	}
+	return perm
+}
+
+// tableOf returns the table which request accesses, and the identity which
+// begins the transaction of request. false is returned if the transaction
+// of request doesn't exist, which is rejected by service
+func (a *authorizer) tableOf(req interface{}) (string, string, bool) {
+	switch r := req.(type) {
+	case interface{ GetTxId() int64 }:
+		return a.service.txOwner(r.GetTxId())
+	case interface{ GetTableName() string }:
+		return r.GetTableName(), "", true
+	case interface{ GetParent() string }:
+		return r.GetParent(), "", true
+	case interface{ GetName() string }:
+		return r.GetName(), "", true
+	default:
+		return kvzoo.Root, "", true
+	}
+}
+
+// invalid table name is regarded as existing, which is rejected by service
+func (a *authorizer) tableExists(table string) bool {
+	tn, err := kvzoo.NewTableName(table)
+	if err != nil {
+		return true
+	}
+	exists, err := a.service.db.TableExists(tn)
+	return err != nil || exists
+}
+
+func (a *authorizer) authorize(ctx context.Context, method string, req interface{}) (context.Context, error) {
+	if strings.HasPrefix(method, "/pb.KVS/") == false {
+		return ctx, nil
+	}
+
+	identity, err := a.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ctx = context.WithValue(ctx, identityKey{}, identity)
+
+	table := kvzoo.Root
+	required, ok := methodPermissions[method]
+	if ok == false {
+		required = PermAdmin
+	} else if req != nil {
+		var owner string
+		if table, owner, ok = a.tableOf(req); ok == false {
+			return ctx, nil
+		}
+		//transaction ids are sequential, transaction is only used by the
+		//identity which begins it, admin could abort any of them
+		if _, ok := req.(interface{ GetTxId() int64 }); ok && owner != identity && method != "/pb.KVS/AbortTransaction" {
+			return nil, status.Errorf(codes.PermissionDenied, "transaction isn't owned by %s", identity)
+		}
+		//creating table is a write, and transaction which isn't read only
+		//holds the writer lock of db, which would block all writers
+		if method == "/pb.KVS/CreateOrGetTable" && a.tableExists(table) == false {
+			required = PermWrite
+		} else if begin, ok := req.(*pb.BeginTransactionRequest); ok && begin.ReadOnly == false {
+			required = PermWrite
+		} else if batch, ok := req.(*pb.BatchRequest); ok && batch.HasWrites() {
+			required = PermWrite
+		}
+		//revision of master moves the applied revision of slave, which
+		//decides whether slave serves reads
+		if r, ok := req.(interface{ GetRevision() *pb.Revision }); ok && r.GetRevision() != nil && required < PermWrite {
+			required = PermWrite
+		}
+	}
+
+	if a.permission(identity, table) < required {
+		return nil, status.Errorf(codes.PermissionDenied, "%s has no %s permission on %s", identity, required, table)
+	}
+	return ctx, nil
+}
+
+func (a *authorizer) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	ctx, err := a.authorize(ctx, info.FullMethod, req)
+	if err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+// request of stream isn't available before handler, stream methods only
+// access the whole db
+func (a *authorizer) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx, err := a.authorize(ss.Context(), info.FullMethod, nil)
+	if err != nil {
+		return err
+	}
+	return handler(srv, &authorizedStream{ServerStream: ss, ctx: ctx})
+}
+
+type authorizedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authorizedStream) Context() context.Context {
+	return s.ctx
+}
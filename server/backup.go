@@ -0,0 +1,117 @@
+package server
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+const backupChunkSize = 1024 * 1024
+
+var ErrBackupNotSupported = fmt.Errorf("db doesn't support backup")
+
+type backupWriter struct {
+	stream pb.KVS_BackupServer
+}
+
+func (w *backupWriter) Write(data []byte) (int, error) {
+	n := 0
+	for len(data) > 0 {
+		size := len(data)
+		if size > backupChunkSize {
+			size = backupChunkSize
+		}
+		if err := w.stream.Send(&pb.BackupReply{Data: data[:size]}); err != nil {
+			return n, err
+		}
+		n += size
+		data = data[size:]
+	}
+	return n, nil
+}
+
+func (s *KVService) Backup(in *pb.BackupRequest, stream pb.KVS_BackupServer) error {
+	db, ok := s.db.(kvzoo.Backupable)
+	if ok == false {
+		return ErrBackupNotSupported
+	}
+
+	cs, err := db.Backup(&backupWriter{stream: stream})
+	if err != nil {
+		return err
+	}
+
+	return stream.Send(&pb.BackupReply{
+		Checksum: cs,
+	})
+}
+
+func (s *KVService) Restore(stream pb.KVS_RestoreServer) error {
+	err := s.restore(stream)
+	s.audit(stream.Context(), "restore", kvzoo.Root, err)
+	return err
+}
+
+func (s *KVService) restore(stream pb.KVS_RestoreServer) error {
+	db, ok := s.db.(kvzoo.Backupable)
+	if ok == false {
+		return ErrBackupNotSupported
+	}
+
+	req, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	cs := req.Checksum
+
+	s.tableLock.Lock()
+	defer s.tableLock.Unlock()
+	s.openedTables = make(map[string]kvzoo.Table)
+	s.txLock.Lock()
+	defer s.txLock.Unlock()
+	s.dropTxs("restored")
+
+	r, w := io.Pipe()
+	//the error channel is buffered, the goroutine won't be blocked if restore
+	//fails and returns without receiving
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- receiveRestoreData(stream, w, req.Data)
+	}()
+
+	err = db.Restore(r, cs)
+	r.Close()
+	//data may be replaced even if restore fails
+	s.revisions.resetWithLock()
+	if err != nil {
+		return err
+	}
+	//restore reads until the writer is closed, so the goroutine has exited
+	if err := <-errCh; err != nil {
+		return err
+	}
+	return stream.SendAndClose(&empty.Empty{})
+}
+
+// receiveRestoreData writes data of the first request and the following ones
+// to the pipe, until the stream ends or the pipe is closed by reader
+func receiveRestoreData(stream pb.KVS_RestoreServer, w *io.PipeWriter, data []byte) error {
+	for {
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+
+		req, err := stream.Recv()
+		if err == io.EOF {
+			return w.Close()
+		} else if err != nil {
+			w.CloseWithError(err)
+			return err
+		}
+		data = req.Data
+	}
+}
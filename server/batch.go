@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+// Batch runs operations in one transaction, so a transaction costs one round
+// trip instead of one for each operation. the transaction is committed only
+// if all the operations succeed, get of missing key isn't a failure but a
+// result which isn't found. it's an ordinary transaction of server, which is
+// listed, audited and traced like others
+func (s *KVService) Batch(ctx context.Context, in *pb.BatchRequest) (*pb.BatchReply, error) {
+	begin, err := s.BeginTransaction(ctx, &pb.BeginTransactionRequest{
+		TableName: in.TableName,
+		ReadOnly:  in.HasWrites() == false,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	id := begin.TxId
+	results := make([]*pb.BatchResult, 0, len(in.Operations))
+	for i, op := range in.Operations {
+		result, err := s.runBatchOperation(ctx, id, op)
+		if err != nil {
+			s.RollbackTransaction(ctx, &pb.RollbackTransactionRequest{TxId: id})
+			//keep the code, so client knows whether to retry
+			return nil, status.Errorf(status.Code(err), "operation %d %s %s failed:%s",
+				i, op.Type, op.Key, status.Convert(err).Message())
+		}
+		results = append(results, result)
+	}
+
+	commit, err := s.CommitTransaction(ctx, &pb.CommitTransactionRequest{
+		TxId:     id,
+		Revision: in.Revision,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BatchReply{
+		Results:  results,
+		Revision: commit.Revision,
+	}, nil
+}
+
+func (s *KVService) runBatchOperation(ctx context.Context, id int64, op *pb.BatchOperation) (*pb.BatchResult, error) {
+	var err error
+	switch op.Type {
+	case pb.BatchOperation_GET:
+		reply, err := s.Get(ctx, &pb.GetRequest{TxId: id, Key: op.Key})
+		if err == kvzoo.ErrNotFound {
+			return &pb.BatchResult{}, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return &pb.BatchResult{Value: reply.Value, Found: true}, nil
+	case pb.BatchOperation_LIST:
+		reply, err := s.List(ctx, &pb.ListRequest{TxId: id})
+		if err != nil {
+			return nil, err
+		}
+		return &pb.BatchResult{Values: reply.Values}, nil
+	case pb.BatchOperation_ADD:
+		_, err = s.Add(ctx, &pb.AddRequest{TxId: id, Key: op.Key, Value: op.Value})
+	case pb.BatchOperation_UPDATE:
+		_, err = s.Update(ctx, &pb.UpdateRequest{TxId: id, Key: op.Key, Value: op.Value})
+	case pb.BatchOperation_DELETE:
+		_, err = s.Delete(ctx, &pb.DeleteRequest{TxId: id, Key: op.Key})
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown operation type %d", op.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &pb.BatchResult{}, nil
+}
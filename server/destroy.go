@@ -0,0 +1,63 @@
+package server
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/internal/backupfile"
+)
+
+type DestroyMode int
+
+const (
+	DestroyAllowed DestroyMode = iota
+	DestroyDisabled
+	//Destroy requires confirm token which is the current checksum of db,
+	//so the caller has to know what it destroys, and nothing is changed
+	//since then
+	DestroyConfirmed
+)
+
+type DestroyGuard struct {
+	Mode DestroyMode
+	//db is backed up into the dir before destroy if it's not empty, backup
+	//failure aborts destroy
+	BackupDir string
+}
+
+var (
+	ErrDestroyDisabled     = status.Error(codes.FailedPrecondition, "destroy is disabled")
+	ErrDestroyNotConfirmed = status.Error(codes.FailedPrecondition, "destroy requires confirm token which is the current checksum of db")
+)
+
+// checkDestroy is called with table and transaction locks held
+func (s *KVService) checkDestroy(confirm string) error {
+	switch s.destroyGuard.Mode {
+	case DestroyDisabled:
+		return ErrDestroyDisabled
+	case DestroyConfirmed:
+		cs, err := s.db.Checksum()
+		if err != nil {
+			return err
+		}
+		if confirm != cs {
+			return ErrDestroyNotConfirmed
+		}
+	}
+
+	if s.destroyGuard.BackupDir != "" {
+		if _, err := backupToDir(s.db, s.destroyGuard.BackupDir); err != nil {
+			return status.Errorf(codes.Internal, "backup before destroy failed:%s", err.Error())
+		}
+	}
+	return nil
+}
+
+func backupToDir(db kvzoo.DB, dir string) (string, error) {
+	backupable, ok := db.(kvzoo.Backupable)
+	if ok == false {
+		return "", ErrBackupNotSupported
+	}
+	return backupfile.Write(dir, backupable.Backup)
+}
@@ -0,0 +1,325 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/tracing"
+)
+
+const maxGatewayBodySize = 64 * 1024 * 1024
+
+// gateway serves KVService in http and json, requests go through the same
+// interceptors as grpc requests through localClient
+//
+//	GET    /checksum
+//	GET    /tables/{table}             list keys and values of table
+//	GET    /tables/{table}/keys/{key}  value is returned as body
+//	PUT    /tables/{table}/keys/{key}  add or update key, body is the value
+//	DELETE /tables/{table}/keys/{key}
+//	POST   /transactions               run GatewayTransaction
+//
+// key could be escaped if it includes "/", values in json are base64 encoded
+type gateway struct {
+	client *localClient
+}
+
+// GatewayTransaction is the body of /transactions, operations are run in
+// one transaction, which is committed only if all of them succeed
+type GatewayTransaction struct {
+	Table      string             `json:"table"`
+	Operations []GatewayOperation `json:"operations"`
+}
+
+type GatewayOperation struct {
+	//get, put or delete
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+// GatewayResult is the result of each operation, only get has value
+type GatewayResult struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value []byte `json:"value,omitempty"`
+}
+
+type gatewayError struct {
+	Error string `json:"error"`
+	//index of the failed operation of transaction
+	Operation *int `json:"operation,omitempty"`
+}
+
+func newGateway(client *localClient) *gateway {
+	return &gateway{
+		client: client,
+	}
+}
+
+func (g *gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := newGatewayContext(r)
+	path := r.URL.EscapedPath()
+	switch {
+	case path == "/checksum" && r.Method == http.MethodGet:
+		g.checksum(ctx, w)
+	case path == "/transactions" && r.Method == http.MethodPost:
+		g.transaction(ctx, w, r)
+	case strings.HasPrefix(path, "/tables/"):
+		table, key, err := parseGatewayPath(strings.TrimPrefix(path, "/tables"))
+		if err != nil {
+			writeGatewayError(w, http.StatusBadRequest, err, nil)
+		} else if key == "" && r.Method == http.MethodGet {
+			g.list(ctx, w, table)
+		} else if key != "" && r.Method == http.MethodGet {
+			g.get(ctx, w, table, key)
+		} else if key != "" && r.Method == http.MethodPut {
+			g.put(ctx, w, r, table, key)
+		} else if key != "" && r.Method == http.MethodDelete {
+			g.delete(ctx, w, table, key)
+		} else {
+			writeGatewayError(w, http.StatusMethodNotAllowed, fmt.Errorf("method %s isn't allowed", r.Method), nil)
+		}
+	default:
+		writeGatewayError(w, http.StatusNotFound, fmt.Errorf("%s %s isn't found", r.Method, path), nil)
+	}
+}
+
+// grpc authenticators read token from metadata and certificate from peer
+func newGatewayContext(r *http.Request) context.Context {
+	ctx := r.Context()
+	md := metadata.MD{}
+	for _, header := range []string{"authorization", tracing.TraceparentKey} {
+		if value := r.Header.Get(header); value != "" {
+			md.Set(header, value)
+		}
+	}
+	ctx = metadata.NewIncomingContext(ctx, md)
+
+	p := &peer.Peer{Addr: peerAddr(r.RemoteAddr)}
+	if r.TLS != nil {
+		p.AuthInfo = credentials.TLSInfo{State: *r.TLS}
+	}
+	return peer.NewContext(ctx, p)
+}
+
+// table and key are split by the last "/keys/"
+func parseGatewayPath(path string) (kvzoo.TableName, string, error) {
+	var key string
+	if i := strings.LastIndex(path, "/keys/"); i != -1 {
+		var err error
+		if key, err = url.PathUnescape(path[i+len("/keys/"):]); err != nil {
+			return "", "", fmt.Errorf("invalid key:%s", err.Error())
+		} else if key == "" {
+			return "", "", fmt.Errorf("key is empty")
+		}
+		path = path[:i]
+	}
+
+	name, err := url.PathUnescape(path)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid table:%s", err.Error())
+	}
+	table, err := kvzoo.NewTableName(name)
+	if err != nil {
+		return "", "", err
+	} else if table == kvzoo.Root {
+		return "", "", fmt.Errorf("root table has no keys")
+	}
+	return table, key, nil
+}
+
+func writeGatewayJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeGatewayError(w http.ResponseWriter, code int, err error, operation *int) {
+	writeGatewayJSON(w, code, gatewayError{
+		Error:     err.Error(),
+		Operation: operation,
+	})
+}
+
+var gatewayStatusCodes = map[codes.Code]int{
+	codes.InvalidArgument:    http.StatusBadRequest,
+	codes.Unauthenticated:    http.StatusUnauthorized,
+	codes.PermissionDenied:   http.StatusForbidden,
+	codes.NotFound:           http.StatusNotFound,
+	codes.Aborted:            http.StatusConflict,
+	codes.FailedPrecondition: http.StatusPreconditionFailed,
+	codes.ResourceExhausted:  http.StatusTooManyRequests,
+	codes.Unimplemented:      http.StatusNotImplemented,
+	codes.Unavailable:        http.StatusServiceUnavailable,
+}
+
+func gatewayStatusCode(err error) int {
+	if err == kvzoo.ErrNotFound {
+		return http.StatusNotFound
+	} else if err == errNoWriter {
+		return http.StatusMethodNotAllowed
+	}
+	if code, ok := gatewayStatusCodes[status.Code(err)]; ok {
+		return code
+	}
+	return http.StatusInternalServerError
+}
+
+func (g *gateway) checksum(ctx context.Context, w http.ResponseWriter) {
+	checksum, err := g.client.checksum(ctx)
+	if err != nil {
+		writeGatewayError(w, gatewayStatusCode(err), err, nil)
+		return
+	}
+	writeGatewayJSON(w, http.StatusOK, map[string]string{"checksum": checksum})
+}
+
+func (g *gateway) list(ctx context.Context, w http.ResponseWriter, table kvzoo.TableName) {
+	var values map[string][]byte
+	err := g.client.view(ctx, table, func(tx *localTx) error {
+		var err error
+		values, err = tx.list()
+		return err
+	})
+	if err != nil {
+		writeGatewayError(w, gatewayStatusCode(err), err, nil)
+		return
+	}
+	if values == nil {
+		values = make(map[string][]byte)
+	}
+	writeGatewayJSON(w, http.StatusOK, values)
+}
+
+func (g *gateway) get(ctx context.Context, w http.ResponseWriter, table kvzoo.TableName, key string) {
+	var value []byte
+	err := g.client.view(ctx, table, func(tx *localTx) error {
+		var err error
+		value, err = tx.get(key)
+		return err
+	})
+	if err != nil {
+		writeGatewayError(w, gatewayStatusCode(err), err, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(value)
+}
+
+func (g *gateway) put(ctx context.Context, w http.ResponseWriter, r *http.Request, table kvzoo.TableName, key string) {
+	value, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxGatewayBodySize))
+	if err != nil {
+		writeGatewayError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+	ops := []*pb.BatchOperation{{Type: pb.BatchOperation_UPDATE, Key: key, Value: value}}
+	err = g.client.update(ctx, table, ops, func(tx kvzoo.Transaction) error {
+		return put(tx, key, value)
+	})
+	if err != nil {
+		writeGatewayError(w, gatewayStatusCode(err), err, nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (g *gateway) delete(ctx context.Context, w http.ResponseWriter, table kvzoo.TableName, key string) {
+	ops := []*pb.BatchOperation{{Type: pb.BatchOperation_DELETE, Key: key}}
+	err := g.client.update(ctx, table, ops, func(tx kvzoo.Transaction) error {
+		return tx.Delete(key)
+	})
+	if err != nil {
+		writeGatewayError(w, gatewayStatusCode(err), err, nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var gatewayOperationTypes = map[string]pb.BatchOperation_Type{
+	"get":    pb.BatchOperation_GET,
+	"put":    pb.BatchOperation_UPDATE,
+	"delete": pb.BatchOperation_DELETE,
+}
+
+func (g *gateway) transaction(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	var tr GatewayTransaction
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxGatewayBodySize)).Decode(&tr); err != nil {
+		writeGatewayError(w, http.StatusBadRequest, fmt.Errorf("invalid transaction:%s", err.Error()), nil)
+		return
+	}
+	table, err := kvzoo.NewTableName(tr.Table)
+	if err != nil {
+		writeGatewayError(w, http.StatusBadRequest, err, nil)
+		return
+	}
+
+	ops := make([]*pb.BatchOperation, 0, len(tr.Operations))
+	for i, op := range tr.Operations {
+		typ, ok := gatewayOperationTypes[op.Op]
+		if ok == false {
+			writeGatewayError(w, http.StatusBadRequest, fmt.Errorf("unknown operation %s", op.Op), &i)
+			return
+		}
+		ops = append(ops, &pb.BatchOperation{Type: typ, Key: op.Key, Value: op.Value})
+	}
+	batch := &pb.BatchRequest{Operations: ops}
+
+	var results []GatewayResult
+	failed := -1
+	//writer may run f again, results of the last run are returned
+	run := func(getKey func(string) ([]byte, error), putKey func(string, []byte) error, deleteKey func(string) error) error {
+		results = make([]GatewayResult, 0, len(tr.Operations))
+		failed = -1
+		for i, op := range tr.Operations {
+			result := GatewayResult{Op: op.Op, Key: op.Key}
+			var err error
+			switch op.Op {
+			case "get":
+				result.Value, err = getKey(op.Key)
+			case "put":
+				err = putKey(op.Key, op.Value)
+			case "delete":
+				err = deleteKey(op.Key)
+			}
+			if err != nil {
+				failed = i
+				return err
+			}
+			results = append(results, result)
+		}
+		return nil
+	}
+	if batch.HasWrites() {
+		err = g.client.update(ctx, table, ops, func(tx kvzoo.Transaction) error {
+			return run(tx.Get, func(key string, value []byte) error {
+				return put(tx, key, value)
+			}, tx.Delete)
+		})
+	} else {
+		err = g.client.view(ctx, table, func(tx *localTx) error {
+			return run(tx.get, nil, nil)
+		})
+	}
+
+	if err == nil {
+		writeGatewayJSON(w, http.StatusOK, results)
+	} else if failed != -1 {
+		writeGatewayError(w, gatewayStatusCode(err), err, &failed)
+	} else {
+		writeGatewayError(w, gatewayStatusCode(err), err, nil)
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// grpc server only accepts one interceptor of each kind, the first
+// interceptor is the outermost one
+func chainUnaryInterceptors(interceptors []grpc.UnaryServerInterceptor) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(ctx context.Context, req interface{}) (interface{}, error) {
+				return interceptor(ctx, req, info, next)
+			}
+		}
+		return chained(ctx, req)
+	}
+}
+
+func chainStreamInterceptors(interceptors []grpc.StreamServerInterceptor) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		chained := handler
+		for i := len(interceptors) - 1; i >= 0; i-- {
+			interceptor, next := interceptors[i], chained
+			chained = func(srv interface{}, ss grpc.ServerStream) error {
+				return interceptor(srv, ss, info, next)
+			}
+		}
+		return chained(srv, ss)
+	}
+}
@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+// localClient calls KVService in process for the http and redis gateways,
+// requests go through the same interceptors as grpc requests, so they are
+// authenticated, authorized, traced and counted in the same way
+type localClient struct {
+	service     *KVService
+	interceptor grpc.UnaryServerInterceptor
+	//nil if WithWriter isn't set
+	writer kvzoo.DB
+}
+
+// updater is implemented by client.Proxy, which retries the transaction
+type updater interface {
+	Update(kvzoo.TableName, func(kvzoo.Transaction) error) error
+}
+
+var errNoWriter = status.Error(codes.FailedPrecondition, "no writer is set, write through proxy instead")
+
+// peerAddr is the address of client which doesn't connect through grpc
+type peerAddr string
+
+func (a peerAddr) Network() string {
+	return "tcp"
+}
+
+func (a peerAddr) String() string {
+	return string(a)
+}
+
+// invoke calls method of service through the interceptors
+func (c *localClient) invoke(ctx context.Context, method string, req interface{}, handler grpc.UnaryHandler) (interface{}, error) {
+	if c.interceptor == nil {
+		return handler(ctx, req)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     c.service,
+		FullMethod: kvsMethodPrefix + method,
+	}
+	return c.interceptor(ctx, req, info, handler)
+}
+
+func (c *localClient) checksum(ctx context.Context) (string, error) {
+	reply, err := c.invoke(ctx, "Checksum", &pb.ChecksumRequest{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.service.Checksum(ctx, req.(*pb.ChecksumRequest))
+	})
+	if err != nil {
+		return "", err
+	}
+	return reply.(*pb.ChecksumReply).Checksum, nil
+}
+
+type localTx struct {
+	c   *localClient
+	ctx context.Context
+	id  int64
+}
+
+// view runs f in a read only transaction of table
+func (c *localClient) view(ctx context.Context, table kvzoo.TableName, f func(tx *localTx) error) error {
+	reply, err := c.invoke(ctx, "TableExists", &pb.TableExistsRequest{Name: string(table)}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.service.TableExists(ctx, req.(*pb.TableExistsRequest))
+	})
+	if err != nil {
+		return err
+	} else if reply.(*pb.TableExistsReply).Exists == false {
+		return status.Errorf(codes.NotFound, "table %s doesn't exist", table)
+	}
+
+	//table is opened by service before transaction begins
+	if _, err := c.invoke(ctx, "CreateOrGetTable", &pb.CreateOrGetTableRequest{Name: string(table)}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.service.CreateOrGetTable(ctx, req.(*pb.CreateOrGetTableRequest))
+	}); err != nil {
+		return err
+	}
+
+	reply, err = c.invoke(ctx, "BeginTransaction", &pb.BeginTransactionRequest{TableName: string(table), ReadOnly: true}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return c.service.BeginTransaction(ctx, req.(*pb.BeginTransactionRequest))
+	})
+	if err != nil {
+		return err
+	}
+
+	tx := &localTx{
+		c:   c,
+		ctx: ctx,
+		id:  reply.(*pb.BeginTransactionReply).TxId,
+	}
+	if err := f(tx); err != nil {
+		tx.rollback()
+		return err
+	}
+	return tx.rollback()
+}
+
+func (tx *localTx) rollback() error {
+	_, err := tx.c.invoke(tx.ctx, "RollbackTransaction", &pb.RollbackTransactionRequest{TxId: tx.id}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return tx.c.service.RollbackTransaction(ctx, req.(*pb.RollbackTransactionRequest))
+	})
+	return err
+}
+
+func (tx *localTx) get(key string) ([]byte, error) {
+	reply, err := tx.c.invoke(tx.ctx, "Get", &pb.GetRequest{TxId: tx.id, Key: key}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return tx.c.service.Get(ctx, req.(*pb.GetRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*pb.GetResponse).Value, nil
+}
+
+func (tx *localTx) list() (map[string][]byte, error) {
+	reply, err := tx.c.invoke(tx.ctx, "List", &pb.ListRequest{TxId: tx.id}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return tx.c.service.List(ctx, req.(*pb.ListRequest))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.(*pb.ListResponse).Values, nil
+}
+
+// scan goes through interceptors as List, since both read keys of the
+// transaction
+func (tx *localTx) scan(after string, count int) ([]string, error) {
+	reply, err := tx.c.invoke(tx.ctx, "List", &pb.ListRequest{TxId: tx.id}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return tx.c.service.scanKeys(req.(*pb.ListRequest).TxId, after, count)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.([]string), nil
+}
+
+// update runs f in a transaction of writer, it's authorized as a batch of
+// ops on table, f may be run again if writer retries the transaction
+func (c *localClient) update(ctx context.Context, table kvzoo.TableName, ops []*pb.BatchOperation, f func(tx kvzoo.Transaction) error) error {
+	if c.writer == nil {
+		return errNoWriter
+	}
+
+	_, err := c.invoke(ctx, "Batch", &pb.BatchRequest{TableName: string(table), Operations: ops}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		if err := c.write(table, f); err != nil {
+			return nil, err
+		}
+		return &pb.BatchReply{}, nil
+	})
+	return err
+}
+
+func (c *localClient) write(table kvzoo.TableName, f func(tx kvzoo.Transaction) error) error {
+	if u, ok := c.writer.(updater); ok {
+		return u.Update(table, f)
+	}
+	t, err := c.writer.CreateOrGetTable(table)
+	if err != nil {
+		return err
+	}
+	return kvzoo.Update(t, f)
+}
+
+// put adds key or updates it if it exists
+func put(tx kvzoo.Transaction, key string, value []byte) error {
+	if _, err := tx.Get(key); err == kvzoo.ErrNotFound {
+		return tx.Add(key, value)
+	} else if err != nil {
+		return err
+	}
+	return tx.Update(key, value)
+}
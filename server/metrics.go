@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+)
+
+const kvsMethodPrefix = "/pb.KVS/"
+
+type serverMetrics struct {
+	registerer prometheus.Registerer
+	collectors []prometheus.Collector
+	requests   *prometheus.CounterVec
+	latency    *prometheus.HistogramVec
+	txDuration *prometheus.HistogramVec
+	slowTxs    prometheus.Counter
+}
+
+// newServerMetrics registers metrics of service, registered collectors are
+// unregistered if any of them fails
+func newServerMetrics(registerer prometheus.Registerer, service *KVService) (*serverMetrics, error) {
+	m := &serverMetrics{
+		registerer: registerer,
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "kvzoo_server_requests_total",
+			Help: "Requests handled by kv service.",
+		}, []string{"method", "code"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "kvzoo_server_request_duration_seconds",
+			Help: "Time spent on handling requests of kv service.",
+		}, []string{"method"}),
+		txDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "kvzoo_server_transaction_duration_seconds",
+			Help: "Time from transaction begins to it's committed or rolled back.",
+		}, []string{"result"}),
+		slowTxs: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "kvzoo_server_slow_transactions_total",
+			Help: "Transactions reported as opened longer than the slow threshold.",
+		}),
+	}
+
+	collectors := []prometheus.Collector{
+		m.requests,
+		m.latency,
+		m.txDuration,
+		m.slowTxs,
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "kvzoo_server_open_transactions",
+			Help: "Transactions opened currently.",
+		}, func() float64 {
+			return float64(service.openTxCount())
+		}),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "kvzoo_server_max_open_transactions",
+			Help: "Max transactions allowed to open at the same time.",
+		}, func() float64 {
+			return float64(atomic.LoadInt64(&service.maxOpenTxCount))
+		}),
+	}
+	if _, ok := service.db.(kvzoo.DBStatsReporter); ok {
+		collectors = append(collectors, &dbStatsCollector{service: service})
+	}
+
+	for _, c := range collectors {
+		if err := registerer.Register(c); err != nil {
+			m.unregister()
+			return nil, err
+		}
+		m.collectors = append(m.collectors, c)
+	}
+	return m, nil
+}
+
+func (m *serverMetrics) unregister() {
+	for _, c := range m.collectors {
+		m.registerer.Unregister(c)
+	}
+	m.collectors = nil
+}
+
+func (m *serverMetrics) observe(method string, start time.Time, err error) {
+	if strings.HasPrefix(method, kvsMethodPrefix) == false {
+		return
+	}
+	method = strings.TrimPrefix(method, kvsMethodPrefix)
+	m.requests.WithLabelValues(method, status.Code(err).String()).Inc()
+	m.latency.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+func (m *serverMetrics) observeTx(tx *openedTx, result string) {
+	if m != nil {
+		m.txDuration.WithLabelValues(result).Observe(time.Since(tx.begin).Seconds())
+	}
+}
+
+func (m *serverMetrics) observeSlowTx() {
+	if m != nil {
+		m.slowTxs.Inc()
+	}
+}
+
+func (m *serverMetrics) unaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.observe(info.FullMethod, start, err)
+	return resp, err
+}
+
+func (m *serverMetrics) streamInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, ss)
+	m.observe(info.FullMethod, start, err)
+	return err
+}
+
+var (
+	dbFileSizeDesc      = prometheus.NewDesc("kvzoo_db_file_size_bytes", "Size of the db file.", nil, nil)
+	dbFreePagesDesc     = prometheus.NewDesc("kvzoo_db_free_pages", "Pages on the freelist.", nil, nil)
+	dbPendingPagesDesc  = prometheus.NewDesc("kvzoo_db_pending_pages", "Pending pages on the freelist.", nil, nil)
+	dbFreeAllocDesc     = prometheus.NewDesc("kvzoo_db_free_alloc_bytes", "Bytes allocated in free pages.", nil, nil)
+	dbFreelistInuseDesc = prometheus.NewDesc("kvzoo_db_freelist_inuse_bytes", "Bytes used by the freelist.", nil, nil)
+	dbOpenTxsDesc       = prometheus.NewDesc("kvzoo_db_open_read_transactions", "Read transactions opened in db.", nil, nil)
+	dbTxsDesc           = prometheus.NewDesc("kvzoo_db_read_transactions_total", "Read transactions started since db is opened.", nil, nil)
+	dbPageCountDesc     = prometheus.NewDesc("kvzoo_db_page_allocations_total", "Page allocations since db is opened.", nil, nil)
+	dbPageAllocDesc     = prometheus.NewDesc("kvzoo_db_page_alloc_bytes_total", "Bytes allocated for pages since db is opened.", nil, nil)
+)
+
+// dbStatsCollector reads the statistics of db at each scrape
+type dbStatsCollector struct {
+	service *KVService
+}
+
+func (c *dbStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{dbFileSizeDesc, dbFreePagesDesc, dbPendingPagesDesc, dbFreeAllocDesc,
+		dbFreelistInuseDesc, dbOpenTxsDesc, dbTxsDesc, dbPageCountDesc, dbPageAllocDesc} {
+		ch <- desc
+	}
+}
+
+func (c *dbStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.service.db.(kvzoo.DBStatsReporter).DBStats()
+	if err != nil {
+		return
+	}
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge(dbFileSizeDesc, float64(stats.FileSize))
+	gauge(dbFreePagesDesc, float64(stats.FreePageN))
+	gauge(dbPendingPagesDesc, float64(stats.PendingPageN))
+	gauge(dbFreeAllocDesc, float64(stats.FreeAlloc))
+	gauge(dbFreelistInuseDesc, float64(stats.FreelistInuse))
+	gauge(dbOpenTxsDesc, float64(stats.OpenTxN))
+	counter(dbTxsDesc, float64(stats.TxN))
+	counter(dbPageCountDesc, float64(stats.PageCount))
+	counter(dbPageAllocDesc, float64(stats.PageAlloc))
+}
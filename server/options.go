@@ -0,0 +1,147 @@
+package server
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/tracing"
+)
+
+// certificate and ca files are reloaded once they are modified
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	//if set, clients must present certificate signed by the ca
+	ClientCAFile string
+}
+
+type options struct {
+	maxOpenTxCount int
+	slowThreshold  time.Duration
+	tls            *TLSConfig
+	authenticators []Authenticator
+	acl            []ACLRule
+	destroyGuard   DestroyGuard
+	auditor        Auditor
+	auditMutations bool
+	metrics        prometheus.Registerer
+	tracer         *tracing.Tracer
+	gatewayAddr    string
+	respAddr       string
+	respDatabases  map[int]kvzoo.TableName
+	writer         kvzoo.DB
+}
+
+type Option func(*options)
+
+func defaultOptions() options {
+	return options{
+		maxOpenTxCount: MaxOpenTxCount,
+		slowThreshold:  DefaultSlowTxThreshold,
+		auditor:        logAuditor{},
+	}
+}
+
+// WithMaxOpenTxCount limits the transactions which are opened at the same time
+func WithMaxOpenTxCount(count int) Option {
+	return func(o *options) {
+		o.maxOpenTxCount = count
+	}
+}
+
+// WithSlowTxThreshold reports transactions which are opened longer than
+// threshold in server log, since they block other writers, <= 0 disables
+// reporting
+func WithSlowTxThreshold(threshold time.Duration) Option {
+	return func(o *options) {
+		o.slowThreshold = threshold
+	}
+}
+
+// WithAuthenticator enables authentication, authenticators are tried in
+// order until one of them finds out the identity of caller. requests are
+// checked against acl, which denies everything by default
+func WithAuthenticator(authenticators ...Authenticator) Option {
+	return func(o *options) {
+		o.authenticators = append(o.authenticators, authenticators...)
+	}
+}
+
+// WithACL sets the rules to authorize the authenticated callers
+func WithACL(acl []ACLRule) Option {
+	return func(o *options) {
+		o.acl = acl
+	}
+}
+
+// WithDestroyGuard disables Destroy or requires confirmation, and backs up
+// db before it's destroyed
+func WithDestroyGuard(guard DestroyGuard) Option {
+	return func(o *options) {
+		o.destroyGuard = guard
+	}
+}
+
+// WithAuditor records destructive calls and changes of committed
+// transactions with auditor, by default only destructive calls are recorded
+// into server log
+func WithAuditor(auditor Auditor) Option {
+	return func(o *options) {
+		o.auditor = auditor
+		o.auditMutations = true
+	}
+}
+
+// WithMetrics registers metrics of server into registerer, they are
+// unregistered after server is stopped
+func WithMetrics(registerer prometheus.Registerer) Option {
+	return func(o *options) {
+		o.metrics = registerer
+	}
+}
+
+// WithTracer records spans of requests and transactions with tracer, spans
+// are children of the client spans propagated in request metadata
+func WithTracer(tracer *tracing.Tracer) Option {
+	return func(o *options) {
+		o.tracer = tracer
+	}
+}
+
+// WithGateway serves http and json gateway of the kv service on addr, with
+// same tls config and authentication as grpc
+func WithGateway(addr string) Option {
+	return func(o *options) {
+		o.gatewayAddr = addr
+	}
+}
+
+// WithRESP serves redis protocol on addr, with same tls config and
+// authentication as grpc, token is sent by AUTH. redis database n is mapped
+// to table databases[n], DefaultRESPDatabases is used if databases is empty
+func WithRESP(addr string, databases map[int]kvzoo.TableName) Option {
+	return func(o *options) {
+		o.respAddr = addr
+		o.respDatabases = databases
+	}
+}
+
+// WithWriter makes the gateways write through writer, which is usually a
+// client.Proxy whose master is the server, since writes to server itself
+// aren't replicated to slaves. without writer the gateways are read only.
+// writes are authorized by server with the identity of caller before they
+// are sent to writer, and audited with the identity of writer
+func WithWriter(writer kvzoo.DB) Option {
+	return func(o *options) {
+		o.writer = writer
+	}
+}
+
+// WithTLS makes server only accept tls connection
+func WithTLS(conf TLSConfig) Option {
+	return func(o *options) {
+		o.tls = &conf
+	}
+}
@@ -0,0 +1,872 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+const (
+	maxRESPArgs       = 1024 * 1024
+	maxRESPBulkSize   = 64 * 1024 * 1024
+	maxRESPInlineSize = 64 * 1024
+	//like redis, requests are limited before connection is authenticated
+	maxUnauthenticatedRESPArgs     = 10
+	maxUnauthenticatedRESPBulkSize = 16 * 1024
+	defaultScanCount               = 10
+	//cursors of SCAN kept by each connection, older ones are dropped
+	maxRESPScanCursors = 1024
+	//keys read in each step of KEYS
+	respKeysPageSize = 1000
+	//redis clients usually use database 0 to 15
+	defaultRESPDatabaseCount = 16
+)
+
+// DefaultRESPDatabases maps redis database n to table /redis/n
+func DefaultRESPDatabases() map[int]kvzoo.TableName {
+	databases := make(map[int]kvzoo.TableName, defaultRESPDatabaseCount)
+	for i := 0; i < defaultRESPDatabaseCount; i++ {
+		databases[i] = kvzoo.TableName(fmt.Sprintf("/redis/%d", i))
+	}
+	return databases
+}
+
+// respServer serves redis protocol, each redis database is a table, which
+// is chosen by SELECT with database index or table name. commands of
+// MULTI/EXEC run in one transaction, which is rolled back if any of them
+// fails. without writer, write commands get READONLY error like the ones
+// sent to a redis replica
+type respServer struct {
+	client    *localClient
+	auth      *authorizer
+	databases map[int]kvzoo.TableName
+	listener  net.Listener
+
+	lock   sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+}
+
+func newRESPServer(addr string, conf *TLSConfig, client *localClient, auth *authorizer, databases map[int]kvzoo.TableName) (*respServer, error) {
+	if len(databases) == 0 {
+		databases = DefaultRESPDatabases()
+	} else if _, ok := databases[0]; ok == false {
+		return nil, fmt.Errorf("redis database 0 isn't mapped to any table")
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if conf != nil {
+		tlsConf, err := newServerTLSConfig(conf)
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		listener = tls.NewListener(listener, tlsConf)
+	}
+
+	return &respServer{
+		client:    client,
+		auth:      auth,
+		databases: databases,
+		listener:  listener,
+		conns:     make(map[net.Conn]struct{}),
+	}, nil
+}
+
+func (s *respServer) serve() error {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.lock.Lock()
+			closed := s.closed
+			s.lock.Unlock()
+			if closed {
+				return nil
+			}
+			return err
+		}
+
+		s.lock.Lock()
+		if s.closed {
+			s.lock.Unlock()
+			conn.Close()
+			return nil
+		}
+		s.conns[conn] = struct{}{}
+		s.lock.Unlock()
+		go s.serveConn(conn)
+	}
+}
+
+func (s *respServer) close() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.closed = true
+	s.listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+}
+
+func (s *respServer) serveConn(conn net.Conn) {
+	defer func() {
+		conn.Close()
+		s.lock.Lock()
+		delete(s.conns, conn)
+		s.lock.Unlock()
+	}()
+
+	session := &respSession{
+		server: s,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+		table:  s.databases[0],
+	}
+	for {
+		args, err := session.readCommand()
+		if err != nil {
+			if err != io.EOF {
+				writeRESPValue(session.writer, respError("ERR "+err.Error()))
+				session.writer.Flush()
+			}
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		reply, quit := session.handle(args)
+		writeRESPValue(session.writer, reply)
+		if err := session.writer.Flush(); err != nil || quit {
+			return
+		}
+	}
+}
+
+// reply is one of respSimple, respError, int64, []byte and []interface{},
+// nil []byte is null bulk string
+type respSimple string
+type respError string
+
+func writeRESPValue(w *bufio.Writer, v interface{}) {
+	switch v := v.(type) {
+	case respSimple:
+		w.WriteString("+" + string(v) + "\r\n")
+	case respError:
+		w.WriteString("-" + strings.NewReplacer("\r", " ", "\n", " ").Replace(string(v)) + "\r\n")
+	case int64:
+		w.WriteString(":" + strconv.FormatInt(v, 10) + "\r\n")
+	case []byte:
+		if v == nil {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		w.WriteString("$" + strconv.Itoa(len(v)) + "\r\n")
+		w.Write(v)
+		w.WriteString("\r\n")
+	case []interface{}:
+		w.WriteString("*" + strconv.Itoa(len(v)) + "\r\n")
+		for _, e := range v {
+			writeRESPValue(w, e)
+		}
+	default:
+		panic(fmt.Sprintf("unknown resp value %T", v))
+	}
+}
+
+type respSession struct {
+	server *respServer
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+	table  kvzoo.TableName
+	token  string
+	//commands queued after MULTI
+	multi  bool
+	queued [][][]byte
+	//a queued command is invalid, EXEC will fail
+	dirty bool
+	//cursors returned by SCAN, which map to the last scanned key
+	cursors    map[int64]respCursor
+	nextCursor int64
+}
+
+type respCursor struct {
+	table kvzoo.TableName
+	key   string
+}
+
+// authenticated is true if server requires no token or AUTH succeeded
+func (s *respSession) authenticated() bool {
+	return s.server.auth == nil || s.token != ""
+}
+
+func (s *respSession) readLine() (string, error) {
+	var line []byte
+	for {
+		data, err := s.reader.ReadSlice('\n')
+		line = append(line, data...)
+		if len(line) > maxRESPInlineSize {
+			return "", fmt.Errorf("Protocol error: too big inline request")
+		} else if err == nil {
+			return strings.TrimRight(string(line), "\r\n"), nil
+		} else if err != bufio.ErrBufferFull {
+			return "", err
+		}
+	}
+}
+
+// readCommand reads command in array of bulk strings, or inline command
+// which is sent by telnet, size of command is limited until connection is
+// authenticated
+func (s *respSession) readCommand() ([][]byte, error) {
+	maxArgs, maxBulkSize := maxRESPArgs, maxRESPBulkSize
+	if s.authenticated() == false {
+		maxArgs, maxBulkSize = maxUnauthenticatedRESPArgs, maxUnauthenticatedRESPBulkSize
+	}
+
+	line, err := s.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(line, "*") == false {
+		var args [][]byte
+		for _, field := range strings.Fields(line) {
+			args = append(args, []byte(field))
+		}
+		return args, nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxArgs {
+		return nil, fmt.Errorf("Protocol error: invalid multibulk length")
+	}
+	args := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		line, err := s.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.HasPrefix(line, "$") == false {
+			return nil, fmt.Errorf("Protocol error: expected '$', got '%s'", line)
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulkSize {
+			return nil, fmt.Errorf("Protocol error: invalid bulk length")
+		}
+		arg := make([]byte, size+2)
+		if _, err := io.ReadFull(s.reader, arg); err != nil {
+			return nil, err
+		}
+		args = append(args, arg[:size])
+	}
+	return args, nil
+}
+
+// same as grpc requests, token is sent as authorization metadata
+func (s *respSession) context() context.Context {
+	ctx := context.Background()
+	if s.token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "Bearer "+s.token))
+	}
+	p := &peer.Peer{Addr: peerAddr(s.conn.RemoteAddr().String())}
+	if conn, ok := s.conn.(*tls.Conn); ok {
+		p.AuthInfo = credentials.TLSInfo{State: conn.ConnectionState()}
+	}
+	return peer.NewContext(ctx, p)
+}
+
+func respErrorOf(err error) respError {
+	switch status.Code(err) {
+	case codes.Unauthenticated:
+		return respError("NOAUTH Authentication required.")
+	case codes.PermissionDenied:
+		return respError("NOPERM " + status.Convert(err).Message())
+	default:
+		if st, ok := status.FromError(err); ok {
+			return respError("ERR " + st.Message())
+		}
+		return respError("ERR " + err.Error())
+	}
+}
+
+func wrongArgs(name string) respError {
+	return respError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+}
+
+// handle returns the reply of command, and whether connection should be
+// closed
+func (s *respSession) handle(args [][]byte) (interface{}, bool) {
+	name := strings.ToUpper(string(args[0]))
+	if s.multi {
+		switch name {
+		case "EXEC":
+			return s.exec(), false
+		case "DISCARD":
+			s.resetMulti()
+			return respSimple("OK"), false
+		case "MULTI":
+			return respError("ERR MULTI calls can not be nested"), false
+		case "SELECT", "AUTH", "QUIT":
+			s.dirty = true
+			return respError(fmt.Sprintf("ERR %s isn't allowed in MULTI", name)), false
+		}
+		if respWriteCommands[name] && s.server.client.writer == nil {
+			s.dirty = true
+			return respReadOnlyError, false
+		}
+		cmd, ok := respCommands[name]
+		if ok == false {
+			s.dirty = true
+			return respError(fmt.Sprintf("ERR unknown command '%s'", args[0])), false
+		} else if cmd.valid(len(args)) == false {
+			s.dirty = true
+			return wrongArgs(name), false
+		}
+		s.queued = append(s.queued, args)
+		return respSimple("QUEUED"), false
+	}
+
+	switch name {
+	case "PING":
+		if len(args) > 1 {
+			return args[1], false
+		}
+		return respSimple("PONG"), false
+	case "ECHO":
+		if len(args) != 2 {
+			return wrongArgs(name), false
+		}
+		return args[1], false
+	case "QUIT":
+		return respSimple("OK"), true
+	case "COMMAND":
+		return []interface{}{}, false
+	case "CLIENT":
+		return respSimple("OK"), false
+	case "AUTH":
+		return s.authenticate(args), false
+	case "SELECT":
+		return s.selectTable(args), false
+	case "MULTI":
+		s.multi = true
+		return respSimple("OK"), false
+	case "EXEC", "DISCARD":
+		return respError(fmt.Sprintf("ERR %s without MULTI", name)), false
+	}
+	if respWriteCommands[name] && s.server.client.writer == nil {
+		return respReadOnlyError, false
+	}
+
+	cmd, ok := respCommands[name]
+	if ok == false {
+		return respError(fmt.Sprintf("ERR unknown command '%s'", args[0])), false
+	} else if cmd.valid(len(args)) == false {
+		return wrongArgs(name), false
+	}
+	replies, err := s.run([][][]byte{args})
+	if err != nil {
+		return respErrorOf(err), false
+	}
+	return replies[0], false
+}
+
+// AUTH [username] password, password is the token
+func (s *respSession) authenticate(args [][]byte) interface{} {
+	if len(args) != 2 && len(args) != 3 {
+		return wrongArgs("AUTH")
+	}
+	if s.server.auth == nil {
+		return respError("ERR AUTH called without any password configured")
+	}
+
+	token := string(args[len(args)-1])
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+	if _, err := s.server.auth.authenticate(ctx); err != nil {
+		return respError("WRONGPASS invalid username-password pair")
+	}
+	s.token = token
+	return respSimple("OK")
+}
+
+// SELECT index or SELECT table
+func (s *respSession) selectTable(args [][]byte) interface{} {
+	if len(args) != 2 {
+		return wrongArgs("SELECT")
+	}
+	arg := string(args[1])
+	if strings.HasPrefix(arg, "/") {
+		table, err := kvzoo.NewTableName(arg)
+		if err != nil {
+			return respError("ERR " + err.Error())
+		} else if table == kvzoo.Root {
+			return respError("ERR root table has no keys")
+		}
+		s.table = table
+		return respSimple("OK")
+	}
+
+	index, err := strconv.Atoi(arg)
+	if err != nil {
+		return respError("ERR value is not an integer or out of range")
+	}
+	table, ok := s.server.databases[index]
+	if ok == false {
+		return respError("ERR DB index is out of range")
+	}
+	s.table = table
+	return respSimple("OK")
+}
+
+func (s *respSession) resetMulti() {
+	s.multi = false
+	s.queued = nil
+	s.dirty = false
+}
+
+func (s *respSession) exec() interface{} {
+	queued, dirty := s.queued, s.dirty
+	s.resetMulti()
+	if dirty {
+		return respError("EXECABORT Transaction discarded because of previous errors.")
+	}
+	if len(queued) == 0 {
+		return []interface{}{}
+	}
+
+	replies, err := s.run(queued)
+	if err != nil {
+		return respError("EXECABORT Transaction discarded because of: " + string(respErrorOf(err)))
+	}
+	return replies
+}
+
+// run runs commands in one transaction of current table, commands with
+// writes run in transaction of writer, reads of table which doesn't exist
+// get empty result
+func (s *respSession) run(commands [][][]byte) ([]interface{}, error) {
+	var replies []interface{}
+	//writer may run f again, replies of the last run are returned
+	f := func(tx respTx) error {
+		replies = make([]interface{}, 0, len(commands))
+		for _, args := range commands {
+			reply, err := respCommands[strings.ToUpper(string(args[0]))].run(s, tx, args)
+			if err != nil {
+				return err
+			}
+			replies = append(replies, reply)
+		}
+		return nil
+	}
+
+	//ops have the type and first key of commands, which are authorized
+	ops := make([]*pb.BatchOperation, 0, len(commands))
+	for _, args := range commands {
+		op := &pb.BatchOperation{Type: respCommands[strings.ToUpper(string(args[0]))].op}
+		if op.Type != pb.BatchOperation_LIST {
+			op.Key = string(args[1])
+		}
+		ops = append(ops, op)
+	}
+	batch := &pb.BatchRequest{Operations: ops}
+	if batch.HasWrites() {
+		err := s.server.client.update(s.context(), s.table, ops, func(tx kvzoo.Transaction) error {
+			return f(writerTx{tx})
+		})
+		if err != nil {
+			return nil, err
+		}
+		return replies, nil
+	}
+
+	err := s.server.client.view(s.context(), s.table, func(tx *localTx) error {
+		return f(readOnlyTx{tx})
+	})
+	if err != nil && status.Code(err) == codes.NotFound {
+		err = f(emptyTx{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	return replies, nil
+}
+
+type respTx interface {
+	get(key string) ([]byte, error)
+	//scan returns at most count keys after key in order
+	scan(after string, count int) ([]string, error)
+	put(key string, value []byte) error
+	delete(key string) error
+}
+
+// readOnlyTx serves commands without writes in transaction of server
+type readOnlyTx struct {
+	*localTx
+}
+
+func (tx readOnlyTx) put(key string, value []byte) error {
+	return ErrReadOnlyTx
+}
+
+func (tx readOnlyTx) delete(key string) error {
+	return ErrReadOnlyTx
+}
+
+// writerTx serves commands with writes in transaction of writer
+type writerTx struct {
+	tx kvzoo.Transaction
+}
+
+func (tx writerTx) get(key string) ([]byte, error) {
+	return tx.tx.Get(key)
+}
+
+func (tx writerTx) scan(after string, count int) ([]string, error) {
+	return scanTxKeys(tx.tx, after, count)
+}
+
+func (tx writerTx) put(key string, value []byte) error {
+	return put(tx.tx, key, value)
+}
+
+func (tx writerTx) delete(key string) error {
+	return tx.tx.Delete(key)
+}
+
+// emptyTx serves reads of table which doesn't exist
+type emptyTx struct{}
+
+func (tx emptyTx) get(key string) ([]byte, error) {
+	return nil, kvzoo.ErrNotFound
+}
+
+func (tx emptyTx) scan(after string, count int) ([]string, error) {
+	return nil, nil
+}
+
+func (tx emptyTx) put(key string, value []byte) error {
+	return ErrReadOnlyTx
+}
+
+func (tx emptyTx) delete(key string) error {
+	return ErrReadOnlyTx
+}
+
+type respCommand struct {
+	//count of arguments including command name, negative means at least
+	arity int
+	//type of batch operation which the command is authorized as
+	op  pb.BatchOperation_Type
+	run func(s *respSession, tx respTx, args [][]byte) (interface{}, error)
+}
+
+func (c *respCommand) valid(argc int) bool {
+	if c.arity < 0 {
+		return argc >= -c.arity
+	}
+	return argc == c.arity
+}
+
+var respCommands = map[string]*respCommand{
+	"GET":    {2, pb.BatchOperation_GET, respGet},
+	"SET":    {-3, pb.BatchOperation_UPDATE, respSet},
+	"DEL":    {-2, pb.BatchOperation_DELETE, respDel},
+	"EXISTS": {-2, pb.BatchOperation_GET, respExists},
+	"KEYS":   {2, pb.BatchOperation_LIST, respKeys},
+	"SCAN":   {-2, pb.BatchOperation_LIST, respScan},
+}
+
+var respReadOnlyError = respError("READONLY You can't write against a read only replica.")
+
+// write commands of strings and keys which are refused without writer, the
+// ones which aren't in respCommands are unknown with writer
+var respWriteCommands = map[string]bool{
+	"SET":      true,
+	"SETNX":    true,
+	"SETEX":    true,
+	"PSETEX":   true,
+	"MSET":     true,
+	"MSETNX":   true,
+	"GETSET":   true,
+	"APPEND":   true,
+	"INCR":     true,
+	"INCRBY":   true,
+	"DECR":     true,
+	"DECRBY":   true,
+	"DEL":      true,
+	"UNLINK":   true,
+	"RENAME":   true,
+	"FLUSHDB":  true,
+	"FLUSHALL": true,
+}
+
+func exists(tx respTx, key string) (bool, error) {
+	if _, err := tx.get(key); err == kvzoo.ErrNotFound {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func respGet(s *respSession, tx respTx, args [][]byte) (interface{}, error) {
+	value, err := tx.get(string(args[1]))
+	if err == kvzoo.ErrNotFound {
+		return []byte(nil), nil
+	} else if err != nil {
+		return nil, err
+	} else if value == nil {
+		value = []byte{}
+	}
+	return value, nil
+}
+
+// SET key value [NX|XX], expiration isn't supported
+func respSet(s *respSession, tx respTx, args [][]byte) (interface{}, error) {
+	key := string(args[1])
+	var nx, xx bool
+	for _, arg := range args[3:] {
+		switch strings.ToUpper(string(arg)) {
+		case "NX":
+			nx = true
+		case "XX":
+			xx = true
+		default:
+			return respError("ERR syntax error"), nil
+		}
+	}
+
+	if nx || xx {
+		found, err := exists(tx, key)
+		if err != nil {
+			return nil, err
+		} else if (nx && found) || (xx && found == false) {
+			return []byte(nil), nil
+		}
+	}
+	if err := tx.put(key, args[2]); err != nil {
+		return nil, err
+	}
+	return respSimple("OK"), nil
+}
+
+func respDel(s *respSession, tx respTx, args [][]byte) (interface{}, error) {
+	var count int64
+	for _, arg := range args[1:] {
+		key := string(arg)
+		if found, err := exists(tx, key); err != nil {
+			return nil, err
+		} else if found {
+			if err := tx.delete(key); err != nil {
+				return nil, err
+			}
+			count += 1
+		}
+	}
+	return count, nil
+}
+
+func respExists(s *respSession, tx respTx, args [][]byte) (interface{}, error) {
+	var count int64
+	for _, arg := range args[1:] {
+		if found, err := exists(tx, string(arg)); err != nil {
+			return nil, err
+		} else if found {
+			count += 1
+		}
+	}
+	return count, nil
+}
+
+func respKeys(s *respSession, tx respTx, args [][]byte) (interface{}, error) {
+	pattern := string(args[1])
+	reply := []interface{}{}
+	var after string
+	for {
+		keys, err := tx.scan(after, respKeysPageSize)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if globMatch(pattern, key) {
+				reply = append(reply, []byte(key))
+			}
+		}
+		if len(keys) < respKeysPageSize {
+			return reply, nil
+		}
+		after = keys[len(keys)-1]
+	}
+}
+
+// SCAN cursor [MATCH pattern] [COUNT count], cursor is kept by connection
+// with the last returned key, so each call only reads the next count keys.
+// keys added or deleted between calls may be missed like redis
+func respScan(s *respSession, tx respTx, args [][]byte) (interface{}, error) {
+	cursor, err := strconv.ParseInt(string(args[1]), 10, 64)
+	if err != nil || cursor < 0 {
+		return respError("ERR invalid cursor"), nil
+	}
+	pattern, count := "*", defaultScanCount
+	for i := 2; i < len(args); i += 2 {
+		if i+1 == len(args) {
+			return respError("ERR syntax error"), nil
+		}
+		switch strings.ToUpper(string(args[i])) {
+		case "MATCH":
+			pattern = string(args[i+1])
+		case "COUNT":
+			if count, err = strconv.Atoi(string(args[i+1])); err != nil || count < 1 || count > maxRESPArgs {
+				return respError("ERR syntax error"), nil
+			}
+		default:
+			return respError("ERR syntax error"), nil
+		}
+	}
+
+	var after string
+	if cursor != 0 {
+		c, ok := s.cursors[cursor]
+		if ok == false || c.table != s.table {
+			return respError("ERR invalid cursor"), nil
+		}
+		after = c.key
+	}
+
+	//one more key is read to know whether scan is finished
+	keys, err := tx.scan(after, count+1)
+	if err != nil {
+		return nil, err
+	}
+	var next int64
+	if len(keys) > count {
+		keys = keys[:count]
+		next = s.saveCursor(keys[count-1])
+	}
+	if cursor != 0 {
+		delete(s.cursors, cursor)
+	}
+
+	matched := []interface{}{}
+	for _, key := range keys {
+		if globMatch(pattern, key) {
+			matched = append(matched, []byte(key))
+		}
+	}
+	return []interface{}{[]byte(strconv.FormatInt(next, 10)), matched}, nil
+}
+
+// saveCursor returns a new cursor which points to key of current table, the
+// oldest cursor is dropped if there are too many
+func (s *respSession) saveCursor(key string) int64 {
+	if s.cursors == nil {
+		s.cursors = make(map[int64]respCursor)
+	} else if len(s.cursors) >= maxRESPScanCursors {
+		oldest := s.nextCursor
+		for cursor := range s.cursors {
+			if cursor < oldest {
+				oldest = cursor
+			}
+		}
+		delete(s.cursors, oldest)
+	}
+	s.nextCursor += 1
+	s.cursors[s.nextCursor] = respCursor{
+		table: s.table,
+		key:   key,
+	}
+	return s.nextCursor
+}
+
+// globMatch matches s with redis glob style pattern, which supports *, ?,
+// [abc], [^abc], [a-z] and \ to escape special character
+func globMatch(pattern, s string) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatch(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := strings.IndexByte(pattern[1:], ']')
+			if end == -1 {
+				return false
+			}
+			class := pattern[1 : end+1]
+			pattern = pattern[end+2:]
+			if matchClass(class, s[0]) == false {
+				return false
+			}
+			s = s[1:]
+		default:
+			if pattern[0] == '\\' && len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+func matchClass(class string, c byte) bool {
+	negate := strings.HasPrefix(class, "^")
+	if negate {
+		class = class[1:]
+	}
+	matched := false
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			lo, hi := class[i], class[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			i += 2
+		} else if class[i] == c {
+			matched = true
+		}
+	}
+	return matched != negate
+}
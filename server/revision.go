@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+// applied writes after a missing one are kept until the missing one comes,
+// if too many are kept, they are dropped and slave looks older than it is
+// until it's synced again
+const maxPendingRevisions = 10000
+
+// revisions numbers writes committed by server, and records writes of master
+// replicated to server by proxy, so staleness of slave is measured by the
+// writes of master from all the clients. all the replicated writes until
+// applied are in the server, applied is unknown until proxy finds the
+// server has same checksum with master
+type revisions struct {
+	lock    sync.Mutex
+	epoch   int64
+	current int64
+
+	masterEpoch int64
+	applied     int64
+	known       bool
+	pending     map[int64]struct{}
+	//incremented by reset, sync based on checksum before reset is ignored
+	resets int64
+}
+
+func newRevisions() *revisions {
+	r := &revisions{}
+	r.reset()
+	return r
+}
+
+// reset starts new epoch, since data is replaced, it's called when service
+// starts, restores or destroys
+func (r *revisions) reset() {
+	epoch := time.Now().UnixNano()
+	if epoch <= r.epoch {
+		epoch = r.epoch + 1
+	}
+	r.epoch = epoch
+	r.current = 0
+	r.masterEpoch = 0
+	r.applied = 0
+	r.known = false
+	r.pending = make(map[int64]struct{})
+	r.resets += 1
+}
+
+func (r *revisions) resetWithLock() {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.reset()
+}
+
+// next numbers a write committed by server itself
+func (r *revisions) next() *pb.Revision {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.current += 1
+	return &pb.Revision{
+		Epoch:  r.epoch,
+		Number: r.current,
+	}
+}
+
+// commit numbers the write, or records it if it's replicated from master
+func (r *revisions) commit(replicated *pb.Revision) *pb.Revision {
+	if replicated == nil {
+		return r.next()
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.switchMaster(replicated.Epoch)
+	if r.known && replicated.Number <= r.applied {
+		return nil
+	}
+	if len(r.pending) >= maxPendingRevisions {
+		r.pending = make(map[int64]struct{})
+	}
+	r.pending[replicated.Number] = struct{}{}
+	r.advance()
+	return nil
+}
+
+// revisions of old master are meaningless to new master
+func (r *revisions) switchMaster(epoch int64) {
+	if r.masterEpoch != epoch {
+		r.masterEpoch = epoch
+		r.applied = 0
+		r.known = false
+		r.pending = make(map[int64]struct{})
+	}
+}
+
+func (r *revisions) advance() {
+	for r.known {
+		if _, ok := r.pending[r.applied+1]; ok == false {
+			return
+		}
+		delete(r.pending, r.applied+1)
+		r.applied += 1
+	}
+}
+
+// sync records that writes of master until revision are applied, since
+// server had the same checksum with master at the revision
+func (r *revisions) sync(master *pb.Revision, resets int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if resets != r.resets {
+		return
+	}
+
+	r.switchMaster(master.Epoch)
+	if r.known == false || r.applied < master.Number {
+		r.applied = master.Number
+		r.known = true
+	}
+	for number := range r.pending {
+		if number <= r.applied {
+			delete(r.pending, number)
+		}
+	}
+	r.advance()
+}
+
+// get returns revision of server, writes of master applied which is nil if
+// unknown, and count of resets
+func (r *revisions) get() (*pb.Revision, *pb.Revision, int64) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	revision := &pb.Revision{
+		Epoch:  r.epoch,
+		Number: r.current,
+	}
+	if r.known == false {
+		return revision, nil, r.resets
+	}
+	return revision, &pb.Revision{
+		Epoch:  r.masterEpoch,
+		Number: r.applied,
+	}, r.resets
+}
+
+func (s *KVService) Revision(ctx context.Context, in *pb.RevisionRequest) (*pb.RevisionReply, error) {
+	revision, applied, _ := s.revisions.get()
+	return &pb.RevisionReply{
+		Revision: revision,
+		Applied:  applied,
+	}, nil
+}
@@ -1,27 +1,43 @@
 package server
 
 import (
+	"crypto/tls"
+	"fmt"
 	"net"
+	"net/http"
+	"time"
+
+	"github.com/zdnscloud/cement/log"
 
 	"github.com/zdnscloud/kvzoo"
 	"github.com/zdnscloud/kvzoo/backend/bolt"
 	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/tracing"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 type KVGRPCServer struct {
 	service  *KVService
+	auth     *authorizer
+	health   *health.Server
 	server   *grpc.Server
 	listener net.Listener
+
+	gateway         *http.Server
+	gatewayListener net.Listener
+	resp            *respServer
 }
 
-func NewWithBoltDB(addr string, dbFilePath string) (*KVGRPCServer, error) {
+func NewWithBoltDB(addr string, dbFilePath string, opts ...Option) (*KVGRPCServer, error) {
 	db, err := bolt.New(dbFilePath)
 	if err != nil {
 		return nil, err
 	}
 
-	if s, err := New(addr, db); err == nil {
+	if s, err := New(addr, db, opts...); err == nil {
 		return s, err
 	} else {
 		db.Destroy()
@@ -29,29 +45,171 @@ func NewWithBoltDB(addr string, dbFilePath string) (*KVGRPCServer, error) {
 	}
 }
 
-func New(addr string, db kvzoo.DB) (*KVGRPCServer, error) {
-	server := grpc.NewServer()
+func New(addr string, db kvzoo.DB, opts ...Option) (*KVGRPCServer, error) {
+	options := defaultOptions()
+	for _, opt := range opts {
+		opt(&options)
+	}
 
-	service := newKVService(db)
-	pb.RegisterKVSServer(server, service)
+	var serverOptions []grpc.ServerOption
+	if options.tls != nil {
+		conf, err := newServerTLSConfig(options.tls)
+		if err != nil {
+			return nil, err
+		}
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewTLS(conf)))
+	}
+
+	service := newKVService(db, options)
+	var unaryInterceptors []grpc.UnaryServerInterceptor
+	var streamInterceptors []grpc.StreamServerInterceptor
+	var auth *authorizer
+	if len(options.authenticators) != 0 {
+		var err error
+		if auth, err = newAuthorizer(service, options.authenticators, options.acl); err != nil {
+			return nil, err
+		}
+		unaryInterceptors = append(unaryInterceptors, auth.unaryInterceptor)
+		streamInterceptors = append(streamInterceptors, auth.streamInterceptor)
+	} else if len(options.acl) != 0 {
+		return nil, fmt.Errorf("acl requires authenticator")
+	}
 
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return nil, err
 	}
 
+	//requests rejected by authorizer are traced and counted too
+	if options.tracer != nil {
+		unaryInterceptors = append([]grpc.UnaryServerInterceptor{tracing.UnaryServerInterceptor(options.tracer)}, unaryInterceptors...)
+		streamInterceptors = append([]grpc.StreamServerInterceptor{tracing.StreamServerInterceptor(options.tracer)}, streamInterceptors...)
+	}
+	if options.metrics != nil {
+		if service.metrics, err = newServerMetrics(options.metrics, service); err != nil {
+			listener.Close()
+			return nil, err
+		}
+		unaryInterceptors = append([]grpc.UnaryServerInterceptor{service.metrics.unaryInterceptor}, unaryInterceptors...)
+		streamInterceptors = append([]grpc.StreamServerInterceptor{service.metrics.streamInterceptor}, streamInterceptors...)
+	}
+	var unaryInterceptor grpc.UnaryServerInterceptor
+	if len(unaryInterceptors) != 0 {
+		unaryInterceptor = chainUnaryInterceptors(unaryInterceptors)
+		serverOptions = append(serverOptions,
+			grpc.UnaryInterceptor(unaryInterceptor),
+			grpc.StreamInterceptor(chainStreamInterceptors(streamInterceptors)))
+	}
+
+	local := &localClient{
+		service:     service,
+		interceptor: unaryInterceptor,
+		writer:      options.writer,
+	}
+	var gateway *http.Server
+	var gatewayListener net.Listener
+	if options.gatewayAddr != "" {
+		if gatewayListener, err = listenGateway(options.gatewayAddr, options.tls); err != nil {
+			listener.Close()
+			service.unregisterMetrics()
+			return nil, err
+		}
+		gateway = &http.Server{Handler: newGateway(local)}
+	}
+
+	var resp *respServer
+	if options.respAddr != "" {
+		if resp, err = newRESPServer(options.respAddr, options.tls, local, auth, options.respDatabases); err != nil {
+			listener.Close()
+			if gatewayListener != nil {
+				gatewayListener.Close()
+			}
+			service.unregisterMetrics()
+			return nil, err
+		}
+	}
+
+	server := grpc.NewServer(serverOptions...)
+	pb.RegisterKVSServer(server, service)
+	//empty service name stands for the whole server
+	health := health.NewServer()
+	healthpb.RegisterHealthServer(server, health)
+
+	go service.watchSlowTxs()
 	return &KVGRPCServer{
-		service:  service,
-		server:   server,
-		listener: listener,
+		service:         service,
+		auth:            auth,
+		health:          health,
+		server:          server,
+		listener:        listener,
+		gateway:         gateway,
+		gatewayListener: gatewayListener,
+		resp:            resp,
 	}, nil
 }
 
+func listenGateway(addr string, conf *TLSConfig) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if conf == nil {
+		return listener, nil
+	}
+
+	tlsConf, err := newServerTLSConfig(conf)
+	if err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return tls.NewListener(listener, tlsConf), nil
+}
+
 func (s *KVGRPCServer) Start() error {
+	if s.gateway != nil {
+		go func() {
+			if err := s.gateway.Serve(s.gatewayListener); err != http.ErrServerClosed {
+				log.Errorf("serve gateway failed:%v", err)
+			}
+		}()
+	}
+	if s.resp != nil {
+		go func() {
+			if err := s.resp.serve(); err != nil {
+				log.Errorf("serve redis protocol failed:%v", err)
+			}
+		}()
+	}
 	return s.server.Serve(s.listener)
 }
 
+// SetMaxOpenTxCount changes the transaction limit of a running server
+func (s *KVGRPCServer) SetMaxOpenTxCount(count int) {
+	s.service.setMaxOpenTxCount(count)
+}
+
+// SetSlowTxThreshold changes the threshold of reporting slow transactions
+func (s *KVGRPCServer) SetSlowTxThreshold(threshold time.Duration) {
+	s.service.setSlowTxThreshold(threshold)
+}
+
+// SetACL replaces the acl of a running server with authentication enabled
+func (s *KVGRPCServer) SetACL(acl []ACLRule) error {
+	if s.auth == nil {
+		return fmt.Errorf("authentication isn't enabled")
+	}
+	return s.auth.setACL(acl)
+}
+
 func (s *KVGRPCServer) Stop() error {
+	//let clients stop sending requests while draining
+	s.health.Shutdown()
+	if s.gateway != nil {
+		s.gateway.Close()
+	}
+	if s.resp != nil {
+		s.resp.close()
+	}
 	s.server.GracefulStop()
 	s.service.Close()
 	return nil
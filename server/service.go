@@ -3,65 +3,196 @@ package server
 import (
 	"context"
 	"fmt"
+	"io"
+	"sort"
 	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 
 	"github.com/zdnscloud/kvzoo"
 	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/tracing"
 )
 
 const MaxOpenTxCount = 2000
 
+var (
+	//client could retry later
+	ErrTooManyTransactions = status.Error(codes.ResourceExhausted, "too many transactions are opened")
+	//transaction is rolled back by server, client should replay the whole transaction
+	ErrInvalidTxID = status.Error(codes.Aborted, "invalid transaction id")
+	ErrReadOnlyTx  = status.Error(codes.FailedPrecondition, "transaction is read only")
+)
+
 type KVService struct {
-	db       kvzoo.DB
-	nextTxId int64
+	db             kvzoo.DB
+	nextTxId       int64
+	maxOpenTxCount int64
+	//nanoseconds, transactions opened longer are reported
+	slowThreshold  int64
+	destroyGuard   DestroyGuard
+	auditor        Auditor
+	auditMutations bool
+	metrics        *serverMetrics
+	tracer         *tracing.Tracer
+	revisions      *revisions
 
 	openedTables map[string]kvzoo.Table
 	tableLock    sync.RWMutex
 
-	openedTxs map[int64]kvzoo.Transaction
+	openedTxs map[int64]*openedTx
 	txLock    sync.RWMutex
+
+	stopWatch chan struct{}
 }
 
-func newKVService(db kvzoo.DB) *KVService {
+type openedTx struct {
+	kvzoo.Transaction
+	id       int64
+	table    string
+	readOnly bool
+	peer     string
+	identity string
+	begin    time.Time
+	//count of reads and writes, updated atomically
+	ops int64
+	//set by watchdog once the transaction is reported as slow
+	reported int32
+	//set once anything is written, updated atomically
+	written int32
+	//span from begin to commit or rollback of backend transaction
+	span *tracing.Span
+
+	lock      sync.Mutex
+	mutations []AuditEntry
+}
+
+func newKVService(db kvzoo.DB, options options) *KVService {
 	return &KVService{
-		db:           db,
-		nextTxId:     0,
-		openedTables: make(map[string]kvzoo.Table),
-		openedTxs:    make(map[int64]kvzoo.Transaction),
+		db:             db,
+		nextTxId:       0,
+		maxOpenTxCount: int64(options.maxOpenTxCount),
+		slowThreshold:  int64(options.slowThreshold),
+		destroyGuard:   options.destroyGuard,
+		auditor:        options.auditor,
+		auditMutations: options.auditMutations,
+		tracer:         options.tracer,
+		revisions:      newRevisions(),
+		openedTables:   make(map[string]kvzoo.Table),
+		openedTxs:      make(map[int64]*openedTx),
+		stopWatch:      make(chan struct{}),
 	}
 }
 
+func (s *KVService) setMaxOpenTxCount(count int) {
+	atomic.StoreInt64(&s.maxOpenTxCount, int64(count))
+}
+
+// transactions left by clients hold the db lock, so they are rollbacked
+// before db is closed
 func (s *KVService) Close() {
+	close(s.stopWatch)
+	s.txLock.Lock()
+	s.dropTxs("closed")
+	s.txLock.Unlock()
 	s.db.Close()
+	if closer, ok := s.auditor.(io.Closer); ok {
+		closer.Close()
+	}
+	s.unregisterMetrics()
+}
+
+func (s *KVService) unregisterMetrics() {
+	if s.metrics != nil {
+		s.metrics.unregister()
+	}
+}
+
+func (s *KVService) openTxCount() int {
+	s.txLock.RLock()
+	defer s.txLock.RUnlock()
+	return len(s.openedTxs)
+}
+
+// txOwner returns table of transaction and identity which begins it
+func (s *KVService) txOwner(id int64) (string, string, bool) {
+	s.txLock.RLock()
+	defer s.txLock.RUnlock()
+	tx, ok := s.openedTxs[id]
+	if ok == false {
+		return "", "", false
+	}
+	return tx.table, tx.identity, true
 }
 
+// Checksum returns revision with checksum, writes numbered before the
+// revision are all included in the checksum. slave with the checksum of
+// master has applied the writes of master until its revision
 func (s *KVService) Checksum(ctx context.Context, in *pb.ChecksumRequest) (*pb.ChecksumReply, error) {
+	revision, _, resets := s.revisions.get()
 	cs, err := s.db.Checksum()
 	if err != nil {
 		return nil, err
-	} else {
-		return &pb.ChecksumReply{
-			Checksum: cs,
-		}, nil
 	}
+
+	if in.Revision != nil && in.MasterChecksum == cs {
+		s.revisions.sync(in.Revision, resets)
+	}
+	return &pb.ChecksumReply{
+		Checksum: cs,
+		Revision: revision,
+	}, nil
 }
 
 func (s *KVService) Destroy(ctx context.Context, in *pb.DestroyRequest) (*empty.Empty, error) {
+	err := s.destroy(in.Confirm)
+	s.audit(ctx, "destroy", kvzoo.Root, err)
+	if err != nil {
+		return nil, err
+	} else {
+		return &empty.Empty{}, nil
+	}
+}
+
+func (s *KVService) destroy(confirm string) error {
 	s.tableLock.Lock()
 	defer s.tableLock.Unlock()
-	s.openedTables = make(map[string]kvzoo.Table)
 	s.txLock.Lock()
 	defer s.txLock.Unlock()
-	s.openedTxs = make(map[int64]kvzoo.Transaction)
+	//commits are blocked by the lock, so checksum doesn't change
+	if err := s.checkDestroy(confirm); err != nil {
+		return err
+	}
 
+	s.dropTxs("destroyed")
+	s.openedTables = make(map[string]kvzoo.Table)
+	s.revisions.resetWithLock()
 	if err := s.db.Close(); err != nil {
-		return nil, err
+		return err
 	}
+	return s.db.Destroy()
+}
 
-	if err := s.db.Destroy(); err != nil {
+func (s *KVService) Compact(ctx context.Context, in *pb.CompactRequest) (*empty.Empty, error) {
+	db, ok := s.db.(kvzoo.Compactable)
+	if ok == false {
+		return nil, fmt.Errorf("db doesn't support compact")
+	}
+
+	s.tableLock.Lock()
+	defer s.tableLock.Unlock()
+	s.txLock.Lock()
+	defer s.txLock.Unlock()
+	if len(s.openedTxs) != 0 {
+		return nil, fmt.Errorf("%d transactions are opened, compact later", len(s.openedTxs))
+	}
+
+	if err := db.Compact(); err != nil {
 		return nil, err
 	} else {
 		return &empty.Empty{}, nil
@@ -88,21 +219,154 @@ func (s *KVService) CreateOrGetTable(ctx context.Context, in *pb.CreateOrGetTabl
 	return &empty.Empty{}, nil
 }
 
-func (s *KVService) DeleteTable(ctx context.Context, in *pb.DeleteTableRequest) (*empty.Empty, error) {
+func (s *KVService) DeleteTable(ctx context.Context, in *pb.DeleteTableRequest) (*pb.DeleteTableReply, error) {
+	err := s.deleteTable(in.Name)
+	s.audit(ctx, "delete table", in.Name, err)
+	if err != nil {
+		return nil, err
+	} else {
+		return &pb.DeleteTableReply{
+			Revision: s.revisions.commit(in.Revision),
+		}, nil
+	}
+}
+
+func (s *KVService) deleteTable(name string) error {
 	s.tableLock.Lock()
-	delete(s.openedTables, in.Name)
+	delete(s.openedTables, name)
 	s.tableLock.Unlock()
 
+	tn, err := kvzoo.NewTableName(name)
+	if err != nil {
+		return err
+	}
+	return s.db.DeleteTable(tn)
+}
+
+func (s *KVService) ListTables(ctx context.Context, in *pb.ListTablesRequest) (*pb.ListTablesReply, error) {
+	parent := kvzoo.TableName(kvzoo.Root)
+	if in.Parent != kvzoo.Root {
+		tn, err := kvzoo.NewTableName(in.Parent)
+		if err != nil {
+			return nil, err
+		}
+		parent = tn
+	}
+
+	tables, err := s.db.ListTables(parent, in.Recursive)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(tables))
+	for _, tn := range tables {
+		names = append(names, string(tn))
+	}
+	return &pb.ListTablesReply{
+		Names: names,
+	}, nil
+}
+
+func (s *KVService) TableExists(ctx context.Context, in *pb.TableExistsRequest) (*pb.TableExistsReply, error) {
 	tn, err := kvzoo.NewTableName(in.Name)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.db.DeleteTable(tn); err != nil {
+	exists, err := s.db.TableExists(tn)
+	if err != nil {
+		return nil, err
+	}
+	return &pb.TableExistsReply{
+		Exists: exists,
+	}, nil
+}
+
+func (s *KVService) Stats(ctx context.Context, in *pb.StatsRequest) (*pb.StatsReply, error) {
+	tn, err := kvzoo.NewTableName(in.Name)
+	if err != nil {
 		return nil, err
-	} else {
-		return &empty.Empty{}, nil
 	}
+
+	stats, err := s.db.Stats(tn)
+	if err != nil {
+		return nil, err
+	}
+
+	storage := &stats.Storage
+	return &pb.StatsReply{
+		KeyCount:        int64(stats.KeyCount),
+		KeySize:         stats.KeySize,
+		ValueSize:       stats.ValueSize,
+		ChildTableCount: int64(stats.ChildTableCount),
+		Storage: &pb.StorageStats{
+			BranchPageN:       int64(storage.BranchPageN),
+			BranchOverflowN:   int64(storage.BranchOverflowN),
+			LeafPageN:         int64(storage.LeafPageN),
+			LeafOverflowN:     int64(storage.LeafOverflowN),
+			KeyN:              int64(storage.KeyN),
+			Depth:             int64(storage.Depth),
+			BranchAlloc:       int64(storage.BranchAlloc),
+			BranchInuse:       int64(storage.BranchInuse),
+			LeafAlloc:         int64(storage.LeafAlloc),
+			LeafInuse:         int64(storage.LeafInuse),
+			BucketN:           int64(storage.BucketN),
+			InlineBucketN:     int64(storage.InlineBucketN),
+			InlineBucketInuse: int64(storage.InlineBucketInuse),
+		},
+	}, nil
+}
+
+func (s *KVService) MerkleTree(ctx context.Context, in *pb.MerkleTreeRequest) (*pb.MerkleTreeReply, error) {
+	db, ok := s.db.(kvzoo.Diffable)
+	if ok == false {
+		return nil, fmt.Errorf("db doesn't support diff")
+	}
+
+	tn, err := kvzoo.NewTableName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := db.MerkleTree(tn, in.Boundaries)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.MerkleTreeReply{
+		Checksum:   tree.Checksum,
+		Nodes:      tree.Nodes,
+		Boundaries: tree.Boundaries,
+	}, nil
+}
+
+func (s *KVService) KeyDigests(ctx context.Context, in *pb.KeyDigestsRequest) (*pb.KeyDigestsReply, error) {
+	db, ok := s.db.(kvzoo.Diffable)
+	if ok == false {
+		return nil, fmt.Errorf("db doesn't support diff")
+	}
+
+	tn, err := kvzoo.NewTableName(in.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	ranges := make([]kvzoo.KeyRange, 0, len(in.Ranges))
+	for _, r := range in.Ranges {
+		ranges = append(ranges, kvzoo.KeyRange{
+			Begin: r.Begin,
+			End:   r.End,
+		})
+	}
+
+	digests, err := db.KeyDigests(tn, ranges)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.KeyDigestsReply{
+		Digests: digests,
+	}, nil
 }
 
 func (s *KVService) BeginTransaction(ctx context.Context, in *pb.BeginTransactionRequest) (*pb.BeginTransactionReply, error) {
@@ -115,42 +379,119 @@ func (s *KVService) BeginTransaction(ctx context.Context, in *pb.BeginTransactio
 	}
 
 	s.txLock.RLock()
-	if len(s.openedTxs) > MaxOpenTxCount {
+	if int64(len(s.openedTxs)) > atomic.LoadInt64(&s.maxOpenTxCount) {
 		s.txLock.RUnlock()
-		return nil, fmt.Errorf("too many transactions are opened")
+		return nil, ErrTooManyTransactions
 	}
 	s.txLock.RUnlock()
 
-	tx, err := table.Begin()
+	//applied is got before and revision after the transaction begins, so
+	//the staleness of slave isn't underestimated
+	_, applied, _ := s.revisions.get()
+	_, span := s.tracer.Start(ctx, "db.Transaction")
+	span.SetAttribute("table", in.TableName)
+	var tx kvzoo.Transaction
+	var err error
+	if t, ok := table.(kvzoo.ReadOnlyTable); ok && in.ReadOnly {
+		span.SetAttribute("readOnly", true)
+		tx, err = t.BeginReadOnly()
+	} else {
+		//backend without read only transaction serves it as a normal one
+		tx, err = table.Begin()
+	}
 	if err != nil {
+		span.End(err)
 		return nil, err
 	}
 
 	id := atomic.AddInt64(&s.nextTxId, 1)
+	span.SetAttribute("txId", id)
+	opened := &openedTx{
+		Transaction: tx,
+		id:          id,
+		table:       in.TableName,
+		readOnly:    in.ReadOnly,
+		begin:       time.Now(),
+		span:        span,
+	}
+	opened.identity, _ = IdentityFromContext(ctx)
+	if p, ok := peer.FromContext(ctx); ok {
+		opened.peer = p.Addr.String()
+	}
 	s.txLock.Lock()
-	s.openedTxs[id] = tx
+	s.openedTxs[id] = opened
 	s.txLock.Unlock()
+	revision, _, _ := s.revisions.get()
 	return &pb.BeginTransactionReply{
-		TxId: id,
+		TxId:     id,
+		Revision: revision,
+		Applied:  applied,
+	}, nil
+}
+
+// dropTxs rolls back all the opened transactions, which are finished with
+// result like the ones rolled back by clients, it's called with txLock held
+func (s *KVService) dropTxs(result string) {
+	for _, tx := range s.openedTxs {
+		s.finishTx(tx, result, tx.Rollback())
+	}
+	s.openedTxs = make(map[int64]*openedTx)
+}
+
+func (s *KVService) finishTx(tx *openedTx, result string, err error) {
+	s.metrics.observeTx(tx, result)
+	s.reportFinishedSlowTx(tx, result)
+	tx.span.SetAttribute("result", result)
+	tx.span.End(err)
+}
+
+func (s *KVService) CommitTransaction(ctx context.Context, in *pb.CommitTransactionRequest) (*pb.CommitTransactionReply, error) {
+	revision, mutations, err := s.commitTx(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+
+	//audit is written without txLock, so its io doesn't block other requests
+	if len(mutations) != 0 {
+		now := time.Now()
+		for i := range mutations {
+			mutations[i].Time = now
+		}
+		s.writeAudit(mutations...)
+	}
+	return &pb.CommitTransactionReply{
+		Revision: revision,
 	}, nil
 }
 
-func (s *KVService) CommitTransaction(ctx context.Context, in *pb.CommitTransactionRequest) (*empty.Empty, error) {
+// commitTx commits transaction and returns its revision and mutations to
+// audit, revision is nil if nothing is written or it's replicated
+func (s *KVService) commitTx(ctx context.Context, in *pb.CommitTransactionRequest) (*pb.Revision, []AuditEntry, error) {
 	s.txLock.Lock()
 	defer s.txLock.Unlock()
 
 	tx, ok := s.openedTxs[in.TxId]
 	if ok == false {
-		return nil, fmt.Errorf("invalid transaction id")
+		return nil, nil, ErrInvalidTxID
 	}
 
+	_, span := s.tracer.Start(ctx, "db.Commit")
 	err := tx.Commit()
+	span.End(err)
 	delete(s.openedTxs, in.TxId)
 	if err != nil {
-		return nil, err
-	} else {
-		return &empty.Empty{}, nil
+		s.finishTx(tx, "failed", err)
+		return nil, nil, err
 	}
+	s.finishTx(tx, "commit", nil)
+
+	var revision *pb.Revision
+	if in.Revision != nil || atomic.LoadInt32(&tx.written) == 1 {
+		revision = s.revisions.commit(in.Revision)
+	}
+	tx.lock.Lock()
+	defer tx.lock.Unlock()
+	return revision, tx.mutations, nil
 }
 
 func (s *KVService) RollbackTransaction(ctx context.Context, in *pb.RollbackTransactionRequest) (*empty.Empty, error) {
@@ -159,11 +500,12 @@ func (s *KVService) RollbackTransaction(ctx context.Context, in *pb.RollbackTran
 
 	tx, ok := s.openedTxs[in.TxId]
 	if ok == false {
-		return nil, fmt.Errorf("invalid transaction id")
+		return nil, ErrInvalidTxID
 	}
 
 	err := tx.Rollback()
 	delete(s.openedTxs, in.TxId)
+	s.finishTx(tx, "rollback", err)
 	if err != nil {
 		return nil, err
 	} else {
@@ -177,9 +519,10 @@ func (s *KVService) Get(ctx context.Context, in *pb.GetRequest) (*pb.GetResponse
 
 	tx, ok := s.openedTxs[in.TxId]
 	if ok == false {
-		return nil, fmt.Errorf("invalid transaction id")
+		return nil, ErrInvalidTxID
 	}
 
+	atomic.AddInt64(&tx.ops, 1)
 	value, err := tx.Get(in.Key)
 	if err != nil {
 		return nil, err
@@ -196,9 +539,10 @@ func (s *KVService) List(ctx context.Context, in *pb.ListRequest) (*pb.ListRespo
 
 	tx, ok := s.openedTxs[in.TxId]
 	if ok == false {
-		return nil, fmt.Errorf("invalid transaction id")
+		return nil, ErrInvalidTxID
 	}
 
+	atomic.AddInt64(&tx.ops, 1)
 	values, err := tx.List()
 	if err != nil {
 		return nil, err
@@ -209,18 +553,80 @@ func (s *KVService) List(ctx context.Context, in *pb.ListRequest) (*pb.ListRespo
 	}, nil
 }
 
-func (s *KVService) Add(ctx context.Context, in *pb.AddRequest) (*empty.Empty, error) {
+// scanKeys returns at most count keys of transaction after key in order,
+// it has no rpc and serves SCAN of the redis gateway
+func (s *KVService) scanKeys(id int64, after string, count int) ([]string, error) {
 	s.txLock.RLock()
 	defer s.txLock.RUnlock()
 
-	tx, ok := s.openedTxs[in.TxId]
+	tx, ok := s.openedTxs[id]
 	if ok == false {
-		return nil, fmt.Errorf("invalid transaction id")
+		return nil, ErrInvalidTxID
 	}
 
+	atomic.AddInt64(&tx.ops, 1)
+	return scanTxKeys(tx.Transaction, after, count)
+}
+
+// scanTxKeys returns at most count keys of tx after key in order
+func scanTxKeys(tx kvzoo.Transaction, after string, count int) ([]string, error) {
+	if seekable, ok := tx.(kvzoo.SeekableTransaction); ok {
+		c := seekable.Cursor()
+		key, _ := c.Seek(after)
+		if key != "" && key == after {
+			key, _ = c.Next()
+		}
+		var keys []string
+		for ; key != "" && len(keys) < count; key, _ = c.Next() {
+			keys = append(keys, key)
+		}
+		return keys, nil
+	}
+
+	values, err := tx.List()
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for key := range values {
+		if key > after {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if len(keys) > count {
+		keys = keys[:count]
+	}
+	return keys, nil
+}
+
+// writableTx returns the opened transaction which isn't read only, txLock
+// should be held
+func (s *KVService) writableTx(id int64) (*openedTx, error) {
+	tx, ok := s.openedTxs[id]
+	if ok == false {
+		return nil, ErrInvalidTxID
+	} else if tx.readOnly {
+		return nil, ErrReadOnlyTx
+	}
+	return tx, nil
+}
+
+func (s *KVService) Add(ctx context.Context, in *pb.AddRequest) (*empty.Empty, error) {
+	s.txLock.RLock()
+	defer s.txLock.RUnlock()
+
+	tx, err := s.writableTx(in.TxId)
+	if err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&tx.ops, 1)
 	if err := tx.Add(in.Key, in.Value); err != nil {
 		return nil, err
 	} else {
+		atomic.StoreInt32(&tx.written, 1)
+		s.recordMutation(ctx, tx, "add", in.Key, in.Value)
 		return &empty.Empty{}, nil
 	}
 }
@@ -229,14 +635,17 @@ func (s *KVService) Delete(ctx context.Context, in *pb.DeleteRequest) (*empty.Em
 	s.txLock.RLock()
 	defer s.txLock.RUnlock()
 
-	tx, ok := s.openedTxs[in.TxId]
-	if ok == false {
-		return nil, fmt.Errorf("invalid transaction id")
+	tx, err := s.writableTx(in.TxId)
+	if err != nil {
+		return nil, err
 	}
 
+	atomic.AddInt64(&tx.ops, 1)
 	if err := tx.Delete(in.Key); err != nil {
 		return nil, err
 	} else {
+		atomic.StoreInt32(&tx.written, 1)
+		s.recordMutation(ctx, tx, "delete", in.Key, nil)
 		return &empty.Empty{}, nil
 	}
 }
@@ -245,14 +654,17 @@ func (s *KVService) Update(ctx context.Context, in *pb.UpdateRequest) (*empty.Em
 	s.txLock.RLock()
 	defer s.txLock.RUnlock()
 
-	tx, ok := s.openedTxs[in.TxId]
-	if ok == false {
-		return nil, fmt.Errorf("invalid transaction id")
+	tx, err := s.writableTx(in.TxId)
+	if err != nil {
+		return nil, err
 	}
 
+	atomic.AddInt64(&tx.ops, 1)
 	if err := tx.Update(in.Key, in.Value); err != nil {
 		return nil, err
 	} else {
+		atomic.StoreInt32(&tx.written, 1)
+		s.recordMutation(ctx, tx, "update", in.Key, in.Value)
 		return &empty.Empty{}, nil
 	}
 }
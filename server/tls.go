@@ -0,0 +1,45 @@
+package server
+
+import (
+	"crypto/tls"
+
+	"github.com/zdnscloud/kvzoo/internal/tlsutil"
+)
+
+func newServerTLSConfig(conf *TLSConfig) (*tls.Config, error) {
+	keyPair, err := tlsutil.NewKeyPair(conf.CertFile, conf.KeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	getCertificate := func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return keyPair.Certificate()
+	}
+	if conf.ClientCAFile == "" {
+		return &tls.Config{
+			GetCertificate: getCertificate,
+		}, nil
+	}
+
+	caPool, err := tlsutil.NewCertPool(conf.ClientCAFile)
+	if err != nil {
+		return nil, err
+	}
+
+	//client ca may change, so config is generated for each connection
+	return &tls.Config{
+		GetCertificate: getCertificate,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			pool, err := caPool.Pool()
+			if err != nil {
+				return nil, err
+			}
+			return &tls.Config{
+				GetCertificate: getCertificate,
+				ClientCAs:      pool,
+				ClientAuth:     tls.RequireAndVerifyClientCert,
+				NextProtos:     []string{"h2"},
+			}, nil
+		},
+	}, nil
+}
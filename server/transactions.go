@@ -0,0 +1,134 @@
+package server
+
+import (
+	"context"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/zdnscloud/cement/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	pb "github.com/zdnscloud/kvzoo/proto"
+)
+
+const (
+	DefaultSlowTxThreshold = 10 * time.Second
+	//transactions are checked at least once per interval
+	maxSlowTxCheckInterval = time.Second
+)
+
+// owner is the peer address with identity if authentication is enabled
+func (tx *openedTx) owner() string {
+	if tx.identity == "" {
+		return tx.peer
+	}
+	return tx.identity + "@" + tx.peer
+}
+
+func (s *KVService) setSlowTxThreshold(threshold time.Duration) {
+	atomic.StoreInt64(&s.slowThreshold, int64(threshold))
+}
+
+func (s *KVService) getSlowTxThreshold() time.Duration {
+	return time.Duration(atomic.LoadInt64(&s.slowThreshold))
+}
+
+// watchSlowTxs reports each transaction once it's opened longer than the
+// threshold, until service is closed
+func (s *KVService) watchSlowTxs() {
+	for {
+		interval := maxSlowTxCheckInterval
+		threshold := s.getSlowTxThreshold()
+		if threshold > 0 && threshold/2 < interval {
+			interval = threshold / 2
+		}
+
+		select {
+		case <-s.stopWatch:
+			return
+		case <-time.After(interval):
+		}
+
+		if threshold > 0 {
+			s.reportSlowTxs(threshold)
+		}
+	}
+}
+
+func (s *KVService) reportSlowTxs(threshold time.Duration) {
+	s.txLock.RLock()
+	defer s.txLock.RUnlock()
+	for _, tx := range s.openedTxs {
+		age := time.Since(tx.begin)
+		if age > threshold && atomic.CompareAndSwapInt32(&tx.reported, 0, 1) {
+			log.Warnf("transaction %d on %s from %s has been opened for %s with %d operations, other writers are blocked",
+				tx.id, tx.table, tx.owner(), age, atomic.LoadInt64(&tx.ops))
+			tx.span.SetAttribute("slow", true)
+			s.metrics.observeSlowTx()
+		}
+	}
+}
+
+// reportFinishedSlowTx logs how long the slow transaction held the db
+func (s *KVService) reportFinishedSlowTx(tx *openedTx, result string) {
+	threshold := s.getSlowTxThreshold()
+	if age := time.Since(tx.begin); threshold > 0 && age > threshold {
+		log.Warnf("slow transaction %d on %s from %s is %s after %s with %d operations",
+			tx.id, tx.table, tx.owner(), result, age, atomic.LoadInt64(&tx.ops))
+	}
+}
+
+// ListTransactions returns opened transactions, the oldest is the first
+func (s *KVService) ListTransactions(ctx context.Context, in *pb.ListTransactionsRequest) (*pb.ListTransactionsReply, error) {
+	now := time.Now()
+	var txs []*pb.TransactionInfo
+	s.txLock.RLock()
+	for _, tx := range s.openedTxs {
+		age := now.Sub(tx.begin)
+		if int64(age) < in.MinAge {
+			continue
+		}
+		txs = append(txs, &pb.TransactionInfo{
+			TxId:     tx.id,
+			Table:    tx.table,
+			Peer:     tx.peer,
+			Identity: tx.identity,
+			Begin:    tx.begin.UnixNano(),
+			Age:      int64(age),
+			OpCount:  atomic.LoadInt64(&tx.ops),
+		})
+	}
+	s.txLock.RUnlock()
+
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Begin < txs[j].Begin
+	})
+	return &pb.ListTransactionsReply{
+		Transactions: txs,
+	}, nil
+}
+
+// AbortTransaction rolls back transaction of other client to release the
+// db, the client gets ErrInvalidTxID on its next request to the transaction
+func (s *KVService) AbortTransaction(ctx context.Context, in *pb.AbortTransactionRequest) (*empty.Empty, error) {
+	s.txLock.Lock()
+	tx, ok := s.openedTxs[in.TxId]
+	if ok == false {
+		s.txLock.Unlock()
+		return nil, status.Errorf(codes.NotFound, "transaction %d isn't opened", in.TxId)
+	}
+
+	err := tx.Rollback()
+	delete(s.openedTxs, in.TxId)
+	s.finishTx(tx, "aborted", err)
+	s.txLock.Unlock()
+
+	s.audit(ctx, "abort transaction", tx.table, err)
+	if err != nil {
+		return nil, err
+	}
+	return &empty.Empty{}, nil
+}
@@ -0,0 +1,51 @@
+package kvzoo
+
+type TableStats struct {
+	//only keys of the table are counted, keys of child tables are excluded
+	KeyCount        int
+	KeySize         int64
+	ValueSize       int64
+	ChildTableCount int
+	Storage         StorageStats
+}
+
+// page and bucket statistics of the backend storage, child tables are
+// included, the meaning of each field depends on the backend
+type StorageStats struct {
+	BranchPageN       int
+	BranchOverflowN   int
+	LeafPageN         int
+	LeafOverflowN     int
+	KeyN              int
+	Depth             int
+	BranchAlloc       int
+	BranchInuse       int
+	LeafAlloc         int
+	LeafInuse         int
+	BucketN           int
+	InlineBucketN     int
+	InlineBucketInuse int
+}
+
+// statistics of the whole db, the meaning of each field depends on the
+// backend
+type DBStats struct {
+	FileSize      int64
+	FreePageN     int
+	PendingPageN  int
+	FreeAlloc     int
+	FreelistInuse int
+	//count of read transactions since db is opened
+	TxN     int
+	OpenTxN int
+	//page allocations since db is opened
+	PageCount int
+	PageAlloc int
+}
+
+func (s *TableStats) SameSize(other *TableStats) bool {
+	return s.KeyCount == other.KeyCount &&
+		s.KeySize == other.KeySize &&
+		s.ValueSize == other.ValueSize &&
+		s.ChildTableCount == other.ChildTableCount
+}
@@ -73,6 +73,10 @@ func (tn TableName) Parent() (TableName, error) {
 	return TableName(parent), nil
 }
 
+func (tn TableName) Child(name string) TableName {
+	return TableName(path.Join(string(tn), name))
+}
+
 func (tn TableName) IsParent(child TableName) bool {
 	return strings.HasPrefix(string(child), string(tn))
 }
@@ -0,0 +1,170 @@
+package tests
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func TestAuditMutations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kvzoo-audit")
+	ut.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+	auditFile := filepath.Join(dir, "audit.log")
+	auditor, err := server.NewFileAuditor(auditFile, 4096, 1)
+	ut.Equal(t, err, nil)
+
+	addr := "127.0.0.1:7789"
+	s, err := server.NewWithBoltDB(addr, "au1.db",
+		server.WithAuthenticator(server.NewTokenAuthenticator(map[string]string{"admin-token": "admin"})),
+		server.WithACL([]server.ACLRule{{Identity: "admin", Table: kvzoo.Root, Permission: server.PermAdmin}}),
+		server.WithAuditor(auditor))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+
+	proxy, err := client.New(addr, nil, client.WithToken("admin-token"))
+	ut.Equal(t, err, nil)
+	defer proxy.Destroy()
+
+	start := time.Now()
+	t1, _ := kvzoo.NewTableName("/app/t1")
+	t2, _ := kvzoo.NewTableName("/other")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(proxy, t1, keys, values), nil)
+	ut.Equal(t, loadDataToTable(proxy, t2, keys, values), nil)
+	ut.Equal(t, deleteDataInTable(proxy, t1, keys[:1], values[:1]), nil)
+	//rolled back changes aren't recorded
+	table, err := proxy.CreateOrGetTable(t1)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, kvzoo.Update(table, func(tx kvzoo.Transaction) error {
+		if err := tx.Update(keys[1], []byte("xxxx")); err != nil {
+			return err
+		}
+		return kvzoo.ErrNotFound
+	}), kvzoo.ErrNotFound)
+
+	c, err := client.NewClient(addr, time.Second, client.WithTokenCredentials("admin-token"))
+	ut.Equal(t, err, nil)
+	defer c.Close()
+	reply, err := c.QueryAudit(context.Background(), &pb.QueryAuditRequest{
+		Since: start.UnixNano(),
+		Table: "/app",
+	})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(reply.Entries), 11)
+	hash := sha256.Sum256([]byte(values[3]))
+	e := reply.Entries[3]
+	ut.Equal(t, e.Identity, "admin")
+	ut.Assert(t, e.Peer != "", "")
+	ut.Equal(t, e.Operation, "add")
+	ut.Equal(t, e.Table, string(t1))
+	ut.Equal(t, e.Key, keys[3])
+	ut.Equal(t, e.ValueSize, int64(len(values[3])))
+	ut.Equal(t, e.ValueHash, hex.EncodeToString(hash[:]))
+	e = reply.Entries[10]
+	ut.Equal(t, e.Operation, "delete")
+	ut.Equal(t, e.Key, keys[0])
+
+	reply, err = c.QueryAudit(context.Background(), &pb.QueryAuditRequest{Limit: 3})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(reply.Entries), 3)
+	ut.Equal(t, reply.Entries[2].Table, string(t1))
+	ut.Equal(t, reply.Entries[0].Table, string(t2))
+
+	reply, err = c.QueryAudit(context.Background(), &pb.QueryAuditRequest{Until: start.UnixNano()})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(reply.Entries), 0)
+
+	//audit file is rotated, only one rotated file is kept
+	for i := 0; i < 5; i++ {
+		ut.Equal(t, updateDataInTable(proxy, t2, keys, values), nil)
+	}
+	_, err = os.Stat(auditFile + ".1")
+	ut.Equal(t, err, nil)
+	_, err = os.Stat(auditFile + ".2")
+	ut.Assert(t, os.IsNotExist(err), "")
+	info, err := os.Stat(auditFile)
+	ut.Equal(t, err, nil)
+	ut.Assert(t, info.Size() < 4096, "")
+}
+
+func TestDBAuditor(t *testing.T) {
+	db, err := bolt.New("au2.db")
+	ut.Equal(t, err, nil)
+	defer db.Destroy()
+
+	auditor, err := server.NewDBAuditor(db, "/audit")
+	ut.Equal(t, err, nil)
+	now := time.Now()
+	var entries []server.AuditEntry
+	for i, table := range []string{"/a", "/a/b", "/ab", "/a"} {
+		entries = append(entries, server.AuditEntry{
+			Time:      now.Add(time.Duration(i) * time.Second),
+			Operation: "add",
+			Table:     table,
+			Key:       "k1",
+		})
+	}
+	//entries committed at the same time
+	ut.Equal(t, auditor.Audit(entries[:2]...), nil)
+	ut.Equal(t, auditor.Audit(entries[2:]...), nil)
+
+	result, err := auditor.Query(server.AuditFilter{Table: "/a"})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(result), 3)
+	ut.Equal(t, result[1].Table, "/a/b")
+
+	result, err = auditor.Query(server.AuditFilter{
+		Since: now.Add(time.Second),
+		Until: now.Add(3 * time.Second),
+	})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(result), 2)
+	ut.Equal(t, result[0].Table, "/a/b")
+	ut.Equal(t, result[1].Table, "/ab")
+	ut.Assert(t, result[0].Time.Equal(entries[1].Time), "")
+
+	result, err = auditor.Query(server.AuditFilter{Table: "/a", Limit: 2})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(result), 2)
+	ut.Equal(t, result[0].Table, "/a/b")
+	ut.Assert(t, result[1].Time.Equal(entries[3].Time), "")
+
+	//keys of entries with same time are after the time
+	later := now.Add(10 * time.Second)
+	var sameTime []server.AuditEntry
+	for i := 0; i < 3; i++ {
+		sameTime = append(sameTime, server.AuditEntry{
+			Time:      later,
+			Operation: "delete",
+			Table:     "/c",
+			Key:       fmt.Sprintf("k%d", i),
+		})
+	}
+	ut.Equal(t, auditor.Audit(sameTime...), nil)
+	result, err = auditor.Query(server.AuditFilter{
+		Since: later,
+		Until: later.Add(time.Nanosecond),
+	})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(result), 3)
+	ut.Equal(t, result[2].Key, "k2")
+	result, err = auditor.Query(server.AuditFilter{Until: later})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(result), 4)
+}
@@ -0,0 +1,213 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func newAuthClient(t *testing.T, addr, token string) *client.Client {
+	var opts []grpc.DialOption
+	if token != "" {
+		opts = append(opts, client.WithTokenCredentials(token))
+	}
+	c, err := client.NewClient(addr, time.Second, opts...)
+	ut.Equal(t, err, nil)
+	return c
+}
+
+func addKey(c *client.Client, table, key string) error {
+	ctx := context.Background()
+	if _, err := c.CreateOrGetTable(ctx, &pb.CreateOrGetTableRequest{Name: table}); err != nil {
+		return err
+	}
+	reply, err := c.BeginTransaction(ctx, &pb.BeginTransactionRequest{TableName: table})
+	if err != nil {
+		return err
+	}
+	defer c.RollbackTransaction(ctx, &pb.RollbackTransactionRequest{TxId: reply.TxId})
+
+	if _, err := c.Add(ctx, &pb.AddRequest{TxId: reply.TxId, Key: key, Value: []byte(key)}); err != nil {
+		return err
+	}
+	_, err = c.CommitTransaction(ctx, &pb.CommitTransactionRequest{TxId: reply.TxId})
+	return err
+}
+
+func getKey(c *client.Client, table, key string) error {
+	ctx := context.Background()
+	if _, err := c.CreateOrGetTable(ctx, &pb.CreateOrGetTableRequest{Name: table}); err != nil {
+		return err
+	}
+	reply, err := c.BeginTransaction(ctx, &pb.BeginTransactionRequest{TableName: table, ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer c.RollbackTransaction(ctx, &pb.RollbackTransactionRequest{TxId: reply.TxId})
+
+	_, err = c.Get(ctx, &pb.GetRequest{TxId: reply.TxId, Key: key})
+	return err
+}
+
+func TestAuthorization(t *testing.T) {
+	addr := "127.0.0.1:7786"
+	acl := []server.ACLRule{
+		{Identity: "root", Table: kvzoo.Root, Permission: server.PermAdmin},
+		{Identity: "app", Table: "/app", Permission: server.PermWrite},
+		{Identity: "reader", Table: "/app", Permission: server.PermRead},
+		{Identity: server.AnyIdentity, Table: "/public", Permission: server.PermRead},
+	}
+	s, err := server.NewWithBoltDB(addr, "a1.db",
+		server.WithAuthenticator(server.NewTokenAuthenticator(map[string]string{
+			"root-token":   "root",
+			"app-token":    "app",
+			"reader-token": "reader",
+		})),
+		server.WithACL(acl))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+
+	anonymous := newAuthClient(t, addr, "")
+	defer anonymous.Close()
+	invalid := newAuthClient(t, addr, "xxxx")
+	defer invalid.Close()
+	root := newAuthClient(t, addr, "root-token")
+	defer root.Close()
+	app := newAuthClient(t, addr, "app-token")
+	defer app.Close()
+	reader := newAuthClient(t, addr, "reader-token")
+	defer reader.Close()
+
+	ut.Equal(t, status.Code(addKey(anonymous, "/app/t1", "k1")), codes.Unauthenticated)
+	ut.Equal(t, status.Code(addKey(invalid, "/app/t1", "k1")), codes.Unauthenticated)
+	//health checking doesn't require authentication
+	ut.Equal(t, anonymous.CheckHealth(context.Background()), nil)
+
+	ut.Equal(t, addKey(app, "/app/t1", "k1"), nil)
+	ut.Equal(t, addKey(app, "/app", "k1"), nil)
+	ut.Equal(t, status.Code(addKey(app, "/apple", "k1")), codes.PermissionDenied)
+	ut.Equal(t, status.Code(addKey(app, "/public", "k1")), codes.PermissionDenied)
+	ut.Equal(t, addKey(root, "/public", "k1"), nil)
+	ut.Equal(t, getKey(app, "/public", "k1"), nil)
+
+	ut.Equal(t, getKey(reader, "/app/t1", "k1"), nil)
+	ut.Equal(t, status.Code(addKey(reader, "/app/t1", "k2")), codes.PermissionDenied)
+	//reader can't open table which doesn't exist
+	ut.Equal(t, status.Code(getKey(reader, "/app/t2", "k1")), codes.PermissionDenied)
+
+	//reader can only begin read only transaction, others lock writers of db
+	ctx := context.Background()
+	_, err = reader.BeginTransaction(ctx, &pb.BeginTransactionRequest{TableName: "/app/t1"})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	//revision of master decides whether slave serves reads, reader can't
+	//move it
+	begin, err := reader.BeginTransaction(ctx, &pb.BeginTransactionRequest{TableName: "/app/t1", ReadOnly: true})
+	ut.Equal(t, err, nil)
+	_, err = reader.CommitTransaction(ctx, &pb.CommitTransactionRequest{
+		TxId:     begin.TxId,
+		Revision: &pb.Revision{Epoch: 1, Number: 1},
+	})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = reader.CommitTransaction(ctx, &pb.CommitTransactionRequest{TxId: begin.TxId})
+	ut.Equal(t, err, nil)
+
+	//transaction of others can't be used to access tables without permission
+	_, err = root.CreateOrGetTable(ctx, &pb.CreateOrGetTableRequest{Name: "/secret"})
+	ut.Equal(t, err, nil)
+	reply, err := root.BeginTransaction(ctx, &pb.BeginTransactionRequest{TableName: "/secret"})
+	ut.Equal(t, err, nil)
+	_, err = app.Get(ctx, &pb.GetRequest{TxId: reply.TxId, Key: "k1"})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = root.RollbackTransaction(ctx, &pb.RollbackTransactionRequest{TxId: reply.TxId})
+	ut.Equal(t, err, nil)
+
+	_, err = app.DeleteTable(ctx, &pb.DeleteTableRequest{Name: "/app/t1"})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = app.Compact(ctx, &pb.CompactRequest{})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = app.Destroy(ctx, &pb.DestroyRequest{})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = app.Checksum(ctx, &pb.ChecksumRequest{})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	ut.Equal(t, s.SetACL(append(acl, server.ACLRule{
+		Identity: "reader", Table: "/app/t1", Permission: server.PermWrite,
+	})), nil)
+	ut.Equal(t, addKey(reader, "/app/t1", "k2"), nil)
+	ut.Assert(t, s.SetACL([]server.ACLRule{{Identity: "reader", Table: "app"}}) != nil, "invalid acl should be rejected")
+
+	_, err = root.DeleteTable(ctx, &pb.DeleteTableRequest{Name: "/app/t1"})
+	ut.Equal(t, err, nil)
+
+	proxy, err := client.New(addr, nil, client.WithToken("root-token"))
+	ut.Equal(t, err, nil)
+	_, err = proxy.Checksum()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, proxy.Destroy(), nil)
+}
+
+func TestTransactionOwner(t *testing.T) {
+	addr := "127.0.0.1:7807"
+	s, err := server.NewWithBoltDB(addr, "a2.db",
+		server.WithAuthenticator(server.NewTokenAuthenticator(map[string]string{
+			"root-token": "root",
+			"a-token":    "a",
+			"b-token":    "b",
+		})),
+		server.WithACL([]server.ACLRule{
+			{Identity: "root", Table: kvzoo.Root, Permission: server.PermAdmin},
+			{Identity: "a", Table: "/app", Permission: server.PermWrite},
+			{Identity: "b", Table: "/app", Permission: server.PermWrite},
+		}))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+
+	root := newAuthClient(t, addr, "root-token")
+	defer root.Close()
+	a := newAuthClient(t, addr, "a-token")
+	defer a.Close()
+	b := newAuthClient(t, addr, "b-token")
+	defer b.Close()
+	defer root.Destroy(context.Background(), &pb.DestroyRequest{})
+
+	ctx := context.Background()
+	ut.Equal(t, addKey(a, "/app/t1", "k1"), nil)
+	reply, err := a.BeginTransaction(ctx, &pb.BeginTransactionRequest{TableName: "/app/t1"})
+	ut.Equal(t, err, nil)
+	id := reply.TxId
+
+	//b has the same permission on table, but can't use transaction of a
+	_, err = b.Add(ctx, &pb.AddRequest{TxId: id, Key: "k2", Value: []byte("v2")})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = b.Get(ctx, &pb.GetRequest{TxId: id, Key: "k1"})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = b.CommitTransaction(ctx, &pb.CommitTransactionRequest{TxId: id})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	_, err = b.RollbackTransaction(ctx, &pb.RollbackTransactionRequest{TxId: id})
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	_, err = a.Add(ctx, &pb.AddRequest{TxId: id, Key: "k3", Value: []byte("v3")})
+	ut.Equal(t, err, nil)
+	_, err = a.CommitTransaction(ctx, &pb.CommitTransactionRequest{TxId: id})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, getKey(b, "/app/t1", "k3"), nil)
+	ut.Equal(t, status.Code(getKey(b, "/app/t1", "k2")), codes.Unknown)
+
+	//admin could abort transaction of others
+	reply, err = a.BeginTransaction(ctx, &pb.BeginTransactionRequest{TableName: "/app/t1"})
+	ut.Equal(t, err, nil)
+	_, err = root.AbortTransaction(ctx, &pb.AbortTransactionRequest{TxId: reply.TxId})
+	ut.Equal(t, err, nil)
+}
@@ -0,0 +1,128 @@
+package tests
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func TestBoltDBBackupAndRestore(t *testing.T) {
+	db1, err := bolt.New("b1.db")
+	ut.Assert(t, err == nil, "")
+	db2, err := bolt.New("b2.db")
+	ut.Assert(t, err == nil, "")
+	defer func() {
+		db1.Destroy()
+		db2.Destroy()
+	}()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "v", 100)
+	err = loadDataToTable(db1, tableName, keys, values)
+	ut.Equal(t, err, nil)
+
+	var buf bytes.Buffer
+	cs, err := db1.(kvzoo.Backupable).Backup(&buf)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, cs, mustChecksum(db1))
+
+	err = db2.(kvzoo.Backupable).Restore(bytes.NewReader(buf.Bytes()), "bad checksum")
+	ut.Equal(t, err, bolt.ErrChecksumMismatch)
+	ut.Assert(t, mustChecksum(db2) != cs, "")
+
+	err = db2.(kvzoo.Backupable).Restore(bytes.NewReader(buf.Bytes()), cs)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, mustChecksum(db2), cs)
+	ut.Assert(t, tableHasData(db2, tableName, keys, values), "")
+}
+
+func TestBoltDBRestoreFailure(t *testing.T) {
+	db1, err := bolt.New("b1.db")
+	ut.Assert(t, err == nil, "")
+	db2, err := bolt.New("b2.db")
+	ut.Assert(t, err == nil, "")
+	defer func() {
+		db1.Destroy()
+		db2.Destroy()
+	}()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "v", 100)
+	ut.Equal(t, loadDataToTable(db1, tableName, keys, values), nil)
+	var buf bytes.Buffer
+	cs, err := db1.(kvzoo.Backupable).Backup(&buf)
+	ut.Equal(t, err, nil)
+
+	//original db file can't be moved aside, so the swap fails
+	ut.Equal(t, os.MkdirAll("b2.db.orig/x", 0755), nil)
+	defer os.RemoveAll("b2.db.orig")
+	origCs := mustChecksum(db2)
+	err = db2.(kvzoo.Backupable).Restore(bytes.NewReader(buf.Bytes()), cs)
+	ut.Assert(t, err != nil, "restore should fail")
+	ut.Equal(t, mustChecksum(db2), origCs)
+	ut.Equal(t, loadDataToTable(db2, tableName, keys, values), nil)
+}
+
+func TestRemoteBackupAndRestore(t *testing.T) {
+	db1, err := bolt.New("s1.db")
+	ut.Equal(t, err, nil)
+	saddr1 := "127.0.0.1:7781"
+	rdb1, err := server.New(saddr1, db1)
+	ut.Equal(t, err, nil)
+	go rdb1.Start()
+
+	db2, err := bolt.New("s2.db")
+	ut.Equal(t, err, nil)
+	saddr2 := "127.0.0.1:7782"
+	rdb2, err := server.New(saddr2, db2)
+	ut.Equal(t, err, nil)
+	go rdb2.Start()
+
+	backupDir := "backups"
+	defer func() {
+		db1.Destroy()
+		db2.Destroy()
+		rdb1.Stop()
+		rdb2.Stop()
+		os.RemoveAll(backupDir)
+	}()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "v", 1000)
+	err = loadDataToTable(db1, tableName, keys, values)
+	ut.Equal(t, err, nil)
+
+	c1, err := client.NewClient(saddr1, client.ConnectTimeout)
+	ut.Equal(t, err, nil)
+	defer c1.Close()
+	var backupFile string
+	for i := 0; i < 3; i++ {
+		backupFile, err = c1.BackupToDir(backupDir, 2)
+		ut.Equal(t, err, nil)
+	}
+	backups, err := client.ListBackups(backupDir)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(backups), 2)
+	ut.Equal(t, backups[0], backupFile)
+
+	c2, err := client.NewClient(saddr2, client.ConnectTimeout)
+	ut.Equal(t, err, nil)
+	defer c2.Close()
+	err = c2.RestoreFromFile(backupFile)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, mustChecksum(db2), mustChecksum(db1))
+	ut.Assert(t, tableHasData(db2, tableName, keys, values), "")
+
+	f, err := os.Open(backupFile)
+	ut.Equal(t, err, nil)
+	defer f.Close()
+	err = c2.RestoreFrom(f, "bad checksum")
+	ut.Assert(t, err != nil, "")
+	ut.Equal(t, mustChecksum(db2), mustChecksum(db1))
+}
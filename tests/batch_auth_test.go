@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"context"
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func TestBatchAuthorization(t *testing.T) {
+	addr := "127.0.0.1:7806"
+	s, err := server.NewWithBoltDB(addr, "b1.db",
+		server.WithAuthenticator(server.NewTokenAuthenticator(map[string]string{
+			"app-token":    "app",
+			"reader-token": "reader",
+		})),
+		server.WithACL([]server.ACLRule{
+			{Identity: "app", Table: kvzoo.Root, Permission: server.PermAdmin},
+			{Identity: "reader", Table: "/app", Permission: server.PermRead},
+		}))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+
+	app := newAuthClient(t, addr, "app-token")
+	defer app.Close()
+	reader := newAuthClient(t, addr, "reader-token")
+	defer reader.Close()
+	ut.Equal(t, addKey(app, "/app/t1", "k1"), nil)
+	defer app.Destroy(context.Background(), &pb.DestroyRequest{})
+
+	//batch without writes only requires read permission
+	ctx := context.Background()
+	batch := &pb.BatchRequest{
+		TableName: "/app/t1",
+		Operations: []*pb.BatchOperation{
+			{Type: pb.BatchOperation_GET, Key: "k1"},
+			{Type: pb.BatchOperation_LIST},
+		},
+	}
+	reply, err := reader.Batch(ctx, batch)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, reply.Results[0].Found, true)
+	ut.Equal(t, reply.Results[1].Values, map[string][]byte{"k1": []byte("k1")})
+
+	//batch with writes requires write permission
+	batch.Operations = append(batch.Operations, &pb.BatchOperation{Type: pb.BatchOperation_ADD, Key: "k2"})
+	_, err = reader.Batch(ctx, batch)
+	ut.Equal(t, status.Code(err), codes.PermissionDenied)
+	reply, err = app.Batch(ctx, batch)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(reply.Results), 3)
+	ut.Equal(t, getKey(reader, "/app/t1", "k2"), nil)
+}
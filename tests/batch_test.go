@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+)
+
+func TestBatch(t *testing.T) {
+	e := newTestEnv(t, 3)
+	defer e.clean()
+	proxy := e.proxy.(*client.Proxy)
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/batch")
+	tb, err := proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	table := tb.(*client.ProxyTable)
+
+	//writes of buffered transaction are sent to each replica in one batch
+	keys, values := genData("key", "value", 100)
+	tx, err := table.BeginBuffered()
+	ut.Equal(t, err, nil)
+	for i, key := range keys {
+		ut.Equal(t, tx.Add(key, []byte(values[i])), nil)
+	}
+	ut.Equal(t, tx.Commit(), nil)
+	e.checkTableHasData(t, tableName, keys, values)
+
+	tx, err = table.BeginBuffered()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Update(keys[0], []byte("new value")), nil)
+	ut.Equal(t, tx.Delete(keys[1]), nil)
+	_, err = tx.Get("missing")
+	ut.Equal(t, err, kvzoo.ErrNotFound)
+	ut.Equal(t, tx.Commit(), nil)
+	keys, values = keys[1:], values[1:]
+	keys[0], values[0] = "key0", "new value"
+	e.checkTableHasData(t, tableName, keys, values)
+
+	//batch is atomic, no operation is applied if any of them fails
+	tx, err = table.BeginBuffered()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Update(keys[1], []byte("updated")), nil)
+	ut.Equal(t, tx.Add(keys[2], []byte("duplicated")), nil)
+	ut.Assert(t, tx.Commit() != nil, "add duplicated key should fail")
+	e.checkTableHasData(t, tableName, keys, values)
+
+	//commit without writes sends nothing
+	tx, err = table.BeginBuffered()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Commit(), nil)
+	_, err = proxy.Checksum()
+	ut.Equal(t, err, nil)
+}
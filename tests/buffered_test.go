@@ -0,0 +1,91 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+)
+
+func TestBufferedTransaction(t *testing.T) {
+	e := newTestEnv(t, 3)
+	defer e.clean()
+	tableName, _ := kvzoo.NewTableName("/xxxx/buffered")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+
+	//buffered mode is chosen by each transaction
+	tb, err := e.proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	table := tb.(*client.ProxyTable)
+	tx, err := table.Begin()
+	ut.Equal(t, err, nil)
+	_, ok := tx.(*client.ProxyTransaction)
+	ut.Assert(t, ok, "transaction should be buffered only if it's asked")
+	ut.Equal(t, tx.Rollback(), nil)
+
+	tx, err = table.BeginBuffered()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Add("new", []byte("v1")), nil)
+	ut.Equal(t, tx.Update("new", []byte("v2")), nil)
+	ut.Equal(t, tx.Update("key0", []byte("updated")), nil)
+	ut.Equal(t, tx.Delete("key1"), nil)
+
+	//read your writes
+	value, err := tx.Get("new")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, string(value), "v2")
+	_, err = tx.Get("key1")
+	ut.Equal(t, err, kvzoo.ErrNotFound)
+	value, err = tx.Get("key2")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, string(value), "value2")
+	_, err = tx.Get("missing")
+	ut.Equal(t, err, kvzoo.ErrNotFound)
+	all, err := tx.List()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(all), len(keys))
+	ut.Equal(t, string(all["key0"]), "updated")
+	ut.Equal(t, string(all["new"]), "v2")
+
+	//replicas aren't locked by the buffered transaction
+	committed := make(chan error)
+	go func() {
+		committed <- updateDataInTable(e.proxy, tableName, []string{"key3"}, []string{"other"})
+	}()
+	select {
+	case err := <-committed:
+		ut.Equal(t, err, nil)
+	case <-time.After(time.Second):
+		t.Fatal("transaction shouldn't be blocked by buffered transaction")
+	}
+	e.checkTableHasData(t, tableName, keys[:1], values[:1])
+	for _, db := range e.backends {
+		ut.Assert(t, tableDoesNotHasKeys(db, tableName, []string{"new"}), "")
+	}
+
+	ut.Equal(t, tx.Commit(), nil)
+	ut.Assert(t, tx.Add("late", nil) != nil, "write after commit should fail")
+	keys, values = append(keys[2:], "key0", "new"), append(values[2:], "updated", "v2")
+	values[1] = "other"
+	e.checkTableHasData(t, tableName, keys, values)
+	_, err = e.proxy.Checksum()
+	ut.Equal(t, err, nil)
+
+	//writes are validated at commit, and nothing is applied if any fails
+	tx, err = table.BeginBuffered()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Delete("key2"), nil)
+	ut.Equal(t, tx.Add("new", []byte("duplicated")), nil)
+	ut.Assert(t, tx.Commit() != nil, "add existing key should fail")
+	e.checkTableHasData(t, tableName, keys, values)
+
+	tx, err = table.BeginBuffered()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Delete("key2"), nil)
+	ut.Equal(t, tx.Rollback(), nil)
+	e.checkTableHasData(t, tableName, keys, values)
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func TestReadOnlyToken(t *testing.T) {
+	addr := "127.0.0.1:7808"
+	s, err := server.NewWithBoltDB(addr, "ctl1.db",
+		server.WithAuthenticator(server.NewTokenAuthenticator(map[string]string{
+			"admin-token":  "admin",
+			"reader-token": "reader",
+		})),
+		server.WithACL([]server.ACLRule{
+			{Identity: "admin", Table: kvzoo.Root, Permission: server.PermAdmin},
+			{Identity: "reader", Table: "/app", Permission: server.PermRead},
+		}))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+
+	admin, err := client.New(addr, nil, client.WithToken("admin-token"))
+	ut.Equal(t, err, nil)
+	defer admin.Destroy()
+	ut.Equal(t, loadDataToTable(admin, "/app/t1", []string{"k1", "k2"}, []string{"v1", "v2"}), nil)
+
+	reader, err := client.New(addr, nil, client.WithToken("reader-token"))
+	ut.Equal(t, err, nil)
+	defer reader.Close()
+	table, err := reader.CreateOrGetTable("/app/t1")
+	ut.Equal(t, err, nil)
+	err = kvzoo.View(table, func(tx kvzoo.Transaction) error {
+		value, err := tx.Get("k1")
+		ut.Equal(t, string(value), "v1")
+		return err
+	})
+	ut.Equal(t, err, nil)
+	ut.Assert(t, kvzoo.Update(table, func(tx kvzoo.Transaction) error {
+		return tx.Add("k3", []byte("v3"))
+	}) != nil, "reader shouldn't write")
+
+	dir, err := ioutil.TempDir("", "kvzoo-ctl")
+	ut.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+	ctl := filepath.Join(dir, "kvzoo-ctl")
+	build, err := exec.Command("go", "build", "-o", ctl, "../cmd/kvzoo-ctl").CombinedOutput()
+	ut.Assert(t, err == nil, string(build))
+	run := func(args ...string) (string, error) {
+		args = append([]string{"-s", addr, "-token", "reader-token"}, args...)
+		output, err := exec.Command(ctl, args...).CombinedOutput()
+		return string(output), err
+	}
+
+	output, err := run("get", "/app/t1", "k1")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, output, "v1\n")
+	output, err = run("ls", "/app/t1")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, output, "k1\tv1\nk2\tv2\n")
+	output, err = run("put", "/app/t1", "k3", "v3")
+	ut.Assert(t, strings.Contains(output, "PermissionDenied"), output)
+}
@@ -38,6 +38,104 @@ func TestBoltDBChecksum(t *testing.T) {
 	ut.Equal(t, mustChecksum(db1), mustChecksum(db2))
 }
 
+func TestBoltDBCompact(t *testing.T) {
+	db, err := bolt.New("test.db")
+	ut.Assert(t, err == nil, "")
+	defer db.Destroy()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "v", 1000)
+	err = loadDataToTable(db, tableName, keys, values)
+	ut.Equal(t, err, nil)
+	deleted, _ := genData("key", "v", 500)
+	err = deleteDataInTable(db, tableName, deleted, deleted)
+	ut.Equal(t, err, nil)
+
+	cs := mustChecksum(db)
+	err = db.(kvzoo.Compactable).Compact()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, mustChecksum(db), cs)
+	ut.Assert(t, tableHasData(db, tableName, keys[500:], values[500:]), "")
+}
+
+func TestBoltDBConcurrentCompact(t *testing.T) {
+	db, err := bolt.New("test.db")
+	ut.Assert(t, err == nil, "")
+	defer db.Destroy()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "v", 100)
+	err = loadDataToTable(db, tableName, keys, values)
+	ut.Equal(t, err, nil)
+	cs := mustChecksum(db)
+
+	//db file is swapped while it's read
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := db.Checksum(); err != nil {
+					errs <- err
+					return
+				}
+				if _, err := db.(kvzoo.DBStatsReporter).DBStats(); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 5; i++ {
+		ut.Equal(t, db.(kvzoo.Compactable).Compact(), nil)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("read during compact failed:%s", err.Error())
+	}
+	ut.Equal(t, mustChecksum(db), cs)
+}
+
+func TestBoltDBCursor(t *testing.T) {
+	db, err := bolt.New("test.db")
+	ut.Assert(t, err == nil, "")
+	defer db.Destroy()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx")
+	ut.Equal(t, loadDataToTable(db, tableName, []string{"a", "c", "e"}, []string{"1", "3", "5"}), nil)
+	//child table is skipped
+	_, err = db.CreateOrGetTable(tableName + "/b")
+	ut.Equal(t, err, nil)
+
+	table, err := db.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	tx, err := table.(kvzoo.ReadOnlyTable).BeginReadOnly()
+	ut.Equal(t, err, nil)
+	defer tx.Rollback()
+	c := tx.(kvzoo.SeekableTransaction).Cursor()
+	key, value := c.First()
+	ut.Equal(t, key, "a")
+	ut.Equal(t, string(value), "1")
+	key, _ = c.Next()
+	ut.Equal(t, key, "c")
+	key, _ = c.Seek("b")
+	ut.Equal(t, key, "c")
+	key, _ = c.Seek("f")
+	ut.Equal(t, key, "")
+	key, value = c.Last()
+	ut.Equal(t, key, "e")
+	ut.Equal(t, string(value), "5")
+	key, _ = c.Prev()
+	ut.Equal(t, key, "c")
+	key, _ = c.Prev()
+	ut.Equal(t, key, "a")
+	key, _ = c.Prev()
+	ut.Equal(t, key, "")
+}
+
 func TestBoltDBTable(t *testing.T) {
 	withBoltDB(t, testTable)
 }
@@ -318,3 +416,91 @@ func testTxRollback(t *testing.T, db kvzoo.DB) {
 	tx.Rollback()
 	ut.Assert(t, tableHasData(db, tn, keys, values), "")
 }
+
+func TestBoltDBListTables(t *testing.T) {
+	withBoltDB(t, testListTables)
+}
+
+func TestRemoteDBListTables(t *testing.T) {
+	withRemoteDB(t, testListTables)
+}
+
+func testListTables(t *testing.T, db kvzoo.DB) {
+	tables, err := db.ListTables(kvzoo.Root, true)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(tables), 0)
+
+	for _, name := range []string{"/app/cd/ns1", "/app/cd/ns2", "/app/zz", "/bb"} {
+		tn, _ := kvzoo.NewTableName(name)
+		_, err := db.CreateOrGetTable(tn)
+		ut.Equal(t, err, nil)
+	}
+	ut.Equal(t, loadDataToTable(db, "/app/cd", []string{"k1"}, []string{"v1"}), nil)
+
+	tables, err = db.ListTables(kvzoo.Root, false)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tables, []kvzoo.TableName{"/app", "/bb"})
+
+	tables, err = db.ListTables(kvzoo.Root, true)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tables, []kvzoo.TableName{"/app", "/app/cd", "/app/cd/ns1", "/app/cd/ns2", "/app/zz", "/bb"})
+
+	tables, err = db.ListTables("/app", false)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tables, []kvzoo.TableName{"/app/cd", "/app/zz"})
+
+	tables, err = db.ListTables("/app/cd", true)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tables, []kvzoo.TableName{"/app/cd/ns1", "/app/cd/ns2"})
+
+	_, err = db.ListTables("/app/nonexist", true)
+	ut.Assert(t, err != nil, "")
+
+	exists, err := db.TableExists("/app/cd/ns1")
+	ut.Equal(t, err, nil)
+	ut.Assert(t, exists, "")
+	exists, err = db.TableExists("/app/cd/ns1/xx")
+	ut.Equal(t, err, nil)
+	ut.Assert(t, exists == false, "")
+
+	ut.Equal(t, db.DeleteTable("/app/cd"), nil)
+	exists, err = db.TableExists("/app/cd/ns1")
+	ut.Equal(t, err, nil)
+	ut.Assert(t, exists == false, "")
+	tables, err = db.ListTables(kvzoo.Root, true)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tables, []kvzoo.TableName{"/app", "/app/zz", "/bb"})
+}
+
+func TestBoltDBStats(t *testing.T) {
+	withBoltDB(t, testStats)
+}
+
+func TestRemoteDBStats(t *testing.T) {
+	withRemoteDB(t, testStats)
+}
+
+func testStats(t *testing.T, db kvzoo.DB) {
+	tableName, _ := kvzoo.NewTableName("/app/cd")
+	keys, values := genData("key", "v", 100)
+	ut.Equal(t, loadDataToTable(db, tableName, keys, values), nil)
+	_, err := db.CreateOrGetTable("/app/cd/ns1")
+	ut.Equal(t, err, nil)
+
+	stats, err := db.Stats(tableName)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, stats.KeyCount, 100)
+	ut.Equal(t, stats.KeySize, int64(490))
+	ut.Equal(t, stats.ValueSize, int64(290))
+	ut.Equal(t, stats.ChildTableCount, 1)
+	ut.Equal(t, stats.Storage.BucketN, 2)
+	ut.Equal(t, stats.Storage.KeyN, 101)
+
+	stats, err = db.Stats("/app")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, stats.KeyCount, 0)
+	ut.Equal(t, stats.ChildTableCount, 1)
+
+	_, err = db.Stats("/app/nonexist")
+	ut.Assert(t, err != nil, "")
+}
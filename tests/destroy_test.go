@@ -0,0 +1,146 @@
+package tests
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+type recordAuditor struct {
+	lock    sync.Mutex
+	entries []server.AuditEntry
+}
+
+func (a *recordAuditor) Audit(entries ...server.AuditEntry) error {
+	a.lock.Lock()
+	a.entries = append(a.entries, entries...)
+	a.lock.Unlock()
+	return nil
+}
+
+// operations returns the destructive calls recorded
+func (a *recordAuditor) operations() []string {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	var ops []string
+	for _, e := range a.entries {
+		if e.Key != "" {
+			continue
+		} else if e.Err == "" {
+			ops = append(ops, e.Operation+" "+e.Table)
+		} else {
+			ops = append(ops, e.Operation+" "+e.Table+" failed")
+		}
+	}
+	return ops
+}
+
+func TestDestroyGuard(t *testing.T) {
+	addr := "127.0.0.1:7787"
+	backupDir, err := ioutil.TempDir("", "kvzoo-destroy")
+	ut.Equal(t, err, nil)
+	defer os.RemoveAll(backupDir)
+
+	auditor := &recordAuditor{}
+	startServer := func(guard server.DestroyGuard) *server.KVGRPCServer {
+		s, err := server.NewWithBoltDB(addr, "d1.db", server.WithDestroyGuard(guard), server.WithAuditor(auditor))
+		ut.Equal(t, err, nil)
+		go s.Start()
+		return s
+	}
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	s := startServer(server.DestroyGuard{Mode: server.DestroyDisabled})
+	proxy, err := client.New(addr, nil)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, loadDataToTable(proxy, tableName, keys, values), nil)
+	ut.Equal(t, status.Code(proxy.Destroy()), codes.FailedPrecondition)
+	ut.Assert(t, tableHasData(proxy, tableName, keys, values), "")
+	ut.Equal(t, proxy.DeleteTable(tableName), nil)
+	ut.Equal(t, loadDataToTable(proxy, tableName, keys, values), nil)
+	proxy.Close()
+	s.Stop()
+
+	s = startServer(server.DestroyGuard{Mode: server.DestroyConfirmed, BackupDir: backupDir})
+	defer s.Stop()
+	proxy, err = client.New(addr, nil)
+	ut.Equal(t, err, nil)
+	cs, err := proxy.Checksum()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, status.Code(proxy.Destroy()), codes.FailedPrecondition)
+	ut.Equal(t, status.Code(proxy.(*client.Proxy).DestroyWithConfirm("xxxx")), codes.FailedPrecondition)
+	backups, _ := client.ListBackups(backupDir)
+	ut.Equal(t, len(backups), 0)
+	ut.Equal(t, proxy.(*client.Proxy).DestroyWithConfirm(cs), nil)
+
+	//wrong confirm is refused by proxy before destroy is sent
+	ut.Equal(t, auditor.operations(), []string{
+		"destroy / failed",
+		"delete table /xxxx/xx",
+		"destroy / failed",
+		"destroy /",
+	})
+
+	backups, err = client.ListBackups(backupDir)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(backups), 1)
+	db, err := bolt.New("d2.db")
+	ut.Equal(t, err, nil)
+	defer db.Destroy()
+	restored, err := server.New("127.0.0.1:7788", db)
+	ut.Equal(t, err, nil)
+	go restored.Start()
+	defer restored.Stop()
+	c, err := client.NewClient("127.0.0.1:7788", client.ConnectTimeout)
+	ut.Equal(t, err, nil)
+	defer c.Close()
+	ut.Equal(t, c.RestoreFromFile(backups[0]), nil)
+	restoredCS, err := db.Checksum()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, restoredCS, cs)
+	ut.Assert(t, tableHasData(db, tableName, keys, values), "")
+}
+
+func TestDestroyConfirmEveryReplica(t *testing.T) {
+	var backends []kvzoo.DB
+	addrs := []string{"127.0.0.1:7801", "127.0.0.1:7802"}
+	for i, addr := range addrs {
+		db, err := bolt.New(fmt.Sprintf("d%d.db", i+3))
+		ut.Equal(t, err, nil)
+		s, err := server.New(addr, db, server.WithDestroyGuard(server.DestroyGuard{Mode: server.DestroyConfirmed}))
+		ut.Equal(t, err, nil)
+		go s.Start()
+		defer s.Stop()
+		backends = append(backends, db)
+	}
+
+	proxy, err := client.New(addrs[0], addrs[1:])
+	ut.Equal(t, err, nil)
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(proxy, tableName, keys, values), nil)
+	cs, err := backends[0].Checksum()
+	ut.Equal(t, err, nil)
+
+	//diverged slave refuses the confirm, and master isn't destroyed
+	ut.Equal(t, loadDataToTable(backends[1], tableName, []string{"diverged"}, []string{"value"}), nil)
+	ut.Equal(t, status.Code(proxy.(*client.Proxy).DestroyWithConfirm(cs)), codes.FailedPrecondition)
+	for _, db := range backends {
+		ut.Assert(t, tableHasData(db, tableName, keys, values), "")
+	}
+
+	ut.Equal(t, deleteDataInTable(backends[1], tableName, []string{"diverged"}, []string{"value"}), nil)
+	ut.Equal(t, proxy.(*client.Proxy).DestroyWithConfirm(cs), nil)
+}
@@ -0,0 +1,144 @@
+package tests
+
+import (
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+)
+
+func TestBoltDBMerkleTree(t *testing.T) {
+	boltDB, err := bolt.New("test.db")
+	ut.Assert(t, err == nil, "")
+	defer boltDB.Destroy()
+	db := boltDB.(kvzoo.Diffable)
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "v", 1000)
+	ut.Equal(t, loadDataToTable(boltDB, tableName, keys, values), nil)
+	tree, err := db.MerkleTree(tableName, nil)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(tree.Nodes), 2*kvzoo.MerkleLeafCount-1)
+	ut.Equal(t, len(tree.Boundaries), kvzoo.MerkleLeafCount)
+	ut.Equal(t, tree.Boundaries[0], "")
+	//keys are split evenly by range
+	for i := 1; i < kvzoo.MerkleLeafCount; i++ {
+		ut.Assert(t, tree.Boundaries[i-1] < tree.Boundaries[i], "boundaries should be ordered")
+	}
+	parentTree, err := db.MerkleTree("/xxxx", nil)
+	ut.Equal(t, err, nil)
+
+	ut.Equal(t, updateDataInTable(boltDB, tableName, []string{"key1"}, []string{"vv"}), nil)
+	newTree, err := db.MerkleTree(tableName, tree.Boundaries)
+	ut.Equal(t, err, nil)
+	leaf := kvzoo.MerkleLeaf(tree.Boundaries, "key1")
+	ut.Equal(t, tree.DiffLeaves(newTree), []int{leaf})
+	ut.Assert(t, newTree.Checksum != tree.Checksum, "")
+	newParentTree, err := db.MerkleTree("/xxxx", nil)
+	ut.Equal(t, err, nil)
+	ut.Assert(t, newParentTree.Checksum != parentTree.Checksum, "")
+	ut.Equal(t, len(parentTree.DiffLeaves(newParentTree)), 0)
+
+	//checksum doesn't depend on boundaries
+	evenTree, err := db.MerkleTree(tableName, nil)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, evenTree.Checksum, newTree.Checksum)
+
+	leafRange := tree.LeafRange(leaf)
+	digests, err := db.KeyDigests(tableName, []kvzoo.KeyRange{leafRange})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, digests["key1"], kvzoo.ValueDigest([]byte("vv")))
+	ut.Assert(t, len(digests) < len(keys)/10, "only keys in the range should be returned")
+	for k := range digests {
+		ut.Assert(t, leafRange.Contains(k), "key %s isn't in range %v", k, leafRange)
+	}
+}
+
+func TestBoltDBDiff(t *testing.T) {
+	db1, err := bolt.New("test1.db")
+	ut.Assert(t, err == nil, "")
+	db2, err := bolt.New("test2.db")
+	ut.Assert(t, err == nil, "")
+	defer func() {
+		db1.Destroy()
+		db2.Destroy()
+	}()
+
+	tables := []kvzoo.TableName{"/app/cd", "/app/cd/ns1", "/app/ns2"}
+	keys, values := genData("key", "v", 1000)
+	for _, tn := range tables {
+		ut.Equal(t, loadDataToTable(db1, tn, keys, values), nil)
+		ut.Equal(t, loadDataToTable(db2, tn, keys, values), nil)
+	}
+	diffs, err := kvzoo.Diff(db1.(kvzoo.Diffable), db2.(kvzoo.Diffable), kvzoo.Root)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(diffs), 0)
+
+	ut.Equal(t, deleteDataInTable(db2, "/app/cd", []string{"key10"}, []string{""}), nil)
+	ut.Equal(t, updateDataInTable(db2, "/app/cd/ns1", []string{"key20"}, []string{"vv"}), nil)
+	ut.Equal(t, loadDataToTable(db2, "/app/cd/ns1", []string{"newkey"}, []string{"vv"}), nil)
+	ut.Equal(t, db2.DeleteTable("/app/ns2"), nil)
+	_, err = db2.CreateOrGetTable("/app/ns3")
+	ut.Equal(t, err, nil)
+
+	//keys out of the ranges of source keys
+	ut.Equal(t, loadDataToTable(db2, "/app/cd", []string{"a", "z"}, []string{"v", "v"}), nil)
+
+	diffs, err = kvzoo.Diff(db1.(kvzoo.Diffable), db2.(kvzoo.Diffable), kvzoo.Root)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, diffs, []kvzoo.Difference{
+		{Table: "/app/cd", Key: "a", Type: kvzoo.DiffExtra},
+		{Table: "/app/cd", Key: "key10", Type: kvzoo.DiffMissing},
+		{Table: "/app/cd", Key: "z", Type: kvzoo.DiffExtra},
+		{Table: "/app/cd/ns1", Key: "key20", Type: kvzoo.DiffChanged},
+		{Table: "/app/cd/ns1", Key: "newkey", Type: kvzoo.DiffExtra},
+		{Table: "/app/ns2", Type: kvzoo.DiffMissing},
+		{Table: "/app/ns3", Type: kvzoo.DiffExtra},
+	})
+
+	diffs, err = kvzoo.Diff(db1.(kvzoo.Diffable), db2.(kvzoo.Diffable), "/app/cd/ns1")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(diffs), 2)
+
+	//table only exists in one db is diverged
+	diffs, err = kvzoo.Diff(db1.(kvzoo.Diffable), db2.(kvzoo.Diffable), "/app/ns2")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, diffs, []kvzoo.Difference{{Table: "/app/ns2", Type: kvzoo.DiffMissing}})
+	diffs, err = kvzoo.Diff(db1.(kvzoo.Diffable), db2.(kvzoo.Diffable), "/app/ns3")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, diffs, []kvzoo.Difference{{Table: "/app/ns3", Type: kvzoo.DiffExtra}})
+	diffs, err = kvzoo.Diff(db1.(kvzoo.Diffable), db2.(kvzoo.Diffable), "/app/ns4")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(diffs), 0)
+}
+
+func TestReplicaDiff(t *testing.T) {
+	e := newTestEnv(t, 3)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 100)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+
+	proxy := e.proxy.(*client.Proxy)
+	diffs, err := proxy.Diff(kvzoo.Root)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(diffs), 0)
+
+	ut.Equal(t, updateDataInTable(e.backends[2], tableName, []string{"key1"}, []string{"v1"}), nil)
+	diffs, err = proxy.Diff(kvzoo.Root)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, diffs, map[string][]kvzoo.Difference{
+		"127.0.0.1:7702": []kvzoo.Difference{{Table: tableName, Key: "key1", Type: kvzoo.DiffChanged}},
+	})
+
+	ut.Equal(t, e.backends[1].DeleteTable(tableName), nil)
+	diffs, err = proxy.Diff(tableName)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, diffs, map[string][]kvzoo.Difference{
+		"127.0.0.1:7701": []kvzoo.Difference{{Table: tableName, Type: kvzoo.DiffMissing}},
+		"127.0.0.1:7702": []kvzoo.Difference{{Table: tableName, Key: "key1", Type: kvzoo.DiffChanged}},
+	})
+}
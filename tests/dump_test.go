@@ -0,0 +1,62 @@
+package tests
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/dump"
+)
+
+func TestExportAndImport(t *testing.T) {
+	db, err := bolt.New("dump.db")
+	ut.Assert(t, err == nil, "")
+	defer db.Destroy()
+
+	parent, _ := kvzoo.NewTableName("/app/cd")
+	child, _ := kvzoo.NewTableName("/app/cd/ns1")
+	empty, _ := kvzoo.NewTableName("/app/empty")
+	keys, values := genData("key", "v", 100)
+	ut.Equal(t, loadDataToTable(db, parent, keys, values), nil)
+	ut.Equal(t, loadDataToTable(db, child, keys, values), nil)
+	_, err = db.CreateOrGetTable(empty)
+	ut.Equal(t, err, nil)
+
+	var buf bytes.Buffer
+	err = dump.Export(db, &buf)
+	ut.Equal(t, err, nil)
+	exported := buf.String()
+	ut.Equal(t, strings.Count(exported, "\n"), 204)
+
+	//export subtree
+	buf.Reset()
+	err = dump.Export(db, &buf, child, parent)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, strings.Count(buf.String(), "\n"), 202)
+	err = dump.Export(db, &buf, "/app/nonexist")
+	ut.Assert(t, err != nil, "")
+
+	withRemoteDB(t, func(t *testing.T, rdb kvzoo.DB) {
+		err := dump.Import(rdb, strings.NewReader(exported))
+		ut.Equal(t, err, nil)
+		ut.Assert(t, tableHasData(rdb, parent, keys, values), "")
+		ut.Assert(t, tableHasData(rdb, child, keys, values), "")
+		ut.Equal(t, mustChecksum(rdb), mustChecksum(db))
+
+		//import again will overwrite the existing keys
+		err = dump.Import(rdb, strings.NewReader(exported))
+		ut.Equal(t, err, nil)
+		ut.Equal(t, mustChecksum(rdb), mustChecksum(db))
+
+		var buf bytes.Buffer
+		err = dump.Export(rdb, &buf)
+		ut.Equal(t, err, nil)
+		ut.Equal(t, buf.String(), exported)
+	})
+
+	err = dump.Import(db, strings.NewReader(`{"table":"app"}`))
+	ut.Assert(t, err != nil, "")
+}
@@ -0,0 +1,218 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+type gatewayClient struct {
+	t     *testing.T
+	url   string
+	token string
+}
+
+func (c *gatewayClient) do(method, path string, body []byte) (int, []byte) {
+	req, err := http.NewRequest(method, c.url+path, bytes.NewReader(body))
+	ut.Equal(c.t, err, nil)
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	ut.Equal(c.t, err, nil)
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	ut.Equal(c.t, err, nil)
+	return resp.StatusCode, data
+}
+
+func TestGateway(t *testing.T) {
+	addr, gatewayAddr, slaveAddr := "127.0.0.1:7795", "127.0.0.1:7796", "127.0.0.1:7810"
+	slave, err := server.NewWithBoltDB(slaveAddr, "gw2.db")
+	ut.Equal(t, err, nil)
+	go slave.Start()
+	defer slave.Stop()
+	//writer retries until master starts
+	writer, err := client.New(addr, []string{slaveAddr}, client.WithToken("admin-token"), client.WithRetryPolicy(client.DefaultRetryPolicy))
+	ut.Equal(t, err, nil)
+
+	s, err := server.NewWithBoltDB(addr, "gw1.db",
+		server.WithAuthenticator(server.NewTokenAuthenticator(map[string]string{
+			"admin-token":  "admin",
+			"reader-token": "reader",
+		})),
+		server.WithACL([]server.ACLRule{
+			{Identity: "admin", Table: kvzoo.Root, Permission: server.PermAdmin},
+			{Identity: "reader", Table: "/app", Permission: server.PermRead},
+		}),
+		server.WithGateway(gatewayAddr),
+		server.WithWriter(writer))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+	defer writer.Destroy()
+	proxy, err := client.New(addr, nil, client.WithToken("admin-token"))
+	ut.Equal(t, err, nil)
+	slaveProxy, err := client.New(slaveAddr, nil)
+	ut.Equal(t, err, nil)
+	defer slaveProxy.Close()
+	//wait for gateway to serve
+	time.Sleep(100 * time.Millisecond)
+
+	anonymous := &gatewayClient{t: t, url: "http://" + gatewayAddr}
+	admin := &gatewayClient{t: t, url: anonymous.url, token: "admin-token"}
+	reader := &gatewayClient{t: t, url: anonymous.url, token: "reader-token"}
+
+	code, _ := anonymous.do("GET", "/tables/app/t1/keys/k1", nil)
+	ut.Equal(t, code, http.StatusUnauthorized)
+	code, _ = reader.do("PUT", "/tables/app/t1/keys/k1", []byte("v1"))
+	ut.Equal(t, code, http.StatusForbidden)
+	//writes go through writer, so they are replicated to slave
+	code, _ = admin.do("PUT", "/tables/app/t1/keys/k%2F1", []byte("v0"))
+	ut.Equal(t, code, http.StatusNoContent)
+	code, _ = admin.do("PUT", "/tables/app/t1/keys/k%2F1", []byte("v1"))
+	ut.Equal(t, code, http.StatusNoContent)
+	code, _ = admin.do("PUT", "/tables/app/t1/keys/k2", []byte("v2"))
+	ut.Equal(t, code, http.StatusNoContent)
+	code, _ = admin.do("PUT", "/tables/app/t1/keys/k3", []byte("v3"))
+	ut.Equal(t, code, http.StatusNoContent)
+	code, _ = admin.do("DELETE", "/tables/app/t1/keys/k3", nil)
+	ut.Equal(t, code, http.StatusNoContent)
+	code, _ = admin.do("GET", "/tables/app/t1/keys/k3", nil)
+	ut.Equal(t, code, http.StatusNotFound)
+	ut.Assert(t, tableHasData(slaveProxy, "/app/t1", []string{"k/1", "k2"}, []string{"v1", "v2"}), "")
+
+	//key with "/" is escaped
+	code, body := reader.do("GET", "/tables/app/t1/keys/k%2F1", nil)
+	ut.Equal(t, code, http.StatusOK)
+	ut.Equal(t, string(body), "v1")
+	code, _ = reader.do("GET", "/tables/app/t1/keys/k3", nil)
+	ut.Equal(t, code, http.StatusNotFound)
+	code, _ = reader.do("GET", "/tables/app/t2", nil)
+	ut.Equal(t, code, http.StatusNotFound)
+	code, _ = admin.do("GET", "/tables/", nil)
+	ut.Equal(t, code, http.StatusBadRequest)
+
+	code, body = reader.do("GET", "/tables/app/t1", nil)
+	ut.Equal(t, code, http.StatusOK)
+	var values map[string][]byte
+	ut.Equal(t, json.Unmarshal(body, &values), nil)
+	ut.Equal(t, values, map[string][]byte{"k/1": []byte("v1"), "k2": []byte("v2")})
+
+	tx, _ := json.Marshal(server.GatewayTransaction{
+		Table: "/app/t1",
+		Operations: []server.GatewayOperation{
+			{Op: "get", Key: "k2"},
+			{Op: "get", Key: "k/1"},
+		},
+	})
+	code, body = reader.do("POST", "/transactions", tx)
+	ut.Equal(t, code, http.StatusOK)
+	var results []server.GatewayResult
+	ut.Equal(t, json.Unmarshal(body, &results), nil)
+	ut.Equal(t, len(results), 2)
+	ut.Equal(t, string(results[0].Value), "v2")
+	ut.Equal(t, string(results[1].Value), "v1")
+
+	var gatewayErr struct {
+		Error     string `json:"error"`
+		Operation int    `json:"operation"`
+	}
+	tx, _ = json.Marshal(server.GatewayTransaction{
+		Table: "/app/t1",
+		Operations: []server.GatewayOperation{
+			{Op: "get", Key: "k2"},
+			{Op: "put", Key: "k3", Value: []byte("v3")},
+		},
+	})
+	code, _ = reader.do("POST", "/transactions", tx)
+	ut.Equal(t, code, http.StatusForbidden)
+
+	//nothing is written if any operation fails
+	tx, _ = json.Marshal(server.GatewayTransaction{
+		Table: "/app/t1",
+		Operations: []server.GatewayOperation{
+			{Op: "put", Key: "k3", Value: []byte("v3")},
+			{Op: "delete", Key: "k2"},
+			{Op: "get", Key: "k4"},
+		},
+	})
+	code, body = admin.do("POST", "/transactions", tx)
+	ut.Equal(t, code, http.StatusNotFound)
+	ut.Equal(t, json.Unmarshal(body, &gatewayErr), nil)
+	ut.Equal(t, gatewayErr.Operation, 2)
+	ut.Assert(t, tableHasData(proxy, "/app/t1", []string{"k/1", "k2"}, []string{"v1", "v2"}), "")
+
+	tx, _ = json.Marshal(server.GatewayTransaction{
+		Table: "/app/t1",
+		Operations: []server.GatewayOperation{
+			{Op: "put", Key: "k3", Value: []byte("v3")},
+			{Op: "delete", Key: "k2"},
+			{Op: "get", Key: "k3"},
+		},
+	})
+	code, body = admin.do("POST", "/transactions", tx)
+	ut.Equal(t, code, http.StatusOK)
+	ut.Equal(t, json.Unmarshal(body, &results), nil)
+	ut.Equal(t, len(results), 3)
+	ut.Equal(t, string(results[2].Value), "v3")
+	ut.Assert(t, tableHasData(slaveProxy, "/app/t1", []string{"k/1", "k3"}, []string{"v1", "v3"}), "")
+	ut.Assert(t, tableDoesNotHasKeys(slaveProxy, "/app/t1", []string{"k2"}), "")
+
+	code, _ = reader.do("GET", "/checksum", nil)
+	ut.Equal(t, code, http.StatusForbidden)
+	code, body = admin.do("GET", "/checksum", nil)
+	ut.Equal(t, code, http.StatusOK)
+	var checksum map[string]string
+	ut.Equal(t, json.Unmarshal(body, &checksum), nil)
+	c, err := client.NewClient(addr, time.Second, client.WithTokenCredentials("admin-token"))
+	ut.Equal(t, err, nil)
+	defer c.Close()
+	reply, err := c.Checksum(context.Background(), &pb.ChecksumRequest{})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, checksum["checksum"], reply.Checksum)
+}
+
+func TestGatewayWithoutWriter(t *testing.T) {
+	addr, gatewayAddr := "127.0.0.1:7811", "127.0.0.1:7812"
+	s, err := server.NewWithBoltDB(addr, "gw3.db", server.WithGateway(gatewayAddr))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+	proxy, err := client.New(addr, nil)
+	ut.Equal(t, err, nil)
+	defer proxy.Destroy()
+	ut.Equal(t, loadDataToTable(proxy, "/app/t1", []string{"k1"}, []string{"v1"}), nil)
+	//wait for gateway to serve
+	time.Sleep(100 * time.Millisecond)
+
+	//gateway is read only without writer
+	c := &gatewayClient{t: t, url: "http://" + gatewayAddr}
+	code, _ := c.do("PUT", "/tables/app/t1/keys/k1", []byte("v2"))
+	ut.Equal(t, code, http.StatusMethodNotAllowed)
+	code, _ = c.do("DELETE", "/tables/app/t1/keys/k1", nil)
+	ut.Equal(t, code, http.StatusMethodNotAllowed)
+	tx, _ := json.Marshal(server.GatewayTransaction{
+		Table: "/app/t1",
+		Operations: []server.GatewayOperation{
+			{Op: "get", Key: "k1"},
+			{Op: "delete", Key: "k1"},
+		},
+	})
+	code, _ = c.do("POST", "/transactions", tx)
+	ut.Equal(t, code, http.StatusMethodNotAllowed)
+	code, body := c.do("GET", "/tables/app/t1/keys/k1", nil)
+	ut.Equal(t, code, http.StatusOK)
+	ut.Equal(t, string(body), "v1")
+}
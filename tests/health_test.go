@@ -0,0 +1,86 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func waitReplicaState(t *testing.T, proxy *client.Proxy, i int, state client.ReplicaState) client.ReplicaStatus {
+	for j := 0; j < 100; j++ {
+		if s := proxy.ReplicaStatus()[i]; s.State == state {
+			return s
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	ut.Assert(t, false, "replica state doesn't change to %s", state)
+	return client.ReplicaStatus{}
+}
+
+func TestReplicaHealth(t *testing.T) {
+	e := newTestEnv(t, 2)
+	defer e.clean()
+
+	db, err := client.New("127.0.0.1:7700", []string{"127.0.0.1:7701"},
+		client.WithHealthCheckInterval(50*time.Millisecond),
+		client.WithCircuitBreakerThreshold(2))
+	ut.Equal(t, err, nil)
+	defer db.Close()
+	proxy := db.(*client.Proxy)
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(proxy, tableName, keys, values), nil)
+	statuses := proxy.ReplicaStatus()
+	ut.Equal(t, len(statuses), 2)
+	ut.Assert(t, statuses[0].Master, "")
+	ut.Equal(t, statuses[1].State, client.ReplicaHealthy)
+	ut.Equal(t, statuses[1].Lag, int64(0))
+
+	//slave is skipped after it's down, writes still succeed
+	e.servers[1].Stop()
+	status := waitReplicaState(t, proxy, 1, client.ReplicaDown)
+	ut.Assert(t, status.LastErr != "", "")
+	newKeys, newValues := genData("newkey", "value", 10)
+	ut.Equal(t, loadDataToTable(proxy, tableName, newKeys, newValues), nil)
+	ut.Equal(t, proxy.ReplicaStatus()[1].Lag, int64(1))
+
+	//missed writes are repaired after slave is back
+	slaveDB, err := bolt.New("s1.db")
+	ut.Equal(t, err, nil)
+	s, err := server.New("127.0.0.1:7701", slaveDB)
+	ut.Equal(t, err, nil)
+	go s.Start()
+	e.backends[1], e.servers[1] = slaveDB, s
+
+	waitReplicaState(t, proxy, 1, client.ReplicaHealthy)
+	for i := 0; i < 100 && proxy.ReplicaStatus()[1].Lag != 0; i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	ut.Equal(t, proxy.ReplicaStatus()[1].Lag, int64(0))
+	e.checkTableHasData(t, tableName, newKeys, newValues)
+}
+
+func TestHealthCheckIsOptIn(t *testing.T) {
+	e := newTestEnv(t, 1)
+	defer e.clean()
+
+	//circuit breaker doesn't work without health checking, since nothing
+	//could close the circuit
+	db, err := client.New("127.0.0.1:7700", []string{"127.0.0.1:7799"})
+	ut.Equal(t, err, nil)
+	defer db.Close()
+	proxy := db.(*client.Proxy)
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	for i := 0; i < 2*client.DefaultCircuitBreakerThreshold; i++ {
+		ut.Equal(t, loadDataToTable(proxy, tableName, []string{"key"}, []string{"value"}), nil)
+		ut.Equal(t, deleteDataInTable(proxy, tableName, []string{"key"}, []string{"value"}), nil)
+	}
+	ut.Equal(t, proxy.ReplicaStatus()[1].State, client.ReplicaSuspect)
+}
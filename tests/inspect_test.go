@@ -0,0 +1,48 @@
+package tests
+
+import (
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+)
+
+func TestBoltDBInspect(t *testing.T) {
+	db, err := bolt.New("inspect.db")
+	ut.Assert(t, err == nil, "")
+	defer db.Destroy()
+
+	parent, _ := kvzoo.NewTableName("/app/cd")
+	child, _ := kvzoo.NewTableName("/app/cd/ns1")
+	keys, values := genData("key", "v", 100)
+	ut.Equal(t, loadDataToTable(db, parent, keys, values), nil)
+	ut.Equal(t, loadDataToTable(db, child, keys[:10], values[:10]), nil)
+	cs := mustChecksum(db)
+	db.Close()
+
+	rdb, err := bolt.OpenReadOnly("inspect.db")
+	ut.Assert(t, err == nil, "")
+	defer rdb.Close()
+	ut.Equal(t, rdb.Check(), nil)
+	ut.Equal(t, mustChecksum(rdb), cs)
+
+	tables, err := rdb.Inspect()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(tables), 1)
+	app := tables[0]
+	ut.Equal(t, app.Name, kvzoo.TableName("/app"))
+	ut.Equal(t, app.KeyCount, 0)
+	ut.Equal(t, len(app.Children), 1)
+	cd := app.Children[0]
+	ut.Equal(t, cd.Name, parent)
+	ut.Equal(t, cd.KeyCount, 100)
+	ut.Equal(t, cd.KeySize, int64(490))
+	ut.Equal(t, cd.ValueSize, int64(290))
+	ut.Equal(t, len(cd.Children), 1)
+	ut.Equal(t, cd.Children[0].Name, child)
+	ut.Equal(t, cd.Children[0].KeyCount, 10)
+
+	_, err = rdb.CreateOrGetTable(child)
+	ut.Assert(t, err != nil, "")
+}
@@ -0,0 +1,147 @@
+package tests
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func TestAddAndRemoveReplica(t *testing.T) {
+	e := newTestEnv(t, 2)
+	defer e.clean()
+
+	db, err := bolt.New("m1.db")
+	ut.Equal(t, err, nil)
+	addr := "127.0.0.1:7783"
+	s, err := server.New(addr, db)
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer func() {
+		s.Stop()
+		db.Destroy()
+	}()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 100)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+
+	//write in transaction which began before replica joins isn't lost
+	table, err := e.proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	tx, err := table.Begin()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Add("inflight", []byte("v")), nil)
+
+	proxy := e.proxy.(*client.Proxy)
+	added := make(chan error)
+	go func() {
+		added <- proxy.AddReplica(context.TODO(), addr)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	ut.Equal(t, tx.Commit(), nil)
+	ut.Equal(t, <-added, nil)
+	ut.Equal(t, proxy.Replicas(), []string{"127.0.0.1:7701", addr})
+	ut.Assert(t, tableHasData(db, tableName, append(keys, "inflight"), append(values, "v")), "")
+	_, err = proxy.Checksum()
+	ut.Equal(t, err, nil)
+
+	ut.Assert(t, proxy.AddReplica(context.TODO(), addr) != nil, "")
+	ut.Equal(t, loadDataToTable(proxy, tableName, []string{"joined"}, []string{"v"}), nil)
+	ut.Assert(t, tableHasData(db, tableName, []string{"joined"}, []string{"v"}), "")
+
+	ut.Equal(t, proxy.RemoveReplica(context.TODO(), addr), nil)
+	ut.Equal(t, proxy.Replicas(), []string{"127.0.0.1:7701"})
+	ut.Equal(t, loadDataToTable(proxy, tableName, []string{"removed"}, []string{"v"}), nil)
+	ut.Assert(t, tableDoesNotHasKeys(db, tableName, []string{"removed"}), "")
+	ut.Assert(t, proxy.RemoveReplica(context.TODO(), addr) != nil, "")
+}
+
+func TestChangeReplicaWithContext(t *testing.T) {
+	e := newTestEnv(t, 2)
+	defer e.clean()
+
+	db, err := bolt.New("m1.db")
+	ut.Equal(t, err, nil)
+	addr := "127.0.0.1:7783"
+	s, err := server.New(addr, db)
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer func() {
+		s.Stop()
+		db.Destroy()
+	}()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	table, err := e.proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	tx, err := table.Begin()
+	ut.Equal(t, err, nil)
+	defer tx.Rollback()
+
+	//membership changes don't wait for the open transaction after ctx is done
+	proxy := e.proxy.(*client.Proxy)
+	for _, change := range []func(context.Context, string) error{proxy.AddReplica, proxy.RemoveReplica} {
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		start := time.Now()
+		ut.Equal(t, change(ctx, addr), nil)
+		ut.Assert(t, time.Since(start) < time.Second, "membership change should stop waiting once ctx is done")
+		cancel()
+	}
+	ut.Equal(t, proxy.Replicas(), []string{"127.0.0.1:7701"})
+}
+
+func TestWatchMembership(t *testing.T) {
+	e := newTestEnv(t, 2)
+	defer e.clean()
+
+	db, err := bolt.New("m1.db")
+	ut.Equal(t, err, nil)
+	addr := "127.0.0.1:7783"
+	s, err := server.New(addr, db)
+	ut.Equal(t, err, nil)
+	go s.Start()
+	membershipFile := "members.txt"
+	defer func() {
+		s.Stop()
+		db.Destroy()
+		os.Remove(membershipFile)
+	}()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+
+	proxy := e.proxy.(*client.Proxy)
+	ut.Equal(t, ioutil.WriteFile(membershipFile, []byte("127.0.0.1:7701\n"), 0644), nil)
+	ut.Equal(t, proxy.WatchMembership(membershipFile, 50*time.Millisecond), nil)
+	defer proxy.StopWatchMembership()
+	ut.Equal(t, proxy.Replicas(), []string{"127.0.0.1:7701"})
+
+	waitReplicas := func(expect []string) {
+		for i := 0; i < 100; i++ {
+			if reflect.DeepEqual(proxy.Replicas(), expect) {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		ut.Equal(t, proxy.Replicas(), expect)
+	}
+
+	ut.Equal(t, ioutil.WriteFile(membershipFile, []byte("#slaves\n127.0.0.1:7701\n"+addr+"\n"), 0644), nil)
+	waitReplicas([]string{"127.0.0.1:7701", addr})
+	ut.Assert(t, tableHasData(db, tableName, keys, values), "")
+
+	ut.Equal(t, ioutil.WriteFile(membershipFile, []byte(addr+"\n"), 0644), nil)
+	waitReplicas([]string{addr})
+	ut.Equal(t, ioutil.WriteFile(membershipFile, []byte("127.0.0.1:7701\n"), 0644), nil)
+	waitReplicas([]string{"127.0.0.1:7701"})
+}
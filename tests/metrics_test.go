@@ -0,0 +1,126 @@
+package tests
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func scrape(t *testing.T, registry *prometheus.Registry) string {
+	w := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	ut.Equal(t, w.Code, 200)
+	ut.Assert(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4"), "")
+	body, err := ioutil.ReadAll(w.Body)
+	ut.Equal(t, err, nil)
+	return string(body)
+}
+
+func assertHasLines(t *testing.T, text string, lines ...string) {
+	for _, line := range lines {
+		ut.Assert(t, strings.Contains(text, line+"\n"), "%s isn't found in:\n%s", line, text)
+	}
+}
+
+func TestServerAndProxyMetrics(t *testing.T) {
+	masterAddr, slaveAddr := "127.0.0.1:7790", "127.0.0.1:7791"
+	serverRegistry := prometheus.NewRegistry()
+	master, err := server.NewWithBoltDB(masterAddr, "m1.db", server.WithMetrics(serverRegistry))
+	ut.Equal(t, err, nil)
+	go master.Start()
+	defer master.Stop()
+	//slave is stopped before proxy destroys the db
+	slave, err := server.NewWithBoltDB(slaveAddr, "m2.db")
+	ut.Equal(t, err, nil)
+	go slave.Start()
+	defer os.Remove("m2.db")
+
+	proxyRegistry := prometheus.NewRegistry()
+	proxy, err := client.New(masterAddr, []string{slaveAddr}, client.WithMetrics(proxyRegistry))
+	ut.Equal(t, err, nil)
+	defer proxy.Destroy()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(proxy, tableName, keys, values), nil)
+	ut.Assert(t, tableHasData(proxy, tableName, keys, values), "")
+	table, err := proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+
+	//missing key isn't failure of replica
+	tx, err := table.Begin()
+	ut.Equal(t, err, nil)
+	_, err = tx.Get("unknown")
+	ut.Assert(t, err != nil, "")
+	tx.Rollback()
+
+	text := scrape(t, serverRegistry)
+	assertHasLines(t, text,
+		`kvzoo_server_requests_total{code="OK",method="Add"} 10`,
+		`kvzoo_server_requests_total{code="OK",method="BeginTransaction"} 3`,
+		`kvzoo_server_request_duration_seconds_count{method="Add"} 10`,
+		`kvzoo_server_transaction_duration_seconds_count{result="commit"} 1`,
+		`kvzoo_server_transaction_duration_seconds_count{result="rollback"} 2`,
+		`kvzoo_server_open_transactions 0`,
+		`kvzoo_server_max_open_transactions 2000`,
+	)
+	ut.Assert(t, strings.Contains(text, "kvzoo_db_file_size_bytes "), "")
+	ut.Assert(t, strings.Contains(text, "kvzoo_db_page_allocations_total "), "")
+
+	text = scrape(t, proxyRegistry)
+	assertHasLines(t, text,
+		`kvzoo_proxy_replica_requests_total{code="OK",method="Add",replica="127.0.0.1:7790"} 10`,
+		`kvzoo_proxy_replica_requests_total{code="OK",method="Add",replica="127.0.0.1:7791"} 10`,
+		`kvzoo_proxy_replica_request_duration_seconds_count{method="Add",replica="127.0.0.1:7791"} 10`,
+		`kvzoo_proxy_replica_up{master="true",replica="127.0.0.1:7790"} 1`,
+		`kvzoo_proxy_replica_up{master="false",replica="127.0.0.1:7791"} 1`,
+		`kvzoo_proxy_replica_lag{master="false",replica="127.0.0.1:7791"} 0`,
+		`kvzoo_proxy_repair_rounds_total 0`,
+		`kvzoo_proxy_divergent_tables_total 0`,
+	)
+
+	slave.Stop()
+	err = loadDataToTable(proxy, tableName, []string{"k1"}, []string{"v1"})
+	ut.Equal(t, err, nil)
+	text = scrape(t, proxyRegistry)
+	ut.Assert(t, strings.Contains(text, `kvzoo_proxy_replica_failures_total{replica="127.0.0.1:7791"} `), "")
+	ut.Assert(t, strings.Contains(text, `kvzoo_proxy_replica_failures_total{replica="127.0.0.1:7790"} `) == false, "")
+	ut.Assert(t, strings.Contains(text, `kvzoo_proxy_replica_lag{master="false",replica="127.0.0.1:7791"} 0`) == false, "")
+}
+
+func TestMetricsUnregistered(t *testing.T) {
+	addr := "127.0.0.1:7803"
+	defer os.Remove("m3.db")
+	serverRegistry := prometheus.NewRegistry()
+	//gateway address is in use
+	_, err := server.NewWithBoltDB(addr, "m3.db", server.WithMetrics(serverRegistry), server.WithGateway(addr))
+	ut.Assert(t, err != nil, "")
+	s, err := server.NewWithBoltDB(addr, "m3.db", server.WithMetrics(serverRegistry))
+	ut.Equal(t, err, nil)
+	go s.Start()
+
+	//master is unreachable
+	proxyRegistry := prometheus.NewRegistry()
+	_, err = client.New("127.0.0.1:7804", nil, client.WithMetrics(proxyRegistry), client.WithReadPolicy(client.ReadRoundRobin))
+	ut.Assert(t, err != nil, "")
+	for i := 0; i < 2; i++ {
+		proxy, err := client.New(addr, nil, client.WithMetrics(proxyRegistry))
+		ut.Equal(t, err, nil)
+		ut.Equal(t, proxy.Close(), nil)
+	}
+
+	s.Stop()
+	s, err = server.NewWithBoltDB(addr, "m3.db", server.WithMetrics(serverRegistry))
+	ut.Equal(t, err, nil)
+	s.Stop()
+}
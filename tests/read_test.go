@@ -0,0 +1,198 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func readKey(t *testing.T, db kvzoo.DB, tableName kvzoo.TableName, key string) string {
+	values, err := getTableData(db, tableName)
+	ut.Equal(t, err, nil)
+	return string(values[key])
+}
+
+func TestReadFromSlaves(t *testing.T) {
+	e := newTestEnv(t, 3)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+
+	reader, err := client.New("127.0.0.1:7700", []string{"127.0.0.1:7701", "127.0.0.1:7702"},
+		client.WithReadPolicy(client.ReadRoundRobin), client.WithMaxStaleness(1))
+	ut.Equal(t, err, nil)
+	defer reader.Close()
+
+	//mark where the value comes from
+	ut.Equal(t, updateDataInTable(e.backends[1], tableName, []string{"key1"}, []string{"s1"}), nil)
+	ut.Equal(t, updateDataInTable(e.backends[2], tableName, []string{"key1"}, []string{"s2"}), nil)
+	reads := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		reads[readKey(t, reader, tableName, "key1")] += 1
+	}
+	ut.Equal(t, reads, map[string]int{"s1": 5, "s2": 5})
+
+	//read your writes in transaction
+	table, err := reader.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	tx, err := table.Begin()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Add("newkey", []byte("v")), nil)
+	v, err := tx.Get("newkey")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, string(v), "v")
+	ut.Equal(t, tx.Commit(), nil)
+
+	//slave2 misses a write, within the bound of staleness it's still read
+	ut.Equal(t, loadDataToTable(e.backends[2], tableName, []string{"k1", "k2"}, []string{"v", "v"}), nil)
+	ut.Equal(t, loadDataToTable(reader, tableName, []string{"k1"}, []string{"v"}), nil)
+	reads = make(map[string]int)
+	for i := 0; i < 10; i++ {
+		reads[readKey(t, reader, tableName, "key1")] += 1
+	}
+	ut.Equal(t, reads, map[string]int{"s1": 5, "s2": 5})
+
+	ut.Equal(t, loadDataToTable(reader, tableName, []string{"k2"}, []string{"v"}), nil)
+	for i := 0; i < 10; i++ {
+		ut.Equal(t, readKey(t, reader, tableName, "key1"), "s1")
+	}
+
+	//repair resets the lag
+	_, err = reader.(*client.Proxy).Repair(kvzoo.Root)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, updateDataInTable(e.backends[2], tableName, []string{"key1"}, []string{"s2"}), nil)
+	reads = make(map[string]int)
+	for i := 0; i < 10; i++ {
+		reads[readKey(t, reader, tableName, "key1")] += 1
+	}
+	ut.Equal(t, reads, map[string]int{"value1": 5, "s2": 5})
+
+	//writes of other clients are numbered by master too
+	other, err := client.New("127.0.0.1:7700", nil)
+	ut.Equal(t, err, nil)
+	defer other.Close()
+	ut.Equal(t, updateDataInTable(e.backends[1], tableName, []string{"key1"}, []string{"s1"}), nil)
+	ut.Equal(t, loadDataToTable(other, tableName, []string{"o1"}, []string{"v"}), nil)
+	reads = make(map[string]int)
+	for i := 0; i < 10; i++ {
+		reads[readKey(t, reader, tableName, "key1")] += 1
+	}
+	ut.Equal(t, reads, map[string]int{"s1": 5, "s2": 5})
+
+	ut.Equal(t, loadDataToTable(other, tableName, []string{"o2"}, []string{"v"}), nil)
+	for i := 0; i < 10; i++ {
+		ut.Equal(t, readKey(t, reader, tableName, "key1"), "value1")
+	}
+}
+
+func TestReadFromDivergedSlaves(t *testing.T) {
+	e := newTestEnv(t, 2)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+	ut.Equal(t, updateDataInTable(e.backends[1], tableName, []string{"key1"}, []string{"s1"}), nil)
+
+	for _, policy := range []client.ReadPolicy{client.ReadRoundRobin, client.ReadLeastLatency, client.ReadSlaveWithFallback} {
+		reader, err := client.New("127.0.0.1:7700", []string{"127.0.0.1:7701"}, client.WithReadPolicy(policy))
+		ut.Equal(t, err, nil)
+		for i := 0; i < 4; i++ {
+			ut.Equal(t, readKey(t, reader, tableName, "key1"), "value1")
+		}
+		reader.Close()
+	}
+}
+
+func TestReadOnlyTransaction(t *testing.T) {
+	e := newTestEnv(t, 3)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+
+	//writer of master doesn't block read only transaction
+	masterTable, err := e.backends[0].CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	writer, err := masterTable.Begin()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, writer.Update("key1", []byte("uncommitted")), nil)
+	table, err := e.proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	read := make(chan string)
+	go func() {
+		tx, _ := table.(*client.ProxyTable).BeginReadOnly()
+		value, _ := tx.Get("key1")
+		tx.Rollback()
+		read <- string(value)
+	}()
+	select {
+	case value := <-read:
+		ut.Equal(t, value, "value1")
+	case <-time.After(time.Second):
+		t.Fatal("read only transaction is blocked by writer")
+	}
+	ut.Equal(t, writer.Rollback(), nil)
+
+	reader, err := client.New("127.0.0.1:7700", []string{"127.0.0.1:7701", "127.0.0.1:7702"},
+		client.WithReadPolicy(client.ReadRoundRobin))
+	ut.Equal(t, err, nil)
+	defer reader.Close()
+	ut.Equal(t, updateDataInTable(e.backends[1], tableName, []string{"key1"}, []string{"s1"}), nil)
+	ut.Equal(t, updateDataInTable(e.backends[2], tableName, []string{"key1"}, []string{"s2"}), nil)
+	table, err = reader.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	reads := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		tx, err := table.(*client.ProxyTable).BeginReadOnly()
+		ut.Equal(t, err, nil)
+		value, err := tx.Get("key1")
+		ut.Equal(t, err, nil)
+		reads[string(value)] += 1
+		_, err = tx.Get("missing")
+		ut.Equal(t, err, kvzoo.ErrNotFound)
+		ut.Assert(t, tx.Add("newkey", []byte("v")) != nil, "write in read only transaction should fail")
+		ut.Equal(t, tx.Commit(), nil)
+	}
+	ut.Equal(t, reads, map[string]int{"s1": 5, "s2": 5})
+
+	//slaves miss the write of other client, read only transactions begin
+	//on master
+	other, err := client.New("127.0.0.1:7700", nil)
+	ut.Equal(t, err, nil)
+	defer other.Close()
+	ut.Equal(t, loadDataToTable(other, tableName, []string{"o1"}, []string{"v"}), nil)
+	for i := 0; i < 4; i++ {
+		tx, err := table.(*client.ProxyTable).BeginReadOnly()
+		ut.Equal(t, err, nil)
+		value, err := tx.Get("key1")
+		ut.Equal(t, err, nil)
+		ut.Equal(t, string(value), "value1")
+		ut.Equal(t, tx.Commit(), nil)
+	}
+
+	//server rejects writes of read only transaction
+	c, err := client.NewClient("127.0.0.1:7700", client.ConnectTimeout)
+	ut.Equal(t, err, nil)
+	defer c.Close()
+	begin, err := c.BeginTransaction(context.TODO(), &pb.BeginTransactionRequest{
+		TableName: string(tableName),
+		ReadOnly:  true,
+	})
+	ut.Equal(t, err, nil)
+	_, err = c.Add(context.TODO(), &pb.AddRequest{TxId: begin.TxId, Key: "newkey"})
+	ut.Equal(t, status.Code(err), codes.FailedPrecondition)
+	_, err = c.CommitTransaction(context.TODO(), &pb.CommitTransactionRequest{TxId: begin.TxId})
+	ut.Equal(t, err, nil)
+	ut.Assert(t, tableDoesNotHasKeys(e.backends[0], tableName, []string{"newkey"}), "")
+}
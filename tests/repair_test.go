@@ -0,0 +1,104 @@
+package tests
+
+import (
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+)
+
+func TestReplicaRepair(t *testing.T) {
+	e := newTestEnv(t, 3)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 100)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+	ut.Equal(t, loadDataToTable(e.proxy, "/xxxx/yy/zz", keys, values), nil)
+
+	slave1, slave2 := e.backends[1], e.backends[2]
+	ut.Equal(t, updateDataInTable(slave1, tableName, []string{"key1"}, []string{"v1"}), nil)
+	ut.Equal(t, deleteDataInTable(slave1, tableName, []string{"key2"}, []string{""}), nil)
+	ut.Equal(t, loadDataToTable(slave1, tableName, []string{"newkey"}, []string{"v"}), nil)
+	ut.Equal(t, slave2.DeleteTable("/xxxx/yy"), nil)
+	_, err := slave2.CreateOrGetTable("/zzzz")
+	ut.Equal(t, err, nil)
+
+	proxy := e.proxy.(*client.Proxy)
+	result, err := proxy.Repair(kvzoo.Root)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, *result, client.RepairResult{
+		DivergentTables: 3,
+		RepairedTables:  2,
+		RepairedKeys:    103,
+	})
+	e.checkTableHasData(t, tableName, keys, values)
+	e.checkTableHasData(t, "/xxxx/yy/zz", keys, values)
+	_, err = e.proxy.Checksum()
+	ut.Equal(t, err, nil)
+
+	result, err = proxy.Repair(kvzoo.Root)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, *result, client.RepairResult{})
+	stats := proxy.RepairStats()
+	ut.Equal(t, stats.Rounds, int64(2))
+	ut.Equal(t, stats.RepairedKeys, int64(103))
+}
+
+func TestAntiEntropy(t *testing.T) {
+	e := newTestEnv(t, 2)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+	ut.Equal(t, deleteDataInTable(e.backends[1], tableName, keys, values), nil)
+
+	proxy := e.proxy.(*client.Proxy)
+	proxy.StartAntiEntropy(client.AntiEntropyConfig{
+		Interval:         100 * time.Millisecond,
+		MaxKeysPerSecond: 100,
+	})
+	start := time.Now()
+	for proxy.RepairStats().RepairedKeys < int64(len(keys)) {
+		ut.Assert(t, time.Since(start) < 5*time.Second, "anti entropy doesn't repair keys in time")
+		time.Sleep(50 * time.Millisecond)
+	}
+	proxy.StopAntiEntropy()
+	//10 keys at 100 keys per second
+	ut.Assert(t, time.Since(start) > 90*time.Millisecond, "")
+	e.checkTableHasData(t, tableName, keys, values)
+}
+
+func TestRateLimitedRepairDoesNotBlockWrites(t *testing.T) {
+	e := newTestEnv(t, 2)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 200)
+	ut.Equal(t, loadDataToTable(e.proxy, tableName, keys, values), nil)
+	ut.Equal(t, deleteDataInTable(e.backends[1], tableName, keys, values), nil)
+
+	proxy := e.proxy.(*client.Proxy)
+	repaired := make(chan error)
+	start := time.Now()
+	go func() {
+		_, err := proxy.RepairWithRate(tableName, 200)
+		repaired <- err
+	}()
+
+	//transactions are only held while a chunk is copied, not while the
+	//limiter waits
+	time.Sleep(100 * time.Millisecond)
+	writeStart := time.Now()
+	ut.Equal(t, updateDataInTable(e.proxy, tableName, []string{"key0"}, []string{"new value"}), nil)
+	ut.Assert(t, time.Since(writeStart) < 300*time.Millisecond, "write is blocked by repair")
+
+	ut.Equal(t, <-repaired, nil)
+	//200 keys at 200 keys per second
+	ut.Assert(t, time.Since(start) > 900*time.Millisecond, "")
+	values[0] = "new value"
+	e.checkTableHasData(t, tableName, keys, values)
+}
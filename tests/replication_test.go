@@ -116,3 +116,34 @@ func TestDBReplication(t *testing.T) {
 	_, err = e.proxy.Checksum()
 	ut.Assert(t, err == nil, "")
 }
+
+func TestReplicaStats(t *testing.T) {
+	e := newTestEnv(t, 3)
+	defer e.clean()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 100)
+	err := loadDataToTable(e.proxy, tableName, keys, values)
+	ut.Equal(t, err, nil)
+
+	proxy := e.proxy.(*client.Proxy)
+	stats, err := proxy.ReplicaStats(tableName)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(stats), 3)
+	for _, s := range stats {
+		ut.Equal(t, s.Err, nil)
+		ut.Assert(t, s.Stats.SameSize(stats[0].Stats), "")
+	}
+
+	err = loadDataToTable(e.backends[2], tableName, []string{"k1"}, []string{"v1"})
+	ut.Equal(t, err, nil)
+	stats, err = proxy.ReplicaStats(tableName)
+	ut.Equal(t, err, nil)
+	ut.Assert(t, stats[1].Stats.SameSize(stats[0].Stats), "")
+	ut.Assert(t, stats[2].Stats.SameSize(stats[0].Stats) == false, "")
+	ut.Equal(t, stats[2].Stats.KeyCount, 101)
+
+	master, err := e.proxy.Stats(tableName)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, master.KeyCount, 100)
+}
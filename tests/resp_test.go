@@ -0,0 +1,221 @@
+package tests
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+type respClient struct {
+	t      *testing.T
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func newRESPClient(t *testing.T, addr string) *respClient {
+	conn, err := net.Dial("tcp", addr)
+	ut.Equal(t, err, nil)
+	return &respClient{
+		t:      t,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+	}
+}
+
+// do sends command and returns reply, simple string and error are returned
+// with their prefix, null bulk string is returned as nil
+func (c *respClient) do(args ...string) interface{} {
+	cmd := fmt.Sprintf("*%d\r\n", len(args))
+	for _, arg := range args {
+		cmd += fmt.Sprintf("$%d\r\n%s\r\n", len(arg), arg)
+	}
+	_, err := c.conn.Write([]byte(cmd))
+	ut.Equal(c.t, err, nil)
+	return c.read()
+}
+
+func (c *respClient) read() interface{} {
+	line, err := c.reader.ReadString('\n')
+	ut.Equal(c.t, err, nil)
+	line = strings.TrimSuffix(line, "\r\n")
+	switch line[0] {
+	case '+', '-':
+		return line
+	case ':':
+		n, err := strconv.ParseInt(line[1:], 10, 64)
+		ut.Equal(c.t, err, nil)
+		return n
+	case '$':
+		size, _ := strconv.Atoi(line[1:])
+		if size < 0 {
+			return nil
+		}
+		data := make([]byte, size+2)
+		_, err := io.ReadFull(c.reader, data)
+		ut.Equal(c.t, err, nil)
+		return string(data[:size])
+	case '*':
+		n, _ := strconv.Atoi(line[1:])
+		values := make([]interface{}, 0, n)
+		for i := 0; i < n; i++ {
+			values = append(values, c.read())
+		}
+		return values
+	}
+	c.t.Fatalf("unknown reply %s", line)
+	return nil
+}
+
+func TestRESP(t *testing.T) {
+	addr, respAddr, slaveAddr := "127.0.0.1:7797", "127.0.0.1:7798", "127.0.0.1:7813"
+	slave, err := server.NewWithBoltDB(slaveAddr, "rs2.db")
+	ut.Equal(t, err, nil)
+	go slave.Start()
+	defer slave.Stop()
+	//writer retries until master starts
+	writer, err := client.New(addr, []string{slaveAddr}, client.WithToken("admin-token"), client.WithRetryPolicy(client.DefaultRetryPolicy))
+	ut.Equal(t, err, nil)
+
+	s, err := server.NewWithBoltDB(addr, "rs1.db",
+		server.WithAuthenticator(server.NewTokenAuthenticator(map[string]string{
+			"admin-token":  "admin",
+			"reader-token": "reader",
+		})),
+		server.WithACL([]server.ACLRule{
+			{Identity: "admin", Table: kvzoo.Root, Permission: server.PermAdmin},
+			{Identity: "reader", Table: "/cache", Permission: server.PermRead},
+		}),
+		server.WithRESP(respAddr, map[int]kvzoo.TableName{0: "/cache", 1: "/app/sessions"}),
+		server.WithWriter(writer))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+	defer writer.Destroy()
+	slaveProxy, err := client.New(slaveAddr, nil)
+	ut.Equal(t, err, nil)
+	defer slaveProxy.Close()
+	time.Sleep(100 * time.Millisecond)
+
+	//commands are limited before authentication
+	c := newRESPClient(t, respAddr)
+	_, err = c.conn.Write([]byte("*100\r\n"))
+	ut.Equal(t, err, nil)
+	ut.Equal(t, c.read(), "-ERR Protocol error: invalid multibulk length")
+	c.conn.Close()
+	c = newRESPClient(t, respAddr)
+	_, err = c.conn.Write([]byte(fmt.Sprintf("*2\r\n$3\r\nGET\r\n$%d\r\n", 1024*1024)))
+	ut.Equal(t, err, nil)
+	ut.Equal(t, c.read(), "-ERR Protocol error: invalid bulk length")
+	c.conn.Close()
+
+	c = newRESPClient(t, respAddr)
+	defer c.conn.Close()
+	ut.Equal(t, c.do("PING"), "+PONG")
+	ut.Equal(t, c.do("GET", "k1"), "-NOAUTH Authentication required.")
+	ut.Equal(t, c.do("AUTH", "wrong-token"), "-WRONGPASS invalid username-password pair")
+	ut.Equal(t, c.do("AUTH", "default", "admin-token"), "+OK")
+
+	//reads of table which doesn't exist are empty
+	ut.Equal(t, c.do("GET", "k1"), nil)
+	ut.Equal(t, c.do("KEYS", "*"), []interface{}{})
+	ut.Equal(t, c.do("SCAN", "0"), []interface{}{"0", []interface{}{}})
+
+	reader := newRESPClient(t, respAddr)
+	defer reader.conn.Close()
+	ut.Equal(t, reader.do("AUTH", "reader-token"), "+OK")
+	ut.Equal(t, reader.do("SET", "k1", "v1"), "-NOPERM reader has no write permission on /cache")
+
+	//SET and DEL reach slave through writer
+	ut.Equal(t, c.do("SET", "k1", "v1"), "+OK")
+	ut.Equal(t, c.do("SET", "k1", "v2", "NX"), nil)
+	ut.Equal(t, c.do("SET", "k2", "v2", "XX"), nil)
+	ut.Equal(t, c.do("SET", "k1", "v2", "XX"), "+OK")
+	ut.Equal(t, c.do("SET", "k3", "v3"), "+OK")
+	ut.Equal(t, c.do("DEL", "k3", "k4"), int64(1))
+	ut.Equal(t, reader.do("GET", "k1"), "v2")
+	ut.Assert(t, tableHasData(slaveProxy, "/cache", []string{"k1"}, []string{"v2"}), "")
+	ut.Assert(t, tableDoesNotHasKeys(slaveProxy, "/cache", []string{"k3"}), "")
+	ut.Equal(t, c.do("get", "k2"), nil)
+	ut.Equal(t, c.do("GET"), "-ERR wrong number of arguments for 'get' command")
+	ut.Equal(t, c.do("HGET", "h", "k"), "-ERR unknown command 'HGET'")
+
+	//database is mapped to table
+	keys, values := genData("user:", "x", 5)
+	keys = append(keys, "order:1", "s1")
+	values = append(values, "x", "a")
+	ut.Equal(t, loadDataToTable(writer, "/app/sessions", keys, values), nil)
+	ut.Equal(t, c.do("SELECT", "1"), "+OK")
+	ut.Equal(t, c.do("GET", "k1"), nil)
+	ut.Equal(t, c.do("EXISTS", "user:1", "user:9", "order:1"), int64(2))
+	ut.Equal(t, c.do("KEYS", "user:[1-2]"), []interface{}{"user:1", "user:2"})
+	ut.Equal(t, c.do("SCAN", "0", "COUNT", "4"), []interface{}{"1", []interface{}{"order:1", "s1", "user:0", "user:1"}})
+	ut.Equal(t, c.do("SCAN", "1", "MATCH", "user:*", "COUNT", "2"), []interface{}{"2", []interface{}{"user:2", "user:3"}})
+	//cursor is dropped after it's used
+	ut.Equal(t, c.do("SCAN", "1"), "-ERR invalid cursor")
+	ut.Equal(t, c.do("SCAN", "2", "COUNT", "1"), []interface{}{"0", []interface{}{"user:4"}})
+	ut.Equal(t, c.do("SELECT", "2"), "-ERR DB index is out of range")
+
+	//multi and exec run in one transaction
+	ut.Equal(t, c.do("SELECT", "/app/sessions"), "+OK")
+	ut.Equal(t, c.do("MULTI"), "+OK")
+	ut.Equal(t, c.do("GET", "s1"), "+QUEUED")
+	ut.Equal(t, c.do("EXISTS", "order:1", "s2"), "+QUEUED")
+	ut.Equal(t, c.do("EXEC"), []interface{}{"a", int64(1)})
+
+	ut.Equal(t, c.do("MULTI"), "+OK")
+	ut.Equal(t, c.do("GET", "s1"), "+QUEUED")
+	ut.Equal(t, c.do("SET", "s2", "b"), "+QUEUED")
+	ut.Equal(t, c.do("DEL", "s1"), "+QUEUED")
+	ut.Equal(t, c.do("KEYS", "s*"), "+QUEUED")
+	ut.Equal(t, c.do("EXEC"), []interface{}{"a", "+OK", int64(1), []interface{}{"s2"}})
+	ut.Assert(t, tableHasData(slaveProxy, "/app/sessions", []string{"s2"}, []string{"b"}), "")
+	ut.Assert(t, tableDoesNotHasKeys(slaveProxy, "/app/sessions", []string{"s1"}), "")
+
+	ut.Equal(t, c.do("MULTI"), "+OK")
+	ut.Equal(t, c.do("GET", "s1"), "+QUEUED")
+	ut.Equal(t, c.do("INCR", "s3"), "-ERR unknown command 'INCR'")
+	ut.Equal(t, c.do("EXEC"), "-EXECABORT Transaction discarded because of previous errors.")
+	ut.Equal(t, c.do("MULTI"), "+OK")
+	ut.Equal(t, c.do("GET"), "-ERR wrong number of arguments for 'get' command")
+	ut.Equal(t, c.do("EXEC"), "-EXECABORT Transaction discarded because of previous errors.")
+	ut.Equal(t, c.do("MULTI"), "+OK")
+	ut.Equal(t, c.do("GET", "s1"), "+QUEUED")
+	ut.Equal(t, c.do("DISCARD"), "+OK")
+	ut.Equal(t, c.do("EXEC"), "-ERR EXEC without MULTI")
+	ut.Equal(t, c.do("QUIT"), "+OK")
+}
+
+func TestRESPWithoutWriter(t *testing.T) {
+	addr, respAddr := "127.0.0.1:7814", "127.0.0.1:7815"
+	s, err := server.NewWithBoltDB(addr, "rs3.db", server.WithRESP(respAddr, nil))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+	proxy, err := client.New(addr, nil)
+	ut.Equal(t, err, nil)
+	defer proxy.Destroy()
+	ut.Equal(t, loadDataToTable(proxy, "/redis/0", []string{"k1"}, []string{"v1"}), nil)
+	time.Sleep(100 * time.Millisecond)
+
+	//server without writer acts like a redis replica
+	c := newRESPClient(t, respAddr)
+	defer c.conn.Close()
+	ut.Equal(t, c.do("SET", "k1", "v2"), "-READONLY You can't write against a read only replica.")
+	ut.Equal(t, c.do("DEL", "k1"), "-READONLY You can't write against a read only replica.")
+	ut.Equal(t, c.do("MULTI"), "+OK")
+	ut.Equal(t, c.do("GET", "k1"), "+QUEUED")
+	ut.Equal(t, c.do("SET", "k2", "v2"), "-READONLY You can't write against a read only replica.")
+	ut.Equal(t, c.do("EXEC"), "-EXECABORT Transaction discarded because of previous errors.")
+	ut.Equal(t, c.do("GET", "k1"), "v1")
+}
@@ -0,0 +1,171 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/backend/bolt"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	policy := client.DefaultRetryPolicy
+	for i := 0; i < 100; i++ {
+		backoff := policy.Backoff(1)
+		ut.Assert(t, backoff >= 80*time.Millisecond && backoff <= 120*time.Millisecond, "")
+		backoff = policy.Backoff(3)
+		ut.Assert(t, backoff >= 320*time.Millisecond && backoff <= 480*time.Millisecond, "")
+		backoff = policy.Backoff(20)
+		ut.Assert(t, backoff >= 4*time.Second && backoff <= 6*time.Second, "")
+	}
+}
+
+func TestRetryOnMasterRestart(t *testing.T) {
+	addr := "127.0.0.1:7784"
+	startServer := func() (kvzoo.DB, *server.KVGRPCServer) {
+		db, err := bolt.New("r1.db")
+		ut.Equal(t, err, nil)
+		s, err := server.New(addr, db)
+		ut.Equal(t, err, nil)
+		go s.Start()
+		return db, s
+	}
+	db, s := startServer()
+
+	proxy, err := client.New(addr, nil, client.WithRetryPolicy(client.RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}))
+	ut.Equal(t, err, nil)
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	attempts := 0
+	err = proxy.(*client.Proxy).Update(tableName, func(tx kvzoo.Transaction) error {
+		attempts += 1
+		if err := tx.Add("k1", []byte("v1")); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			return server.ErrTooManyTransactions
+		}
+		return nil
+	})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, attempts, 2)
+	ut.Assert(t, tableHasData(db, tableName, []string{"k1"}, []string{"v1"}), "")
+
+	attempts = 0
+	err = proxy.(*client.Proxy).Update(tableName, func(tx kvzoo.Transaction) error {
+		attempts += 1
+		return fmt.Errorf("bad data")
+	})
+	ut.Assert(t, err != nil, "")
+	ut.Equal(t, attempts, 1)
+
+	cs, err := db.Checksum()
+	ut.Equal(t, err, nil)
+	s.Stop()
+	restarted := make(chan *server.KVGRPCServer, 1)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_, s := startServer()
+		restarted <- s
+	}()
+	newCs, err := proxy.Checksum()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, newCs, cs)
+	s = <-restarted
+
+	//read in transaction isn't retried, since the restarted server doesn't
+	//know the transaction, it fails the transaction instead
+	table, err := proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	tx, err := table.Begin()
+	ut.Equal(t, err, nil)
+	s.Stop()
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		_, s := startServer()
+		restarted <- s
+	}()
+	_, err = tx.Get("k1")
+	ut.Assert(t, client.IsRetriable(err), "get in transaction shouldn't be retried")
+	tx.Rollback()
+	s = <-restarted
+	_, err = proxy.Checksum()
+	ut.Equal(t, err, nil)
+
+	//reads of buffered transaction are batches without transaction, which
+	//are retried
+	ut.Assert(t, tableHasData(proxy, tableName, []string{"k1"}, []string{"v1"}), "")
+	tx, err = table.Begin()
+	ut.Equal(t, err, nil)
+	s.SetMaxOpenTxCount(0)
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		tx.Rollback()
+	}()
+	buffered, err := table.(*client.ProxyTable).BeginBuffered()
+	ut.Equal(t, err, nil)
+	value, err := buffered.Get("k1")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, string(value), "v1")
+	ut.Equal(t, buffered.Rollback(), nil)
+	s.SetMaxOpenTxCount(server.MaxOpenTxCount)
+
+	ut.Equal(t, proxy.Destroy(), nil)
+	s.Stop()
+}
+
+func TestNoReplayAfterCommitFailure(t *testing.T) {
+	addr := "127.0.0.1:7809"
+	startServer := func() *server.KVGRPCServer {
+		s, err := server.NewWithBoltDB(addr, "r2.db")
+		ut.Equal(t, err, nil)
+		go s.Start()
+		return s
+	}
+	s := startServer()
+
+	proxy, err := client.New(addr, nil, client.WithRetryPolicy(client.RetryPolicy{
+		MaxAttempts:    10,
+		InitialBackoff: 100 * time.Millisecond,
+		MaxBackoff:     time.Second,
+		Multiplier:     2,
+	}))
+	ut.Equal(t, err, nil)
+
+	//master is unreachable when commit, whether the transaction is
+	//committed is unknown, so it isn't replayed after master is back
+	restarted := make(chan *server.KVGRPCServer, 1)
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	attempts := 0
+	err = proxy.(*client.Proxy).Update(tableName, func(tx kvzoo.Transaction) error {
+		attempts += 1
+		if err := tx.Add("k1", []byte("v1")); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			s.Stop()
+			go func() {
+				time.Sleep(300 * time.Millisecond)
+				restarted <- startServer()
+			}()
+		}
+		return nil
+	})
+	ut.Assert(t, err != nil, "")
+	ut.Equal(t, attempts, 1)
+	s = <-restarted
+	_, err = proxy.Checksum()
+	ut.Equal(t, err, nil)
+
+	ut.Equal(t, proxy.Destroy(), nil)
+	s.Stop()
+}
@@ -0,0 +1,217 @@
+package tests
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	"github.com/zdnscloud/kvzoo/internal/tlsutil"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+var nextSerial int64 = 1
+
+// genCert creates a certificate signed by parent, self signed ca is created
+// if parent is nil
+func genCert(t *testing.T, name string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ut.Equal(t, err, nil)
+
+	nextSerial += 1
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(nextSerial),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer := &testCert{cert: template, key: key}
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+	} else {
+		signer = parent
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer.cert, &key.PublicKey, signer.key)
+	ut.Equal(t, err, nil)
+	cert, err := x509.ParseCertificate(der)
+	ut.Equal(t, err, nil)
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) writeTo(t *testing.T, certFile, keyFile string) {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+	ut.Equal(t, ioutil.WriteFile(certFile, certPEM, 0600), nil)
+	if keyFile != "" {
+		der, err := x509.MarshalECPrivateKey(c.key)
+		ut.Equal(t, err, nil)
+		keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+		ut.Equal(t, ioutil.WriteFile(keyFile, keyPEM, 0600), nil)
+	}
+}
+
+func checkTLSClient(t *testing.T, addr string, conf *client.TLSConfig) error {
+	c, err := client.NewTLSClient(addr, time.Second, conf)
+	ut.Equal(t, err, nil)
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	return c.CheckHealth(ctx)
+}
+
+func TestMutualTLS(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kvzoo-tls")
+	ut.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+	file := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	ca := genCert(t, "ca", nil)
+	ca.writeTo(t, file("ca.pem"), "")
+	genCert(t, "server", ca).writeTo(t, file("server.pem"), file("server.key"))
+	genCert(t, "client", ca).writeTo(t, file("client.pem"), file("client.key"))
+	otherCA := genCert(t, "other ca", nil)
+	genCert(t, "other client", otherCA).writeTo(t, file("other.pem"), file("other.key"))
+
+	addr := "127.0.0.1:7785"
+	s, err := server.NewWithBoltDB(addr, "t1.db", server.WithTLS(server.TLSConfig{
+		CertFile:     file("server.pem"),
+		KeyFile:      file("server.key"),
+		ClientCAFile: file("ca.pem"),
+	}))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+
+	conf := client.TLSConfig{
+		CAFile:   file("ca.pem"),
+		CertFile: file("client.pem"),
+		KeyFile:  file("client.key"),
+	}
+	ut.Equal(t, checkTLSClient(t, addr, &conf), nil)
+
+	proxy, err := client.New(addr, nil, client.WithTLS(conf))
+	ut.Equal(t, err, nil)
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	keys, values := genData("key", "value", 10)
+	ut.Equal(t, loadDataToTable(proxy, tableName, keys, values), nil)
+	ut.Assert(t, tableHasData(proxy, tableName, keys, values), "")
+	proxy.Destroy()
+
+	ut.Assert(t, checkTLSClient(t, addr, nil) != nil, "plaintext client should be rejected")
+	ut.Assert(t, checkTLSClient(t, addr, &client.TLSConfig{
+		CAFile: file("ca.pem"),
+	}) != nil, "client without certificate should be rejected")
+	ut.Assert(t, checkTLSClient(t, addr, &client.TLSConfig{
+		CAFile:   file("ca.pem"),
+		CertFile: file("other.pem"),
+		KeyFile:  file("other.key"),
+	}) != nil, "client certificate signed by other ca should be rejected")
+	ut.Assert(t, checkTLSClient(t, addr, &client.TLSConfig{
+		CertFile: file("client.pem"),
+		KeyFile:  file("client.key"),
+	}) != nil, "server certificate signed by unknown ca should be rejected")
+}
+
+func TestKeyPairReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kvzoo-tls")
+	ut.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "cert.key")
+
+	ca := genCert(t, "ca", nil)
+	first := genCert(t, "first", ca)
+	first.writeTo(t, certFile, keyFile)
+	kp, err := tlsutil.NewKeyPair(certFile, keyFile)
+	ut.Equal(t, err, nil)
+	cert, err := kp.Certificate()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, cert.Certificate[0], first.cert.Raw)
+
+	//broken files keep the old certificate
+	ut.Equal(t, ioutil.WriteFile(certFile, []byte("broken"), 0600), nil)
+	future := time.Now().Add(time.Minute)
+	ut.Equal(t, os.Chtimes(certFile, future, future), nil)
+	cert, err = kp.Certificate()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, cert.Certificate[0], first.cert.Raw)
+
+	second := genCert(t, "second", ca)
+	second.writeTo(t, certFile, keyFile)
+	future = future.Add(time.Minute)
+	ut.Equal(t, os.Chtimes(certFile, future, future), nil)
+	ut.Equal(t, os.Chtimes(keyFile, future, future), nil)
+	cert, err = kp.Certificate()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, cert.Certificate[0], second.cert.Raw)
+}
+
+func TestServerCAReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kvzoo-tls")
+	ut.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+	file := func(name string) string {
+		return filepath.Join(dir, name)
+	}
+
+	ca := genCert(t, "ca", nil)
+	genCert(t, "server", ca).writeTo(t, file("server.pem"), file("server.key"))
+	genCert(t, "other ca", nil).writeTo(t, file("ca.pem"), "")
+
+	addr := "127.0.0.1:7800"
+	s, err := server.NewWithBoltDB(addr, "t2.db", server.WithTLS(server.TLSConfig{
+		CertFile: file("server.pem"),
+		KeyFile:  file("server.key"),
+	}))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+	defer os.Remove("t2.db")
+
+	c, err := client.NewTLSClient(addr, time.Second, &client.TLSConfig{
+		CAFile: file("ca.pem"),
+	})
+	ut.Equal(t, err, nil)
+	defer c.Close()
+	checkHealth := func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		return c.CheckHealth(ctx)
+	}
+	ut.Assert(t, checkHealth() != nil, "server certificate signed by unknown ca should be rejected")
+
+	//new connection of same client verifies server with the renewed ca
+	ca.writeTo(t, file("ca.pem"), "")
+	future := time.Now().Add(time.Minute)
+	ut.Equal(t, os.Chtimes(file("ca.pem"), future, future), nil)
+	for i := 0; i < 50 && checkHealth() != nil; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	ut.Equal(t, checkHealth(), nil)
+}
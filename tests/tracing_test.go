@@ -0,0 +1,227 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	ut "github.com/zdnscloud/cement/unittest"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/server"
+	"github.com/zdnscloud/kvzoo/tracing"
+)
+
+type spanRecorder struct {
+	lock  sync.Mutex
+	spans []tracing.SpanData
+}
+
+func (r *spanRecorder) Export(spans ...tracing.SpanData) error {
+	r.lock.Lock()
+	r.spans = append(r.spans, spans...)
+	r.lock.Unlock()
+	return nil
+}
+
+func (r *spanRecorder) find(service, name string) []tracing.SpanData {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	var spans []tracing.SpanData
+	for _, span := range r.spans {
+		if span.Service == service && span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestTraceparent(t *testing.T) {
+	sc, err := tracing.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, sc.TraceID.String(), "4bf92f3577b34da6a3ce929d0e0e4736")
+	ut.Equal(t, sc.SpanID.String(), "00f067aa0ba902b7")
+	ut.Equal(t, sc.Traceparent(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+
+	for _, s := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473x-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+	} {
+		_, err := tracing.ParseTraceparent(s)
+		ut.Assert(t, err != nil, "%s should be invalid", s)
+	}
+}
+
+func TestFileExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kvzoo-trace")
+	ut.Equal(t, err, nil)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "trace.log")
+	exporter, err := tracing.NewFileExporter(path)
+	ut.Equal(t, err, nil)
+
+	tracer := tracing.NewTracer("app", exporter)
+	ctx, parent := tracer.Start(context.Background(), "parent")
+	_, child := tracer.Start(ctx, "child")
+	child.SetAttribute("count", 3)
+	child.End(fmt.Errorf("timeout"))
+	child.End(nil)
+	parent.End(nil)
+	ut.Equal(t, exporter.Close(), nil)
+
+	f, err := os.Open(path)
+	ut.Equal(t, err, nil)
+	defer f.Close()
+	var spans []tracing.SpanData
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var span tracing.SpanData
+		ut.Equal(t, json.Unmarshal(scanner.Bytes(), &span), nil)
+		spans = append(spans, span)
+	}
+	ut.Equal(t, len(spans), 2)
+	ut.Equal(t, spans[0].Name, "child")
+	ut.Equal(t, spans[0].Err, "timeout")
+	ut.Equal(t, spans[0].Attributes["count"], "3")
+	ut.Equal(t, spans[0].TraceID, spans[1].TraceID)
+	ut.Equal(t, spans[0].ParentID, spans[1].SpanID)
+	ut.Equal(t, spans[1].ParentID, "")
+	ut.Equal(t, spans[1].Service, "app")
+
+	//nil tracer disables tracing
+	var nilTracer *tracing.Tracer
+	ctx, span := nilTracer.Start(context.Background(), "nothing")
+	span.SetAttribute("key", "value")
+	span.End(nil)
+	ut.Assert(t, tracing.SpanFromContext(ctx) == nil, "")
+}
+
+func TestTraceAcrossProxyAndServer(t *testing.T) {
+	recorder := &spanRecorder{}
+	masterAddr, slaveAddr := "127.0.0.1:7792", "127.0.0.1:7793"
+	master, err := server.NewWithBoltDB(masterAddr, "tr1.db", server.WithTracer(tracing.NewTracer("master", recorder)))
+	ut.Equal(t, err, nil)
+	go master.Start()
+	defer master.Stop()
+	slave, err := server.NewWithBoltDB(slaveAddr, "tr2.db", server.WithTracer(tracing.NewTracer("slave", recorder)))
+	ut.Equal(t, err, nil)
+	go slave.Start()
+	defer slave.Stop()
+
+	proxy, err := client.New(masterAddr, []string{slaveAddr}, client.WithTracer(tracing.NewTracer("proxy", recorder)))
+	ut.Equal(t, err, nil)
+	defer proxy.Destroy()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	table, err := proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+
+	app := tracing.NewTracer("app", recorder)
+	ctx, request := app.Start(context.Background(), "request")
+	tx, err := table.(*client.ProxyTable).BeginWithContext(ctx)
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Add("k1", []byte("v1")), nil)
+	value, err := tx.Get("k1")
+	ut.Equal(t, err, nil)
+	ut.Equal(t, string(value), "v1")
+	ut.Equal(t, tx.Commit(), nil)
+	request.End(nil)
+
+	traceID := request.Context().TraceID.String()
+	requestID := request.Context().SpanID.String()
+	spans := recorder.find("proxy", "kvzoo.Transaction")
+	ut.Equal(t, len(spans), 1)
+	txSpan := spans[0]
+	ut.Equal(t, txSpan.TraceID, traceID)
+	ut.Equal(t, txSpan.ParentID, requestID)
+	ut.Equal(t, txSpan.Attributes["table"], string(tableName))
+
+	for _, name := range []string{"kvzoo.Begin", "kvzoo.Add", "kvzoo.Get", "kvzoo.Commit"} {
+		spans := recorder.find("proxy", name)
+		ut.Equal(t, len(spans), 1)
+		ut.Equal(t, spans[0].TraceID, traceID)
+		ut.Equal(t, spans[0].ParentID, txSpan.SpanID)
+	}
+
+	//a child span for each replica, which is parent of the server span
+	add := recorder.find("proxy", "kvzoo.Add")[0]
+	ut.Equal(t, add.Attributes["key"], "k1")
+	replicaSpans := recorder.find("proxy", "pb.KVS/Add")
+	ut.Equal(t, len(replicaSpans), 2)
+	replicas := make(map[string]string)
+	for _, span := range replicaSpans {
+		ut.Equal(t, span.ParentID, add.SpanID)
+		ut.Equal(t, span.Attributes["rpc.code"], "OK")
+		replicas[span.Attributes["replica"]] = span.SpanID
+	}
+	for service, addr := range map[string]string{"master": masterAddr, "slave": slaveAddr} {
+		spans := recorder.find(service, "pb.KVS/Add")
+		ut.Equal(t, len(spans), 1)
+		ut.Equal(t, spans[0].TraceID, traceID)
+		ut.Equal(t, spans[0].ParentID, replicas[addr])
+
+		//backend transaction lasts from begin to commit
+		spans = recorder.find(service, "db.Transaction")
+		ut.Equal(t, len(spans), 1)
+		ut.Equal(t, spans[0].TraceID, traceID)
+		ut.Equal(t, spans[0].Attributes["result"], "commit")
+		ut.Equal(t, spans[0].Attributes["table"], string(tableName))
+		ut.Equal(t, len(recorder.find(service, "db.Commit")), 1)
+	}
+
+	//reads are served by master only
+	ut.Equal(t, len(recorder.find("proxy", "pb.KVS/Get")), 1)
+	ut.Equal(t, len(recorder.find("slave", "pb.KVS/Get")), 0)
+
+	//requests without trace context start new traces
+	ut.Equal(t, loadDataToTable(proxy, tableName, []string{"k2"}, []string{"v2"}), nil)
+	spans = recorder.find("proxy", "kvzoo.Transaction")
+	ut.Equal(t, len(spans), 2)
+	ut.Assert(t, spans[1].TraceID != traceID, "")
+	ut.Equal(t, spans[1].ParentID, "")
+}
+
+func TestTraceDroppedTransactions(t *testing.T) {
+	recorder := &spanRecorder{}
+	addr := "127.0.0.1:7805"
+	s, err := server.NewWithBoltDB(addr, "tr3.db", server.WithTracer(tracing.NewTracer("server", recorder)))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer os.Remove("tr3.db")
+
+	c, err := client.NewClient(addr, client.ConnectTimeout)
+	ut.Equal(t, err, nil)
+	defer c.Close()
+	_, err = c.CreateOrGetTable(context.Background(), &pb.CreateOrGetTableRequest{Name: "/xxxx"})
+	ut.Equal(t, err, nil)
+	var ids []int64
+	for i := 0; i < 2; i++ {
+		reply, err := c.BeginTransaction(context.Background(), &pb.BeginTransactionRequest{TableName: "/xxxx", ReadOnly: true})
+		ut.Equal(t, err, nil)
+		ids = append(ids, reply.TxId)
+	}
+
+	//transactions dropped by abort and server close are finished too
+	_, err = c.AbortTransaction(context.Background(), &pb.AbortTransactionRequest{TxId: ids[0]})
+	ut.Equal(t, err, nil)
+	s.Stop()
+	results := make(map[string]string)
+	for _, span := range recorder.find("server", "db.Transaction") {
+		results[span.Attributes["txId"]] = span.Attributes["result"]
+	}
+	ut.Equal(t, results, map[string]string{
+		fmt.Sprintf("%d", ids[0]): "aborted",
+		fmt.Sprintf("%d", ids[1]): "closed",
+	})
+}
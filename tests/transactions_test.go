@@ -0,0 +1,87 @@
+package tests
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	ut "github.com/zdnscloud/cement/unittest"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/zdnscloud/kvzoo"
+	"github.com/zdnscloud/kvzoo/client"
+	pb "github.com/zdnscloud/kvzoo/proto"
+	"github.com/zdnscloud/kvzoo/server"
+)
+
+func TestListAndAbortTransactions(t *testing.T) {
+	addr := "127.0.0.1:7794"
+	registry := prometheus.NewRegistry()
+	s, err := server.NewWithBoltDB(addr, "tx1.db", server.WithSlowTxThreshold(50*time.Millisecond), server.WithMetrics(registry))
+	ut.Equal(t, err, nil)
+	go s.Start()
+	defer s.Stop()
+
+	proxy, err := client.New(addr, nil)
+	ut.Equal(t, err, nil)
+	defer proxy.Destroy()
+	c, err := client.NewClient(addr, time.Second)
+	ut.Equal(t, err, nil)
+	defer c.Close()
+
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	table, err := proxy.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+	tx, err := table.Begin()
+	ut.Equal(t, err, nil)
+	ut.Equal(t, tx.Add("k1", []byte("v1")), nil)
+	ut.Equal(t, tx.Add("k2", []byte("v2")), nil)
+	_, err = tx.Get("k1")
+	ut.Equal(t, err, nil)
+	//let watchdog report the transaction
+	time.Sleep(100 * time.Millisecond)
+	assertHasLines(t, scrape(t, registry), `kvzoo_server_slow_transactions_total 1`)
+
+	reply, err := c.ListTransactions(context.Background(), &pb.ListTransactionsRequest{})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(reply.Transactions), 1)
+	info := reply.Transactions[0]
+	ut.Equal(t, info.Table, string(tableName))
+	ut.Equal(t, info.OpCount, int64(3))
+	ut.Assert(t, info.Peer != "", "")
+	ut.Assert(t, info.Age >= int64(100*time.Millisecond), "age %d is too small", info.Age)
+
+	reply, err = c.ListTransactions(context.Background(), &pb.ListTransactionsRequest{
+		MinAge: int64(time.Hour),
+	})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, len(reply.Transactions), 0)
+
+	//the open transaction blocks other writers until it's aborted
+	begun := make(chan error)
+	go func() {
+		other, err := table.Begin()
+		if err == nil {
+			err = other.Rollback()
+		}
+		begun <- err
+	}()
+	select {
+	case <-begun:
+		t.Fatal("transaction shouldn't begin while db is held")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = c.AbortTransaction(context.Background(), &pb.AbortTransactionRequest{TxId: info.TxId})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, <-begun, nil)
+
+	err = tx.Add("k3", []byte("v3"))
+	ut.Equal(t, status.Code(err), codes.Aborted)
+	tx.Rollback()
+	_, err = c.AbortTransaction(context.Background(), &pb.AbortTransactionRequest{TxId: info.TxId})
+	ut.Equal(t, status.Code(err), codes.NotFound)
+	ut.Assert(t, tableHasData(proxy, tableName, nil, nil), "")
+}
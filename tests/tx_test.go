@@ -0,0 +1,108 @@
+package tests
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	ut "github.com/zdnscloud/cement/unittest"
+	"github.com/zdnscloud/kvzoo"
+)
+
+type countRetrier struct {
+	maxAttempts int
+}
+
+func (r *countRetrier) Retry(attempt int, err error) (time.Duration, bool) {
+	return time.Millisecond, attempt < r.maxAttempts
+}
+
+func TestBoltDBTxHelper(t *testing.T) {
+	withBoltDB(t, testTxHelper)
+}
+
+func TestRemoteDBTxHelper(t *testing.T) {
+	withRemoteDB(t, testTxHelper)
+}
+
+func testTxHelper(t *testing.T, db kvzoo.DB) {
+	tableName, _ := kvzoo.NewTableName("/xxxx/xx")
+	table, err := db.CreateOrGetTable(tableName)
+	ut.Equal(t, err, nil)
+
+	err = kvzoo.Update(table, func(tx kvzoo.Transaction) error {
+		return tx.Add("k1", []byte("v1"))
+	})
+	ut.Equal(t, err, nil)
+	err = kvzoo.View(table, func(tx kvzoo.Transaction) error {
+		v, err := tx.Get("k1")
+		ut.Equal(t, string(v), "v1")
+		return err
+	})
+	ut.Equal(t, err, nil)
+
+	//view doesn't block writers
+	err = kvzoo.View(table, func(tx kvzoo.Transaction) error {
+		updated := make(chan error)
+		go func() {
+			updated <- kvzoo.Update(table, func(tx kvzoo.Transaction) error {
+				return tx.Update("k1", []byte("v1"))
+			})
+		}()
+		select {
+		case err := <-updated:
+			return err
+		case <-time.After(time.Second):
+			return fmt.Errorf("update is blocked by view")
+		}
+	})
+	ut.Equal(t, err, nil)
+
+	//view is read only
+	err = kvzoo.View(table, func(tx kvzoo.Transaction) error {
+		return tx.Add("k2", []byte("v2"))
+	})
+	ut.Assert(t, err != nil, "write in view should fail")
+	ut.Assert(t, tableDoesNotHasKeys(db, tableName, []string{"k2"}), "")
+
+	err = kvzoo.Update(table, func(tx kvzoo.Transaction) error {
+		tx.Add("k2", []byte("v2"))
+		return fmt.Errorf("bad data")
+	})
+	ut.Equal(t, err.Error(), "bad data")
+	ut.Assert(t, tableDoesNotHasKeys(db, tableName, []string{"k2"}), "")
+
+	func() {
+		defer func() {
+			ut.Equal(t, recover(), "bad data")
+		}()
+		kvzoo.Update(table, func(tx kvzoo.Transaction) error {
+			tx.Add("k2", []byte("v2"))
+			panic("bad data")
+		})
+	}()
+	ut.Assert(t, tableDoesNotHasKeys(db, tableName, []string{"k2"}), "")
+
+	attempts := 0
+	err = kvzoo.UpdateWithRetry(table, func(tx kvzoo.Transaction) error {
+		attempts += 1
+		if err := tx.Add("k2", []byte("v2")); err != nil {
+			return err
+		}
+		if attempts < 3 {
+			return fmt.Errorf("conflict")
+		}
+		return nil
+	}, &countRetrier{maxAttempts: 3})
+	ut.Equal(t, err, nil)
+	ut.Equal(t, attempts, 3)
+	ut.Assert(t, tableHasData(db, tableName, []string{"k1", "k2"}, []string{"v1", "v2"}), "")
+
+	attempts = 0
+	err = kvzoo.UpdateWithRetry(table, func(tx kvzoo.Transaction) error {
+		attempts += 1
+		return fmt.Errorf("conflict")
+	}, &countRetrier{maxAttempts: 2})
+	ut.Equal(t, err.Error(), "conflict")
+	ut.Equal(t, attempts, 2)
+}
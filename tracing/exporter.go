@@ -0,0 +1,59 @@
+package tracing
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// WriterExporter writes spans into writer in json lines
+type WriterExporter struct {
+	lock   sync.Mutex
+	w      io.Writer
+	closer io.Closer
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{w: w}
+}
+
+// NewStdoutExporter is used to debug tracing locally
+func NewStdoutExporter() *WriterExporter {
+	return NewWriterExporter(os.Stdout)
+}
+
+// NewFileExporter appends spans to file, the file is closed by Close
+func NewFileExporter(path string) (*WriterExporter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return nil, err
+	}
+	return &WriterExporter{
+		w:      f,
+		closer: f,
+	}, nil
+}
+
+func (e *WriterExporter) Export(spans ...SpanData) error {
+	var buf []byte
+	for _, span := range spans {
+		line, err := json.Marshal(span)
+		if err != nil {
+			return err
+		}
+		buf = append(append(buf, line...), '\n')
+	}
+
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	_, err := e.w.Write(buf)
+	return err
+}
+
+func (e *WriterExporter) Close() error {
+	if e.closer == nil {
+		return nil
+	}
+	return e.closer.Close()
+}
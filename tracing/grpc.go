@@ -0,0 +1,102 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// TraceparentKey is the grpc metadata key which carries span context
+const TraceparentKey = "traceparent"
+
+// Inject adds the span context in ctx to outgoing metadata
+func Inject(ctx context.Context) context.Context {
+	s := SpanFromContext(ctx)
+	if s == nil {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, TraceparentKey, s.sc.Traceparent())
+}
+
+// Extract returns context whose spans are children of the span context in
+// incoming metadata, invalid span context is ignored
+func Extract(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok == false {
+		return ctx
+	}
+	values := md.Get(TraceparentKey)
+	if len(values) == 0 {
+		return ctx
+	}
+	sc, err := ParseTraceparent(values[0])
+	if err != nil {
+		return ctx
+	}
+	return ContextWithRemoteParent(ctx, sc)
+}
+
+// "/pb.KVS/Get" is named as "pb.KVS/Get"
+func spanName(method string) string {
+	return strings.TrimPrefix(method, "/")
+}
+
+func setCode(span *Span, err error) {
+	span.SetAttribute("rpc.code", status.Code(err).String())
+}
+
+// UnaryClientInterceptor records a span for each request sent to replica
+// and propagates it to server
+func UnaryClientInterceptor(t *Tracer, replica string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, span := t.Start(ctx, spanName(method))
+		span.SetAttribute("replica", replica)
+		err := invoker(Inject(ctx), method, req, reply, cc, opts...)
+		setCode(span, err)
+		span.End(err)
+		return err
+	}
+}
+
+func startServerSpan(t *Tracer, ctx context.Context, method string) (context.Context, *Span) {
+	ctx, span := t.Start(Extract(ctx), spanName(method))
+	if p, ok := peer.FromContext(ctx); ok {
+		span.SetAttribute("peer", p.Addr.String())
+	}
+	return ctx, span
+}
+
+// UnaryServerInterceptor records a span for each request handled by server,
+// the span is child of the client span if trace context is propagated
+func UnaryServerInterceptor(t *Tracer) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, span := startServerSpan(t, ctx, info.FullMethod)
+		reply, err := handler(ctx, req)
+		setCode(span, err)
+		span.End(err)
+		return reply, err
+	}
+}
+
+func StreamServerInterceptor(t *Tracer) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, span := startServerSpan(t, ss.Context(), info.FullMethod)
+		err := handler(srv, &tracedStream{ServerStream: ss, ctx: ctx})
+		setCode(span, err)
+		span.End(err)
+		return err
+	}
+}
+
+type tracedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracedStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,207 @@
+// Package tracing records spans of requests which go through proxy and
+// server, trace context is propagated in grpc metadata with w3c
+// traceparent format, finished spans are sent to a pluggable exporter
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// SpanContext identifies a span across process boundary
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats sc as w3c traceparent header, spans are always sampled
+func (sc SpanContext) Traceparent() string {
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-01"
+}
+
+// ParseTraceparent parses w3c traceparent header, flags are ignored
+func ParseTraceparent(s string) (SpanContext, error) {
+	var sc SpanContext
+	fields := strings.Split(s, "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || fields[0] == "ff" {
+		return sc, fmt.Errorf("invalid traceparent %s", s)
+	}
+	if len(fields[1]) != 32 || len(fields[2]) != 16 {
+		return sc, fmt.Errorf("invalid traceparent %s", s)
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(fields[1])); err != nil {
+		return sc, fmt.Errorf("invalid trace id %s:%s", fields[1], err.Error())
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(fields[2])); err != nil {
+		return sc, fmt.Errorf("invalid span id %s:%s", fields[2], err.Error())
+	}
+	if sc.IsValid() == false {
+		return sc, fmt.Errorf("invalid traceparent %s", s)
+	}
+	return sc, nil
+}
+
+// SpanData is a finished span which is sent to exporter
+type SpanData struct {
+	TraceID  string    `json:"traceId"`
+	SpanID   string    `json:"spanId"`
+	ParentID string    `json:"parentId,omitempty"`
+	Service  string    `json:"service,omitempty"`
+	Name     string    `json:"name"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	//duration in microseconds
+	Duration   int64             `json:"duration"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+	//empty means the operation succeeded
+	Err string `json:"err,omitempty"`
+}
+
+type Exporter interface {
+	Export(spans ...SpanData) error
+}
+
+// Tracer creates spans and exports them once they end, methods of nil
+// Tracer and nil Span do nothing, so tracing could be disabled by nil Tracer
+type Tracer struct {
+	service  string
+	exporter Exporter
+}
+
+func NewTracer(service string, exporter Exporter) *Tracer {
+	return &Tracer{
+		service:  service,
+		exporter: exporter,
+	}
+}
+
+type Span struct {
+	tracer *Tracer
+	sc     SpanContext
+
+	lock  sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// Start creates span which is child of the span or remote span context in
+// ctx, the returned context holds the new span
+func (t *Tracer) Start(ctx context.Context, name string) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+
+	var parent SpanContext
+	if s := SpanFromContext(ctx); s != nil {
+		parent = s.sc
+	} else if sc, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+		parent = sc
+	}
+
+	s := &Span{tracer: t}
+	if parent.IsValid() {
+		s.sc.TraceID = parent.TraceID
+		s.data.ParentID = parent.SpanID.String()
+	} else {
+		randomID(s.sc.TraceID[:])
+	}
+	randomID(s.sc.SpanID[:])
+	s.data.TraceID = s.sc.TraceID.String()
+	s.data.SpanID = s.sc.SpanID.String()
+	s.data.Service = t.service
+	s.data.Name = name
+	s.data.Start = time.Now()
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// crypto/rand is safe for concurrent use
+func randomID(id []byte) {
+	if _, err := rand.Read(id); err != nil {
+		binary.BigEndian.PutUint64(id[len(id)-8:], uint64(time.Now().UnixNano()))
+	}
+}
+
+func SpanFromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// ContextWithRemoteParent returns context whose spans are children of the
+// span created by other process
+func ContextWithRemoteParent(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+func (s *Span) Context() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.sc
+}
+
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = make(map[string]string)
+	}
+	s.data.Attributes[key] = fmt.Sprint(value)
+	s.lock.Unlock()
+}
+
+// End finishes the span with the result of operation and exports it, only
+// the first call takes effect
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	s.data.Duration = int64(s.data.End.Sub(s.data.Start) / time.Microsecond)
+	if err != nil {
+		s.data.Err = err.Error()
+	}
+	data := s.data
+	s.lock.Unlock()
+
+	s.tracer.exporter.Export(data)
+}
@@ -0,0 +1,64 @@
+package kvzoo
+
+import (
+	"time"
+)
+
+// View runs fn in a transaction of table, the transaction is always rolled
+// back, so fn shouldn't change data. read only transaction is used if table
+// supports it, which doesn't block writers, and writes in it fail
+func View(table Table, fn func(Transaction) error) error {
+	var tx Transaction
+	var err error
+	if t, ok := table.(ReadOnlyTable); ok {
+		tx, err = t.BeginReadOnly()
+	} else {
+		tx, err = table.Begin()
+	}
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	return fn(tx)
+}
+
+// Update runs fn in a transaction of table, the transaction is committed if
+// fn returns nil, otherwise it's rolled back, so does fn panics
+func Update(table Table, fn func(Transaction) error) error {
+	tx, err := table.Begin()
+	if err != nil {
+		return err
+	}
+	//rollback after commit has no effect
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Retrier decides whether a failed transaction should run again, attempt
+// starts from 1, returns the time to wait before next attempt
+type Retrier interface {
+	Retry(attempt int, err error) (time.Duration, bool)
+}
+
+// UpdateWithRetry is same with Update except the whole transaction is run
+// again if retrier allows, fn may run several times, it shouldn't have side
+// effect out of the transaction
+func UpdateWithRetry(table Table, fn func(Transaction) error, retrier Retrier) error {
+	for attempt := 1; ; attempt++ {
+		err := Update(table, fn)
+		if err == nil {
+			return nil
+		}
+
+		backoff, ok := retrier.Retry(attempt, err)
+		if ok == false {
+			return err
+		}
+		time.Sleep(backoff)
+	}
+}